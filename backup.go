@@ -0,0 +1,52 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/WagnerJust/go-gator/internal/backup"
+)
+
+// handlerBackup saves the whole database to a new snapshot file.
+func handlerBackup(s *state, cmd Command) error {
+	path := cmd.Args[0]
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0600)
+	if errors.Is(err, os.ErrExist) {
+		return fmt.Errorf("%s already exists; choose a new file", path)
+	}
+	if err != nil {
+		return err
+	}
+	manifest, err := backup.Write(context.Background(), s.Db, file)
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(path)
+		return err
+	}
+	fmt.Printf("Backed up %s to %s\n", manifest.Summary(), path)
+	return nil
+}
+
+// handlerRestore loads a snapshot from backup, or one reset saved, into an
+// empty database.
+func handlerRestore(s *state, cmd Command) error {
+	path := cmd.Args[0]
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	manifest, err := backup.Restore(context.Background(), s.Db, file)
+	if errors.Is(err, backup.ErrNotEmpty) {
+		return fmt.Errorf("%w; run %s reset first, which saves a snapshot of what's there now", err, programName)
+	}
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Restored %s from %s\n", manifest.Summary(), path)
+	return nil
+}
@@ -1,87 +1,674 @@
 package main
 
 import (
+	"context"
 	"database/sql"
+	"errors"
+	"flag"
 	"fmt"
+	"io"
 	"os"
+	"strings"
 
 	"github.com/WagnerJust/go-gator/internal/config"
-	"github.com/WagnerJust/go-gator/internal/database"
-	_ "github.com/lib/pq"
+	"github.com/WagnerJust/go-gator/internal/output"
+	"github.com/WagnerJust/go-gator/internal/render"
+	"github.com/WagnerJust/go-gator/internal/rss"
+	"github.com/WagnerJust/go-gator/internal/storage"
 )
 
+const programName = "go-gator"
+
 type state struct {
 	Config *config.Config
-	Db *database.Queries
+	Db storage.Store
+	Output output.Format
+	// UserOverride is the --user flag: the user commands act as for this
+	// run, without changing who is logged in.
+	UserOverride string
+	Verbose bool
+	Fetcher *rss.Fetcher
+	// logOutput receives logf diagnostics: stderr, or logging.file.
+	logOutput io.Writer
+	// configErr is why the config is unusable, if it is; only commands
+	// marked AnyConfig run then.
+	configErr error
+	// db is the connection behind Db, opened to dbUrl with driver.
+	db *sql.DB
+	dbUrl string
+	driver string
+	// dbErr is why dbUrl can't be opened, if it can't; Db then fails every
+	// query with it.
+	dbErr error
+	// schemaChecked is set once db's schema is known to match this build.
+	schemaChecked bool
+	// ctx, when set, is cancelled to stop a long-running command, as the
+	// shell does on Ctrl-C.
+	ctx context.Context
+}
+
+// context is the context commands run under: ctx, or one that is never
+// cancelled.
+func (s *state) context() context.Context {
+	if s.ctx == nil {
+		return context.Background()
+	}
+	return s.ctx
+}
+
+// print writes records to stdout in the format chosen with --output.
+func (s *state) print(records any) error {
+	return output.Write(os.Stdout, s.Output, records)
+}
+
+// currentUserName is the user commands act as: --user if given, otherwise
+// whoever last logged in.
+func (s *state) currentUserName() string {
+	if s.UserOverride != "" {
+		return s.UserOverride
+	}
+	return s.Config.Current().CurrentUserName
+}
+
+// connect points Db at dbUrl, Postgres or SQLite, reusing the open
+// connection when it's already there. Nothing is dialled or created until
+// the first query, so commands that never query cost nothing, and a dbUrl
+// that can't be used only fails the commands that need the database.
+func (s *state) connect(dbUrl string) {
+	if s.db != nil && s.dbUrl == dbUrl {
+		return
+	}
+	s.close()
+	s.driver = storage.Driver(dbUrl)
+	db, err := storage.Open(dbUrl)
+	s.dbErr = err
+	if err != nil {
+		db = storage.Unavailable(err)
+		s.driver = storage.Postgres
+		s.logf("using profile %s, no database: %v", s.Config.ProfileName(), err)
+	} else {
+		s.logf("using profile %s, %s database %s", s.Config.ProfileName(), s.driver, redactDbUrl(dbUrl))
+	}
+	s.db = db
+	s.dbUrl = dbUrl
+	s.Db = storage.New(db, s.driver)
+	s.schemaChecked = false
+}
+
+func (s *state) close() {
+	if s.db != nil {
+		s.db.Close()
+		s.db = nil
+	}
+}
+
+// logf prints a diagnostic to stderr when --verbose is set.
+func (s *state) logf(format string, args ...any) {
+	if s.Verbose {
+		if s.logOutput == nil {
+			s.logOutput = os.Stderr
+		}
+		fmt.Fprintf(s.logOutput, format+"\n", args...)
+	}
 }
+
+// renderOptions is the terminal's rendering options with output.color from
+// the config applied.
+func (s *state) renderOptions(t render.Terminal) render.Options {
+	opts := t.Options()
+	switch s.Config.Output.Color {
+	case "always":
+		opts.Color = true
+	case "never":
+		opts.Color = false
+	}
+	return opts
+}
+
+// loadConfig reads the config and sets up what depends on it. A missing file
+// is only reported, as before; a file that doesn't parse or validate is kept
+// in configErr.
+func (s *state) loadConfig() {
+	err := s.Config.Read()
+	if errors.Is(err, os.ErrNotExist) {
+		fmt.Fprintln(os.Stderr, "Error reading config:", err)
+	} else if err != nil {
+		s.configErr = err
+	} else {
+		s.configErr = s.Config.Validate()
+	}
+
+	if s.Config.Logging.Level == "debug" {
+		s.Verbose = true
+	}
+	if s.Config.Logging.File != "" {
+		file, err := os.OpenFile(s.Config.Logging.File, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "Error opening log file:", err)
+		} else {
+			s.logOutput = file
+		}
+	}
+
+	fetchOptions := rss.FetchOptions{}
+	if s.configErr == nil {
+		fetchOptions = rss.FetchOptions{
+			Timeout: s.Config.Fetcher.TimeoutDuration(),
+			UserAgent: s.Config.Fetcher.UserAgent,
+			Proxy: s.Config.Fetcher.Proxy,
+			MaxBodyBytes: s.Config.Fetcher.MaxBody(),
+		}
+	}
+	s.Fetcher, err = rss.NewFetcher(fetchOptions)
+	if err != nil {
+		s.configErr = err
+		s.Fetcher, _ = rss.NewFetcher(rss.FetchOptions{})
+	}
+}
+
+// globalOptions are the flags accepted before or after any command.
+// Precedence is flag, then environment, then config file, then defaults.
+type globalOptions struct {
+	ConfigPath string
+	User string
+	DbUrl string
+	Verbose bool
+	Output string
+	Profile string
+	AutoMigrate bool
+	// EnvDbUrl and EnvProfile are GATOR_DB_URL and GATOR_PROFILE, kept apart
+	// from the flags so that a --profile given on the command line isn't
+	// overridden by GATOR_DB_URL.
+	EnvDbUrl string
+	EnvProfile string
+}
+
+// globalOptionsFromEnv returns the defaults the environment sets for the
+// global flags. GATOR_CONFIG is handled by the config package itself.
+func globalOptionsFromEnv() *globalOptions {
+	return &globalOptions{
+		User: os.Getenv("GATOR_USER"),
+		EnvDbUrl: os.Getenv("GATOR_DB_URL"),
+		EnvProfile: os.Getenv(config.ProfileEnv),
+	}
+}
+
+func (o *globalOptions) define(fs *flag.FlagSet) {
+	fs.StringVar(&o.ConfigPath, "config", o.ConfigPath, "read and save the config at `path` (env GATOR_CONFIG)")
+	fs.StringVar(&o.User, "user", o.User, "act as `name` for this command without logging in (env GATOR_USER)")
+	fs.StringVar(&o.DbUrl, "db-url", o.DbUrl, "connect to `url` instead of the profile's db_url (env GATOR_DB_URL)")
+	fs.StringVar(&o.Profile, "profile", o.Profile, "use the config profile `name` instead of the active one (env GATOR_PROFILE)")
+	fs.BoolVar(&o.Verbose, "verbose", o.Verbose, "print diagnostics to stderr")
+	fs.BoolVar(&o.AutoMigrate, "auto-migrate", o.AutoMigrate, "apply pending database migrations instead of refusing to run")
+	fs.StringVar(&o.Output, "output", o.Output, "print listings as `format`: text, json, yaml, csv or table")
+	fs.StringVar(&o.Output, "o", o.Output, "same as --output `format`")
+}
+
+// commandInfo describes a command for parsing, validation and help.
+type commandInfo struct {
+	Name string
+	// Usage is the argument synopsis shown after the name, e.g. "<name> <url>".
+	Usage string
+	Summary string
+	// MinArgs and MaxArgs bound the positional arguments; MaxArgs of -1 means
+	// no limit.
+	MinArgs int
+	MaxArgs int
+	// Flags, if set, defines the command's own flags.
+	Flags func(fs *flag.FlagSet)
+	Handler func(*state, Command) error
+	// Hidden commands work but aren't listed in help.
+	Hidden bool
+	// RawArgs commands get their arguments unparsed, flags and all.
+	RawArgs bool
+	// AnyConfig commands run even when the config is invalid.
+	AnyConfig bool
+	// NoSchemaCheck commands run whatever state the database schema is in,
+	// typically because they don't use the database.
+	NoSchemaCheck bool
+}
+
+func (info commandInfo) usageLine() string {
+	line := programName + " " + info.Name
+	if info.Flags != nil {
+		line += " [flags]"
+	}
+	if info.Usage != "" {
+		line += " " + info.Usage
+	}
+	return line
+}
+
+// flagSet returns a fresh flag set holding the command's own flags, plus the
+// global ones when globals is non-nil.
+func (info commandInfo) flagSet(globals *globalOptions) *flag.FlagSet {
+	fs := flag.NewFlagSet(info.Name, flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	if info.Flags != nil {
+		info.Flags(fs)
+	}
+	if globals != nil {
+		globals.define(fs)
+	}
+	return fs
+}
+
 type commands struct {
-	CmdRegister map[string]func(*state, Command) error
+	CmdRegister map[string]commandInfo
+	// names keeps registration order for help listings.
+	names []string
+	globals *globalOptions
+}
+
+func newCommands(globals *globalOptions) *commands {
+	return &commands{
+		CmdRegister: make(map[string]commandInfo),
+		globals: globals,
+	}
 }
 
 func (c *commands) run (s *state, cmd Command) error {
-	handler, ok := c.CmdRegister[cmd.Name]
+	info, ok := c.CmdRegister[cmd.Name]
 	if !ok {
-		return fmt.Errorf("command not found: %s", cmd.Name)
+		return unknownCommandError(cmd.Name, c.visibleNames())
+	}
+	if info.RawArgs {
+		err := c.checkConfig(s, info)
+		if err != nil {
+			return err
+		}
+		err = c.applyGlobals(s)
+		if err != nil {
+			return err
+		}
+		return info.Handler(s, cmd)
+	}
+	fs := info.flagSet(c.globals)
+	args, err := parseInterspersed(fs, cmd.Args)
+	if errors.Is(err, flag.ErrHelp) {
+		return c.printCommandHelp(os.Stdout, info)
+	}
+	if err != nil {
+		return fmt.Errorf("%v\nusage: %s", err, info.usageLine())
+	}
+	err = c.checkConfig(s, info)
+	if err != nil {
+		return err
+	}
+	err = c.applyGlobals(s)
+	if err != nil {
+		return err
 	}
-	err := handler(s, cmd)
+	if len(args) < info.MinArgs || (info.MaxArgs >= 0 && len(args) > info.MaxArgs) {
+		return fmt.Errorf("usage: %s", info.usageLine())
+	}
+	if !info.NoSchemaCheck {
+		err = s.ensureSchema(c.globals.AutoMigrate)
+		if err != nil {
+			return err
+		}
+	}
+	cmd.Args = args
+	cmd.Flags = fs
+	s.logf("running %s %q", cmd.Name, cmd.Args)
+	err = info.Handler(s, cmd)
 	if err != nil {
 		return err
 	}
 	return nil
 }
 
-func (c *commands) register (name string, f func(*state, Command) error) {
-	c.CmdRegister[name] = f
+// checkConfig switches to the config --config names when it came after the
+// command name, and so wasn't known when the config was loaded, then makes
+// sure the config is valid if info needs it to be.
+func (c *commands) checkConfig(s *state, info commandInfo) error {
+	if c.globals.ConfigPath != s.Config.Path {
+		if closer, ok := s.logOutput.(io.Closer); ok && s.logOutput != os.Stderr {
+			closer.Close()
+		}
+		s.logOutput = nil
+		s.configErr = nil
+		s.Config = config.NewConfigAt(c.globals.ConfigPath)
+		s.loadConfig()
+	}
+	if s.configErr != nil && !info.AnyConfig {
+		return fmt.Errorf("the config is invalid (see '%s config validate'):\n%w", programName, s.configErr)
+	}
+	return nil
 }
 
-func CliLoop () {
-	appState := &state{
-		Config: config.NewConfig(),
+// applyGlobals copies the global flags that can also follow the command name
+// onto the state, connecting to the database they select.
+func (c *commands) applyGlobals(s *state) error {
+	formatName := c.globals.Output
+	if formatName == "" {
+		formatName = s.Config.Output.Format
 	}
-	err := appState.Config.Read()
+	format, err := output.ParseFormat(formatName)
 	if err != nil {
-		fmt.Println("Error reading config:", err)
+		return err
+	}
+	profile, dbUrl := c.globals.Profile, c.globals.DbUrl
+	if profile == "" && dbUrl == "" {
+		dbUrl = c.globals.EnvDbUrl
+	}
+	if profile == "" {
+		profile = c.globals.EnvProfile
 	}
+	if profile != "" && !s.Config.HasProfile(profile) {
+		return fmt.Errorf("no profile named %q (see '%s profile list')", profile, programName)
+	}
+	s.Config.Selected = profile
+	if dbUrl == "" {
+		dbUrl = s.Config.Current().DbUrl
+	}
+	s.connect(dbUrl)
+	s.Output = format
+	s.UserOverride = c.globals.User
+	s.Verbose = c.globals.Verbose || s.Config.Logging.Level == "debug"
+	return nil
+}
+
+func (c *commands) register (info commandInfo) {
+	if info.MaxArgs == 0 && info.MinArgs > 0 {
+		info.MaxArgs = info.MinArgs
+	}
+	if _, ok := c.CmdRegister[info.Name]; !ok {
+		c.names = append(c.names, info.Name)
+	}
+	c.CmdRegister[info.Name] = info
+}
+
+func (c *commands) visibleNames() []string {
+	var names []string
+	for _, name := range c.names {
+		if !c.CmdRegister[name].Hidden {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// parseInterspersed parses flags wherever they appear among args, not just
+// before the first positional argument, and returns the positional ones.
+// Everything after "--" is positional.
+func parseInterspersed(fs *flag.FlagSet, args []string) ([]string, error) {
+	var positional []string
+	for {
+		err := fs.Parse(args)
+		if err != nil {
+			return nil, err
+		}
+		rest := fs.Args()
+		consumed := len(args) - len(rest)
+		if consumed > 0 && args[consumed-1] == "--" {
+			return append(positional, rest...), nil
+		}
+		if len(rest) == 0 {
+			return positional, nil
+		}
+		positional = append(positional, rest[0])
+		args = rest[1:]
+	}
+}
+
+func registerCommands(c *commands) {
+	c.register(commandInfo{
+		Name: "help",
+		Usage: "[command]",
+		Summary: "Show the command list, or details for one command",
+		MaxArgs: 1,
+		Handler: c.handlerHelp,
+		AnyConfig: true,
+		NoSchemaCheck: true,
+	})
+	c.register(commandInfo{
+		Name: "login",
+		Usage: "<username>",
+		Summary: "Log in as an existing user",
+		MinArgs: 1,
+		Handler: handlerLogin,
+	})
+	c.register(commandInfo{
+		Name: "print",
+		Summary: "Print the current configuration",
+		Handler: handlerPrintConfig,
+		AnyConfig: true,
+		NoSchemaCheck: true,
+	})
+	c.register(commandInfo{
+		Name: "register",
+		Usage: "<username>",
+		Summary: "Register a new user and log in as them",
+		MinArgs: 1,
+		Handler: handlerRegisterUser,
+	})
+	c.register(commandInfo{
+		Name: "config",
+		Usage: "validate",
+		Summary: "Check the config file for unknown keys and bad values",
+		MinArgs: 1,
+		Handler: handlerConfig,
+		AnyConfig: true,
+		NoSchemaCheck: true,
+	})
+	c.register(commandInfo{
+		Name: "profile",
+		Usage: "list | use <name> | add <name> <db_url> | remove <name>",
+		Summary: "Manage config profiles, each with its own database and user",
+		MinArgs: 1,
+		MaxArgs: 3,
+		Handler: handlerProfile,
+		AnyConfig: true,
+		NoSchemaCheck: true,
+	})
+	c.register(commandInfo{
+		Name: "migrate",
+		Usage: "up|down|status|redo",
+		Summary: "Apply, roll back or list database schema migrations",
+		MinArgs: 1,
+		Handler: handlerMigrate,
+		NoSchemaCheck: true,
+	})
+	c.register(commandInfo{
+		Name: "reset",
+		Usage: "[all | posts | feeds | user <name>]",
+		Summary: "Delete every user, or just posts, feeds or one user, saving a snapshot first",
+		MaxArgs: 2,
+		Flags: func(fs *flag.FlagSet) {
+			fs.Bool("yes", false, "don't ask for confirmation")
+		},
+		Handler: handlerResetDatabase,
+	})
+	c.register(commandInfo{
+		Name: "backup",
+		Usage: "<file>",
+		Summary: "Save everything in the database to a snapshot file",
+		MinArgs: 1,
+		MaxArgs: 1,
+		Handler: handlerBackup,
+	})
+	c.register(commandInfo{
+		Name: "restore",
+		Usage: "<file>",
+		Summary: "Load a snapshot from backup or reset into an empty database",
+		MinArgs: 1,
+		MaxArgs: 1,
+		Handler: handlerRestore,
+	})
+	c.register(commandInfo{
+		Name: "users",
+		Summary: "List all users",
+		Handler: handlerGetAllUsers,
+	})
+	c.register(commandInfo{
+		Name: "user",
+		Usage: "show [name] | rename <name> <new_name> | delete <name>",
+		Summary: "Show a user's details, rename a user, or delete one after saving a snapshot",
+		MinArgs: 1,
+		MaxArgs: 3,
+		Flags: func(fs *flag.FlagSet) {
+			fs.Bool("yes", false, "delete without asking for confirmation")
+		},
+		Handler: handlerUser,
+	})
+	c.register(commandInfo{
+		Name: "addfeed",
+		Usage: "<name> <url>",
+		Summary: "Add a feed and follow it",
+		MinArgs: 2,
+		Handler: middlewareLoggedIn(handlerAddFeed),
+	})
+	c.register(commandInfo{
+		Name: "feeds",
+		Summary: "List all feeds",
+		Handler: handlerGetAllFeeds,
+	})
+	c.register(commandInfo{
+		Name: "follow",
+		Usage: "<feed_url>",
+		Summary: "Follow an existing feed",
+		MinArgs: 1,
+		Handler: middlewareLoggedIn(handlerFollowFeed),
+	})
+	c.register(commandInfo{
+		Name: "following",
+		Summary: "List the feeds you follow",
+		Handler: middlewareLoggedIn(handlerGetFollowing),
+	})
+	c.register(commandInfo{
+		Name: "unfollow",
+		Usage: "<feed_url>",
+		Summary: "Stop following a feed",
+		MinArgs: 1,
+		Handler: middlewareLoggedIn(handlerUnfollowFeed),
+	})
+	c.register(commandInfo{
+		Name: "agg",
+		Usage: "[time_between_reqs]",
+		Summary: "Scrape feeds continuously, e.g. agg 1m; defaults to aggregator.interval",
+		MaxArgs: 1,
+		Handler: handlerScrapeFeeds,
+	})
+	c.register(commandInfo{
+		Name: "aggone",
+		Usage: "<feed_url>",
+		Summary: "Scrape one feed once and report what was found",
+		MinArgs: 1,
+		Handler: handlerAggOne,
+	})
+	c.register(commandInfo{
+		Name: "prune",
+		Summary: "Delete old posts as set by the aggregator's retention policy",
+		Flags: func(fs *flag.FlagSet) {
+			fs.Bool("dry-run", false, "list what would be deleted without deleting it")
+		},
+		Handler: handlerPrune,
+	})
+	c.register(commandInfo{
+		Name: "browse",
+		Usage: "[limit]",
+		Summary: "Show recent posts from the feeds you follow",
+		MaxArgs: 1,
+		Flags: func(fs *flag.FlagSet) {
+			fs.Int("limit", 2, "show at most `n` posts")
+		},
+		Handler: middlewareLoggedIn(handlerBrowsePosts),
+	})
+	c.register(commandInfo{
+		Name: "download",
+		Usage: "<post_id>",
+		Summary: "Download a post's podcast or media files",
+		MinArgs: 1,
+		Flags: func(fs *flag.FlagSet) {
+			fs.String("dir", "", "save to `directory` instead of the config's download_dir")
+		},
+		Handler: handlerDownload,
+	})
+	c.register(commandInfo{
+		Name: "tui",
+		Summary: "Open the interactive reader",
+		Handler: middlewareLoggedIn(handlerTUI),
+	})
+	c.register(commandInfo{
+		Name: "shell",
+		Summary: "Run commands interactively against one open session",
+		Handler: c.handlerShell,
+		NoSchemaCheck: true,
+	})
+	c.register(commandInfo{
+		Name: "completion",
+		Usage: "<bash|zsh|fish>",
+		Summary: "Print a shell completion script",
+		MinArgs: 1,
+		Handler: handlerCompletion,
+		AnyConfig: true,
+		NoSchemaCheck: true,
+	})
+	c.register(commandInfo{
+		Name: completeCommand,
+		Summary: "Print completion candidates for the given words",
+		Hidden: true,
+		RawArgs: true,
+		Handler: c.handlerComplete,
+		AnyConfig: true,
+		NoSchemaCheck: true,
+	})
+}
 
-	db, err := sql.Open("postgres", appState.Config.DbUrl)
+func CliLoop () {
+	globals := globalOptionsFromEnv()
+	commands := newCommands(globals)
+	registerCommands(commands)
+
+	// Global flags may come before the command name; the command's own flag
+	// set accepts them after it too.
+	top := flag.NewFlagSet(programName, flag.ContinueOnError)
+	top.SetOutput(io.Discard)
+	globals.define(top)
+	err := top.Parse(os.Args[1:])
+	if errors.Is(err, flag.ErrHelp) {
+		commands.printHelp(os.Stdout)
+		return
+	}
 	if err != nil {
-		fmt.Println("Error opening database:", err)
+		fmt.Println("Error:", err)
 		os.Exit(1)
 	}
-	defer db.Close()
-	appState.Db = database.New(db)
-
-	commands := commands{
-		CmdRegister: make(map[string]func(*state, Command) error),
-	}
-
-	commands.register("login", handlerLogin)
-	commands.register("print", handlerPrintConfig)
-	commands.register("register", handlerRegisterUser)
-	commands.register("reset", handlerResetDatabase)
-	commands.register("users", handlerGetAllUsers)
-	commands.register("addfeed", middlewareLoggedIn(handlerAddFeed))
-	commands.register("feeds", handlerGetAllFeeds)
-	commands.register("follow", middlewareLoggedIn(handlerFollowFeed))
-	commands.register("following", middlewareLoggedIn(handlerGetFollowing))
-	commands.register("unfollow", middlewareLoggedIn(handlerUnfollowFeed))
-	commands.register("agg", handlerScrapeFeeds)
-	commands.register("aggone", handlerAggOne)
-	commands.register("browse", middlewareLoggedIn(handlerBrowsePosts))
-
-	args := os.Args
-	if len(args) < 2 {
-		fmt.Println("Error: you must provide a command")
+	args := top.Args()
+	if len(args) < 1 {
+		commands.printHelp(os.Stderr)
 		os.Exit(1)
 	}
+
+	appState := &state{
+		Config: config.NewConfigAt(globals.ConfigPath),
+		Verbose: globals.Verbose,
+	}
+	appState.loadConfig()
+	if path, err := appState.Config.FilePath(); err == nil {
+		appState.logf("using config %s", path)
+	}
+
 	userCommand := Command{
-		Name: args[1],
-		Args: args[2:],
+		Name: args[0],
+		Args: args[1:],
 	}
 
 	err = commands.run(appState, userCommand)
+	appState.close()
 	if err != nil {
 		fmt.Println("Error:", err)
 		os.Exit(1)
 	}
 }
+
+// unknownCommandError reports an unknown command, suggesting the closest
+// known ones.
+func unknownCommandError(name string, known []string) error {
+	suggestions := suggestCommands(name, known)
+	if len(suggestions) == 0 {
+		return fmt.Errorf("command not found: %s (run '%s help' for a list)", name, programName)
+	}
+	return fmt.Errorf("command not found: %s\ndid you mean %s?", name, strings.Join(suggestions, " or "))
+}
@@ -3,20 +3,41 @@ package main
 import (
 	"context"
 	"database/sql"
+	"flag"
 	"fmt"
+	"path/filepath"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 
+	"github.com/WagnerJust/go-gator/internal/config"
 	"github.com/WagnerJust/go-gator/internal/database"
+	"github.com/WagnerJust/go-gator/internal/download"
+	"github.com/WagnerJust/go-gator/internal/output"
+	"github.com/WagnerJust/go-gator/internal/render"
 	"github.com/WagnerJust/go-gator/internal/rss"
+	"github.com/WagnerJust/go-gator/internal/storage"
 	"github.com/google/uuid"
-	_ "github.com/lib/pq"
 )
 
 type Command struct {
 	Name string
 	Args []string
+	// Flags holds the command's parsed flags; see commandInfo.Flags.
+	Flags *flag.FlagSet
+}
+
+func (c Command) intFlag(name string) int {
+	return c.Flags.Lookup(name).Value.(flag.Getter).Get().(int)
+}
+
+func (c Command) stringFlag(name string) string {
+	return c.Flags.Lookup(name).Value.String()
+}
+
+func (c Command) boolFlag(name string) bool {
+	return c.Flags.Lookup(name).Value.(flag.Getter).Get().(bool)
 }
 
 func stringPtrToNullString(s *string) sql.NullString {
@@ -27,9 +48,51 @@ func stringPtrToNullString(s *string) sql.NullString {
 }
 
 
+func stringToNullString(s string) sql.NullString {
+	if s == "" {
+		return sql.NullString{Valid: false}
+	}
+	return sql.NullString{String: s, Valid: true}
+}
+
+// postEnclosures returns the enclosures to store for a post created from
+// item.
+func postEnclosures(post database.Post, item rss.RSSItem) []database.CreatePostEnclosureParams {
+	var params []database.CreatePostEnclosureParams
+	for _, enclosure := range item.Enclosures {
+		if enclosure.URL == "" {
+			continue
+		}
+		length, ok := enclosure.LengthBytes()
+		params = append(params, database.CreatePostEnclosureParams{
+			ID: uuid.New(),
+			CreatedAt: time.Now().UTC(),
+			UpdatedAt: time.Now().UTC(),
+			PostID: post.ID,
+			Url: enclosure.URL,
+			MimeType: stringToNullString(enclosure.Type),
+			Length: sql.NullInt64{Int64: length, Valid: ok},
+			Duration: stringToNullString(item.ITunesDuration),
+			Episode: stringToNullString(item.ITunesEpisode),
+			ImageUrl: stringToNullString(item.ITunesImage.Href),
+		})
+	}
+	return params
+}
+
+// recordFetchWarnings stores the parser's warnings on the feed, clearing any
+// left over from an earlier fetch when the feed parsed cleanly this time.
+func recordFetchWarnings(feeds storage.Feeds, feed database.Feed, fetchedFeed *rss.RSSFeed) error {
+	params := database.SetFeedFetchWarningParams{
+		ID: feed.ID,
+		LastFetchWarning: stringToNullString(strings.Join(fetchedFeed.Warnings, "; ")),
+	}
+	return feeds.SetFeedFetchWarning(context.Background(), params)
+}
+
 func middlewareLoggedIn(handler func(s *state, cmd Command, user database.User) error) func(*state, Command) error {
 	return func(s *state, cmd Command) error {
-		user, err := s.Db.GetUserByName(context.Background(), s.Config.CurrentUserName)
+		user, err := s.Db.GetUserByName(context.Background(), s.currentUserName())
 		if err != nil {
 			return err
 		}
@@ -38,14 +101,11 @@ func middlewareLoggedIn(handler func(s *state, cmd Command, user database.User)
 }
 
 func handlerLogin(s *state, cmd Command) error {
-	if len(cmd.Args) != 1 {
-		return fmt.Errorf("usage: login <username>")
-	}
-	_, err := s.Db.GetUserByName(context.Background(),cmd.Args[0])
+	user, err := s.Db.GetUserByName(context.Background(),cmd.Args[0])
 	if err != nil {
 		return err
 	}
-	err = s.Config.SetUser(cmd.Args[0])
+	err = s.Config.SetUser(user.Name)
 	if err != nil {
 		return err
 	}
@@ -54,27 +114,31 @@ func handlerLogin(s *state, cmd Command) error {
 }
 
 func handlerPrintConfig(s *state, cmd Command) error {
-	if len(cmd.Args) != 0 {
-		return fmt.Errorf("usage: config")
-	}
-	fmt.Println(s.Config.String())
+	redacted := *s.Config
+	redacted.DbUrl = redactDbUrl(redacted.DbUrl)
+	redacted.Profiles = make(map[string]config.Profile, len(s.Config.Profiles))
+	for name, profile := range s.Config.Profiles {
+		profile.DbUrl = redactDbUrl(profile.DbUrl)
+		redacted.Profiles[name] = profile
+	}
+	fmt.Println(redacted.String())
 	return nil
 }
 
 func handlerRegisterUser (s *state, cmd Command) error {
-	if len(cmd.Args) != 1 {
-		return fmt.Errorf("usage: register <username>")
+	err := validateUserName(cmd.Args[0])
+	if err != nil {
+		return err
 	}
-
 	userParams := database.CreateUserParams{
 		ID: uuid.New(),
 		Name: cmd.Args[0],
-		CreatedAt: time.Now(),
-		UpdatedAt: time.Now(),
+		CreatedAt: time.Now().UTC(),
+		UpdatedAt: time.Now().UTC(),
 	}
 	user, err := s.Db.CreateUser(context.Background(), userParams )
 	if err != nil {
-		return err
+		return nameTakenError(userParams.Name, err)
 	}
 	s.Config.SetUser(user.Name)
 	fmt.Println("User created successfully!")
@@ -82,32 +146,43 @@ func handlerRegisterUser (s *state, cmd Command) error {
 	return nil
 }
 
-func handlerResetDatabase(s *state, cmd Command) error {
-	if len(cmd.Args) != 0 {
-		return fmt.Errorf("usage: reset")
+func handlerConfig(s *state, cmd Command) error {
+	if cmd.Args[0] != "validate" {
+		return fmt.Errorf("usage: %s config validate", programName)
 	}
-
-	err := s.Db.DeleteAllUsers(context.Background())
+	path, err := s.Config.FilePath()
 	if err != nil {
 		return err
 	}
-	fmt.Println("All users have been cleared from the database")
+	err = config.CheckFile(path)
+	if err != nil {
+		return fmt.Errorf("%s is invalid:\n%w", path, err)
+	}
+	fmt.Printf("%s is valid\n", path)
 	return nil
 }
 
 func handlerGetAllUsers(s *state, cmd Command) error {
-	if len(cmd.Args) != 0 {
-		return fmt.Errorf("usage: users")
-	}
-
 	users, err := s.Db.GetAllUsers(context.Background())
 	if err != nil {
 		return err
 	}
 
+	if s.Output != output.Text {
+		records := make([]userRecord, 0, len(users))
+		for _, user := range users {
+			records = append(records, userRecord{
+				ID: user.ID,
+				Name: user.Name,
+				CreatedAt: user.CreatedAt.UTC(),
+				Current: strings.EqualFold(user.Name, s.currentUserName()),
+			})
+		}
+		return s.print(records)
+	}
 	for _, user := range users {
-		phrase := "* " + strings.ToLower(user.Name)
-		if strings.EqualFold(user.Name, s.Config.CurrentUserName) {
+		phrase := "* " + user.Name
+		if strings.EqualFold(user.Name, s.currentUserName()) {
 			phrase += " (current)"
 		}
 		fmt.Println(phrase)
@@ -116,16 +191,12 @@ func handlerGetAllUsers(s *state, cmd Command) error {
 }
 
 func handlerAddFeed (s *state, cmd Command, user database.User) error {
-	if len(cmd.Args) != 2 {
-		return fmt.Errorf("usage: addfeed <name> <url>")
-	}
-
 	feedParams := database.CreateFeedParams{
 		ID: uuid.New(),
 		Name: cmd.Args[0],
 		Url: cmd.Args[1],
-		CreatedAt: time.Now(),
-		UpdatedAt: time.Now(),
+		CreatedAt: time.Now().UTC(),
+		UpdatedAt: time.Now().UTC(),
 		UserID: user.ID,
 	}
 	feed, err := s.Db.CreateFeed(context.Background(),feedParams)
@@ -135,8 +206,8 @@ func handlerAddFeed (s *state, cmd Command, user database.User) error {
 
 	feedFollowParams := database.CreateFeedFollowParams{
 		ID: uuid.New(),
-		CreatedAt: time.Now(),
-		UpdatedAt: time.Now(),
+		CreatedAt: time.Now().UTC(),
+		UpdatedAt: time.Now().UTC(),
 		UserID: user.ID,
 		FeedID: feed.ID,
 	}
@@ -146,31 +217,50 @@ func handlerAddFeed (s *state, cmd Command, user database.User) error {
 		return err
 	}
 
-	fmt.Printf("Feed Follow: %+v\n", feedFollow)
+	if s.Output != output.Text {
+		return s.print(followRecord{
+			ID: feedFollow.ID,
+			FeedID: feedFollow.FeedID,
+			FeedName: feedFollow.FeedName,
+			User: feedFollow.UserName,
+			CreatedAt: feedFollow.CreatedAt.UTC(),
+		})
+	}
+	fmt.Printf("%s is now following %s\n", feedFollow.UserName, feedFollow.FeedName)
 	return nil
 }
 
 func handlerGetAllFeeds (s *state, cmd Command) error {
-	if len(cmd.Args) != 0 {
-		return fmt.Errorf("usage: feeds")
-	}
-
 	feeds, err := s.Db.GetAllFeedsWithUsers(context.Background())
 	if err != nil {
 		return err
 	}
 
+	if s.Output != output.Text {
+		records := make([]feedRecord, 0, len(feeds))
+		for _, feed := range feeds {
+			records = append(records, feedRecord{
+				ID: feed.ID,
+				Name: feed.Name,
+				URL: feed.Url,
+				User: feed.UserName,
+				CreatedAt: feed.CreatedAt.UTC(),
+				LastFetchedAt: nullTimePtr(feed.LastFetchedAt),
+				LastFetchWarning: nullStringPtr(feed.LastFetchWarning),
+			})
+		}
+		return s.print(records)
+	}
 	for _, feed := range feeds {
 		fmt.Printf("name: %s\n\turl: %s\n\tuser: %s\n", feed.Name, feed.Url, feed.UserName)
+		if feed.LastFetchWarning.Valid {
+			fmt.Printf("\twarning: %s\n", feed.LastFetchWarning.String)
+		}
 	}
 	return nil
 }
 
 func handlerFollowFeed (s *state, cmd Command, user database.User) error {
-	if len(cmd.Args) != 1 {
-		return fmt.Errorf("usage: follow <url>")
-	}
-
 	feed, err := s.Db.GetFeedByUrl(context.Background(), cmd.Args[0])
 	if err != nil {
 		return err
@@ -178,8 +268,8 @@ func handlerFollowFeed (s *state, cmd Command, user database.User) error {
 
 	feedFollowParams := database.CreateFeedFollowParams{
 		ID: uuid.New(),
-		CreatedAt: time.Now(),
-		UpdatedAt: time.Now(),
+		CreatedAt: time.Now().UTC(),
+		UpdatedAt: time.Now().UTC(),
 		UserID: user.ID,
 		FeedID: feed.ID,
 	}
@@ -189,19 +279,37 @@ func handlerFollowFeed (s *state, cmd Command, user database.User) error {
 		return err
 	}
 
-	fmt.Printf("Feed Follow: %+v\n", feedFollow)
+	if s.Output != output.Text {
+		return s.print(followRecord{
+			ID: feedFollow.ID,
+			FeedID: feedFollow.FeedID,
+			FeedName: feedFollow.FeedName,
+			User: feedFollow.UserName,
+			CreatedAt: feedFollow.CreatedAt.UTC(),
+		})
+	}
+	fmt.Printf("%s is now following %s\n", feedFollow.UserName, feedFollow.FeedName)
 	return nil
 }
 
 func handlerGetFollowing (s *state, cmd Command, user database.User) error {
-	if len(cmd.Args) != 0 {
-		return fmt.Errorf("usage: following")
-	}
-
 	feedFollows, err := s.Db.GetFeedFollowsForUser(context.Background(), user.ID)
 	if err != nil {
 		return err
 	}
+	if s.Output != output.Text {
+		records := make([]followRecord, 0, len(feedFollows))
+		for _, feedFollow := range feedFollows {
+			records = append(records, followRecord{
+				ID: feedFollow.ID,
+				FeedID: feedFollow.FeedID,
+				FeedName: feedFollow.FeedName,
+				User: feedFollow.UserName,
+				CreatedAt: feedFollow.CreatedAt.UTC(),
+			})
+		}
+		return s.print(records)
+	}
 	if len(feedFollows) == 0 {
 		fmt.Println("You are not following any feeds")
 		return nil
@@ -214,9 +322,6 @@ func handlerGetFollowing (s *state, cmd Command, user database.User) error {
 }
 
 func handlerUnfollowFeed(s *state, cmd Command, user database.User) error {
-	if len(cmd.Args) != 1 {
-		return  fmt.Errorf("usage: unfollow <url>")
-	}
 	feed, err := s.Db.GetFeedByUrl(context.Background(), cmd.Args[0])
 	if err != nil {
 		return err
@@ -234,69 +339,75 @@ func handlerUnfollowFeed(s *state, cmd Command, user database.User) error {
 
 
 func handlerScrapeFeeds ( s *state, cmd Command) error {
-	if len(cmd.Args) != 1 {
-		return fmt.Errorf("usage: agg <duration_string>");
+	timeBetweenReqs := s.Config.Aggregator.IntervalDuration()
+	if len(cmd.Args) == 1 {
+		var err error
+		timeBetweenReqs, err = time.ParseDuration(cmd.Args[0])
+		if err != nil {
+			return err
+		}
 	}
-	timeBetweenReqs, err := time.ParseDuration(cmd.Args[0])
-	if err != nil {
-		return err
+	if timeBetweenReqs <= 0 {
+		return fmt.Errorf("usage: %s agg <time_between_reqs> (or set aggregator.interval in the config)", programName)
 	}
+	workers := s.Config.Aggregator.WorkerCount()
+	pruneInterval := s.Config.Aggregator.PruneIntervalDuration()
+	var lastPruned time.Time
 	fmt.Println("Collecting feeds every ", timeBetweenReqs.String())
 	ticker := time.NewTicker(timeBetweenReqs)
 	defer ticker.Stop()
-	for ; ; <-ticker.C {
-		fmt.Println("Checking...")
-		feed, err := s.Db.GetNextFeedToFetch(context.Background())
-		if err != nil {
-			return err
+	ctx := s.context()
+	for {
+		if pruneInterval > 0 && time.Since(lastPruned) >= pruneInterval {
+			pruned, err := prunePosts(ctx, s.Db, s.Config.Aggregator, time.Now(), false)
+			// A failed prune is tried again after the next interval rather
+			// than stopping the collection.
+			lastPruned = time.Now()
+			if err != nil {
+				fmt.Printf("Error pruning posts: %v\n", err)
+			} else {
+				fmt.Printf("Pruned %d old posts, %d kept because they are unread\n", countPruned(pruned), countUnread(pruned))
+			}
 		}
-		err = s.Db.MarkFeedFetched(context.Background(), feed.ID)
+		fmt.Println("Checking...")
+		feeds, err := s.Db.GetNextFeedsToFetch(ctx, int32(workers))
 		if err != nil {
 			return err
 		}
-
-		fetchedFeed, err := rss.FetchFeed(context.Background(), feed.Url)
-		if err != nil {
-			return err
+		results := make([]scrapeResult, len(feeds))
+		errs := make([]error, len(feeds))
+		var wg sync.WaitGroup
+		for i, feed := range feeds {
+			wg.Go(func() {
+				results[i], errs[i] = scrapeFeed(s, feed)
+			})
 		}
-		postsCreated := 0
-		for _, item := range fetchedFeed.Channel.Item {
-
-			pubDate, err := time.Parse(time.RFC1123Z, item.PubDate)
-			if err != nil {
-				fmt.Printf("Could not parse publication date for '%s': %v\n", item.Title, err)
+		wg.Wait()
+		for i, feed := range feeds {
+			if errs[i] != nil {
+				fmt.Printf("Error collecting feed %s: %v\n", feed.Name, errs[i])
 				continue
 			}
-			postParams := database.CreatePostParams{
-				ID: uuid.New(),
-				CreatedAt: time.Now(),
-				UpdatedAt: time.Now(),
-				PublishedAt: pubDate,
-				Title: item.Title,
-				Url: item.Link,
-				Description: stringPtrToNullString(item.Description),
-				FeedID: feed.ID,
+			for _, warning := range results[i].Fetched.Warnings {
+				fmt.Printf("Warning for feed %s: %s\n", feed.Name, warning)
 			}
-			_, err = s.Db.CreatePost(context.Background(), postParams)
-			if err != nil {
-				if strings.Contains(err.Error(), "duplicate key value") {
-					continue
-				}
-				fmt.Printf("Error creating post: %v\n", err)
-				return err
+			for _, problem := range results[i].Problems {
+				fmt.Println(problem)
 			}
-			postsCreated++
+			fmt.Printf("Feed %s collected, %v new posts found\n", feed.Name, len(results[i].Created))
+		}
+		select {
+		case <-ctx.Done():
+			fmt.Println("Stopped collecting feeds")
+			return nil
+		case <-ticker.C:
 		}
-		fmt.Printf("Feed %s collected, %v new posts found\n", feed.Name, postsCreated)
 	}
 }
 
 
 func handlerBrowsePosts( s *state, cmd Command, user database.User) error {
-	if len(cmd.Args) > 1 {
-		return fmt.Errorf("usage: browse <optional_limit_integer>")
-	}
-	limit := 2
+	limit := cmd.intFlag("limit")
 	var err error
 	if len(cmd.Args) == 1 {
 		limit, err = strconv.Atoi(cmd.Args[0])
@@ -313,23 +424,49 @@ func handlerBrowsePosts( s *state, cmd Command, user database.User) error {
 	if err != nil {
 		return err
 	}
-	for _, post := range posts {
-		fmt.Printf("Title: %s\n", post.Title)
-		fmt.Printf("URL: %s\n", post.Url)
+	if s.Output != output.Text {
+		records := make([]postRecord, 0, len(posts))
+		for _, post := range posts {
+			enclosures, err := s.Db.GetEnclosuresForPost(context.Background(), post.ID)
+			if err != nil {
+				return err
+			}
+			records = append(records, newPostRecord(post, enclosures))
+		}
+		return s.print(records)
+	}
+	terminal := render.Stdout()
+	opts := s.renderOptions(terminal)
+	location := s.Config.Output.Location()
+	var out strings.Builder
+	for i, post := range posts {
+		if i > 0 {
+			fmt.Fprintf(&out, "\n%s\n\n", opts.Rule())
+		}
+		fmt.Fprintln(&out, opts.Title(post.Title))
+		fmt.Fprintln(&out, opts.Dim(fmt.Sprintf("%s · %s", post.PublishedAt.In(location).Format("2006-01-02 15:04"), post.ID)))
+		fmt.Fprintln(&out, post.Url)
+		if post.ThumbnailUrl.Valid {
+			fmt.Fprintf(&out, "%s %s\n", opts.Bold("Thumbnail:"), post.ThumbnailUrl.String)
+		}
+		enclosures, err := s.Db.GetEnclosuresForPost(context.Background(), post.ID)
+		if err != nil {
+			return err
+		}
+		for _, enclosure := range enclosures {
+			fmt.Fprintf(&out, "%s %s\n", opts.Bold("Media:"), formatEnclosure(enclosure))
+		}
 		if post.Description.Valid {
-			fmt.Printf("Description: %s\n", post.Description.String)
+			body := render.HTML(post.Description.String, opts)
+			if body != "" {
+				fmt.Fprintf(&out, "\n%s\n", body)
+			}
 		}
-		fmt.Printf("Published: %s\n", post.PublishedAt.Format("2006-01-02 15:04:05"))
-		fmt.Println("================================================================================")
 	}
-	return nil
+	return terminal.Page(out.String())
 }
 
 func handlerAggOne(s *state, cmd Command) error {
-	if len(cmd.Args) != 1 {
-		return fmt.Errorf("usage: aggone <feed_url>")
-	}
-
 	feed, err := s.Db.GetFeedByUrl(context.Background(), cmd.Args[0])
 	if err != nil {
 		return err
@@ -339,70 +476,92 @@ func handlerAggOne(s *state, cmd Command) error {
 	fmt.Printf("URL: %s\n", feed.Url)
 	fmt.Printf("Feed ID: %s\n", feed.ID)
 
-	err = s.Db.MarkFeedFetched(context.Background(), feed.ID)
-	if err != nil {
-		return err
-	}
-
-	fetchedFeed, err := rss.FetchFeed(context.Background(), feed.Url)
+	result, err := scrapeFeed(s, feed)
 	if err != nil {
 		return err
 	}
+	fetchedFeed := result.Fetched
 
 	fmt.Printf("\nFetched RSS Feed: %s\n", fetchedFeed.Channel.Title)
 	fmt.Printf("Number of items: %d\n\n", len(fetchedFeed.Channel.Item))
+	for _, warning := range fetchedFeed.Warnings {
+		fmt.Printf("Warning: %s\n", warning)
+	}
+	for _, problem := range result.Problems {
+		fmt.Println(problem)
+	}
+	for i, post := range result.Created {
+		if i == 3 {
+			break
+		}
+		fmt.Printf("✓ Created: %s (published: %s)\n", post.Title, post.PublishedAt.In(s.Config.Output.Location()).Format("2006-01-02"))
+	}
 
-	postsCreated := 0
-	duplicates := 0
-	parseErrors := 0
+	fmt.Printf("\n=== Summary ===\n")
+	fmt.Printf("Feed: %s\n", feed.Name)
+	fmt.Printf("Total items: %d\n", len(fetchedFeed.Channel.Item))
+	fmt.Printf("New posts: %d\n", len(result.Created))
+	fmt.Printf("Duplicates: %d\n", result.Duplicates)
+	fmt.Printf("Parse errors: %d\n", result.ParseErrors)
 
-	for i, item := range fetchedFeed.Channel.Item {
-		pubDate, err := time.Parse(time.RFC1123Z, item.PubDate)
+	return nil
+}
+
+func formatEnclosure(enclosure database.PostEnclosure) string {
+	var details []string
+	if enclosure.Episode.Valid {
+		details = append(details, "episode "+enclosure.Episode.String)
+	}
+	if enclosure.MimeType.Valid {
+		details = append(details, enclosure.MimeType.String)
+	}
+	if enclosure.Length.Valid {
+		details = append(details, fmt.Sprintf("%.1f MB", float64(enclosure.Length.Int64)/(1024*1024)))
+	}
+	if enclosure.Duration.Valid {
+		details = append(details, enclosure.Duration.String)
+	}
+	if len(details) == 0 {
+		return enclosure.Url
+	}
+	return fmt.Sprintf("%s (%s)", enclosure.Url, strings.Join(details, ", "))
+}
+
+func handlerDownload(s *state, cmd Command) error {
+	postID, err := uuid.Parse(cmd.Args[0])
+	if err != nil {
+		return fmt.Errorf("invalid post id %q: %w", cmd.Args[0], err)
+	}
+	post, err := s.Db.GetPostByID(context.Background(), postID)
+	if err != nil {
+		return err
+	}
+	enclosures, err := s.Db.GetEnclosuresForPost(context.Background(), post.ID)
+	if err != nil {
+		return err
+	}
+	if len(enclosures) == 0 {
+		return fmt.Errorf("post '%s' has no media to download", post.Title)
+	}
+	dir := cmd.stringFlag("dir")
+	if dir == "" {
+		dir, err = s.Config.GetDownloadDir()
 		if err != nil {
-			// Try alternative date formats
-			pubDate, err = time.Parse(time.RFC1123, item.PubDate)
-			if err != nil {
-				// Try RFC3339
-				pubDate, err = time.Parse(time.RFC3339, item.PubDate)
-				if err != nil {
-					fmt.Printf("[%d] Could not parse date '%s' for: %s\n", i+1, item.PubDate, item.Title)
-					parseErrors++
-					continue
-				}
-			}
+			return err
 		}
+	}
 
-		postParams := database.CreatePostParams{
-			ID:          uuid.New(),
-			CreatedAt:   time.Now(),
-			UpdatedAt:   time.Now(),
-			PublishedAt: pubDate,
-			Title:       item.Title,
-			Url: item.Link,
-			Description: stringPtrToNullString(item.Description),
-			FeedID:      feed.ID,
-		}
-		_, err = s.Db.CreatePost(context.Background(), postParams)
+	// Each post gets its own directory, as episodes from different feeds
+	// often share a file name.
+	dir = filepath.Join(dir, post.ID.String())
+	for i, enclosure := range enclosures {
+		fallbackName := fmt.Sprintf("%s-%d", post.ID, i+1)
+		fmt.Printf("Downloading %s\n", enclosure.Url)
+		path, err := download.File(s.context(), enclosure.Url, dir, fallbackName)
 		if err != nil {
-			if strings.Contains(err.Error(), "duplicate key value") {
-				duplicates++
-				continue
-			}
-			fmt.Printf("Error creating post: %v\n", err)
 			return err
 		}
-		postsCreated++
-		if postsCreated <= 3 {
-			fmt.Printf("✓ Created: %s (published: %s)\n", item.Title, pubDate.Format("2006-01-02"))
-		}
+		fmt.Printf("Saved to %s\n", path)
 	}
-
-	fmt.Printf("\n=== Summary ===\n")
-	fmt.Printf("Feed: %s\n", feed.Name)
-	fmt.Printf("Total items: %d\n", len(fetchedFeed.Channel.Item))
-	fmt.Printf("New posts: %d\n", postsCreated)
-	fmt.Printf("Duplicates: %d\n", duplicates)
-	fmt.Printf("Parse errors: %d\n", parseErrors)
-
 	return nil
 }
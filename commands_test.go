@@ -0,0 +1,695 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/WagnerJust/go-gator/internal/backup"
+	"github.com/WagnerJust/go-gator/internal/config"
+	"github.com/WagnerJust/go-gator/internal/database"
+	"github.com/WagnerJust/go-gator/internal/output"
+	"github.com/WagnerJust/go-gator/internal/rss"
+	"github.com/WagnerJust/go-gator/internal/storage"
+	"github.com/google/uuid"
+)
+
+// newTestState returns a state backed by an in-memory store and a config
+// file in a temporary directory, which also holds the snapshots.
+func newTestState(t *testing.T) *state {
+	t.Helper()
+	dir := t.TempDir()
+	t.Setenv("XDG_DATA_HOME", dir)
+	fetcher, err := rss.NewFetcher(rss.FetchOptions{})
+	if err != nil {
+		t.Fatalf("error creating fetcher: %v", err)
+	}
+	return &state{
+		Config:  config.NewConfigAt(filepath.Join(dir, "config.json")),
+		Db:      storage.NewMemory(),
+		Output:  output.Text,
+		Fetcher: fetcher,
+	}
+}
+
+// runCommand runs a registered command's handler the way commands.run does
+// once the database is connected, returning what it printed.
+func runCommand(t *testing.T, s *state, name string, args ...string) (string, error) {
+	t.Helper()
+	c := newCommands(&globalOptions{})
+	registerCommands(c)
+	info, ok := c.CmdRegister[name]
+	if !ok {
+		t.Fatalf("no command %q", name)
+	}
+	fs := info.flagSet(nil)
+	positional, err := parseInterspersed(fs, args)
+	if err != nil {
+		return "", err
+	}
+	if len(positional) < info.MinArgs || (info.MaxArgs >= 0 && len(positional) > info.MaxArgs) {
+		return "", fmt.Errorf("usage: %s", info.usageLine())
+	}
+	return captureStdout(t, func() error {
+		return info.Handler(s, Command{Name: name, Args: positional, Flags: fs})
+	})
+}
+
+func captureStdout(t *testing.T, run func() error) (string, error) {
+	t.Helper()
+	reader, writer, err := os.Pipe()
+	if err != nil {
+		t.Fatalf("error creating pipe: %v", err)
+	}
+	stdout := os.Stdout
+	os.Stdout = writer
+	printed := make(chan string)
+	go func() {
+		data, _ := io.ReadAll(reader)
+		printed <- string(data)
+	}()
+	err = run()
+	os.Stdout = stdout
+	writer.Close()
+	return <-printed, err
+}
+
+func mustRun(t *testing.T, s *state, name string, args ...string) string {
+	t.Helper()
+	out, err := runCommand(t, s, name, args...)
+	if err != nil {
+		t.Fatalf("%s %v: %v", name, args, err)
+	}
+	return out
+}
+
+func TestRegisterAndLogin(t *testing.T) {
+	s := newTestState(t)
+	mustRun(t, s, "register", "shawn")
+	mustRun(t, s, "register", "gus")
+	if s.Config.CurrentUserName != "gus" {
+		t.Fatalf("expected register to log in as gus, got %q", s.Config.CurrentUserName)
+	}
+	for _, name := range []string{"gus", "GUS"} {
+		_, err := runCommand(t, s, "register", name)
+		if err == nil || !strings.Contains(err.Error(), "already exists") {
+			t.Fatalf("expected registering %s to fail as taken, got %v", name, err)
+		}
+	}
+	invalid := map[string]string{
+		"burton_guster_the_3rd": "the most is 20",
+		"gus guster":            "has ' '",
+		"_gus":                  "must start with a letter or digit",
+	}
+	for name, reason := range invalid {
+		_, err := runCommand(t, s, "register", name)
+		if err == nil || !strings.Contains(err.Error(), reason) {
+			t.Fatalf("expected registering %q to fail with %q, got %v", name, reason, err)
+		}
+	}
+
+	mustRun(t, s, "login", "SHAWN")
+	saved := config.NewConfigAt(s.Config.Path)
+	err := saved.Read()
+	if err != nil || saved.CurrentUserName != "shawn" {
+		t.Fatalf("expected login to save shawn, got %q (%v)", saved.CurrentUserName, err)
+	}
+	_, err = runCommand(t, s, "login", "lassiter")
+	if !errors.Is(err, sql.ErrNoRows) {
+		t.Fatalf("expected logging in as an unknown user to fail, got %v", err)
+	}
+
+	out := mustRun(t, s, "users")
+	if out != "* shawn (current)\n* gus\n" {
+		t.Fatalf("unexpected users output:\n%s", out)
+	}
+}
+
+func TestUserCommands(t *testing.T) {
+	s := newTestState(t)
+	mustRun(t, s, "register", "gus")
+	mustRun(t, s, "addfeed", "Blog", "https://example.com/gus")
+	mustRun(t, s, "register", "shawn")
+	mustRun(t, s, "follow", "https://example.com/gus")
+
+	out := mustRun(t, s, "user", "show")
+	if !strings.HasPrefix(out, "name: shawn (current)\n") || !strings.Contains(out, "\tfeeds added: 0\n\tfollowing: 1\n") {
+		t.Fatalf("unexpected user show output:\n%s", out)
+	}
+	s.Output = output.JSON
+	out = mustRun(t, s, "user", "show", "GUS")
+	var records []userDetailRecord
+	err := json.Unmarshal([]byte(out), &records)
+	if err != nil || len(records) != 1 || records[0].Name != "gus" || records[0].FeedsAdded != 1 || records[0].Current {
+		t.Fatalf("unexpected user show json %s (%v)", out, err)
+	}
+	s.Output = output.Text
+
+	_, err = runCommand(t, s, "user", "rename", "shawn", "Gus")
+	if err == nil || !strings.Contains(err.Error(), "already exists") {
+		t.Fatalf("expected renaming onto a taken name to fail, got %v", err)
+	}
+	_, err = runCommand(t, s, "user", "rename", "shawn", "shawn spencer")
+	if err == nil || !strings.Contains(err.Error(), "has ' '") {
+		t.Fatalf("expected renaming to an invalid name to fail, got %v", err)
+	}
+	mustRun(t, s, "user", "rename", "shawn", "Shawn.Spencer")
+	if s.Config.CurrentUserName != "Shawn.Spencer" {
+		t.Fatalf("expected renaming to keep the user logged in, got %q", s.Config.CurrentUserName)
+	}
+	out = mustRun(t, s, "following")
+	if !strings.Contains(out, "Blog") {
+		t.Fatalf("expected the follow to survive the rename, got:\n%s", out)
+	}
+
+	_, err = runCommand(t, s, "user", "delete", "shawn.spencer")
+	if err == nil || !strings.Contains(err.Error(), "--yes") {
+		t.Fatalf("expected delete to refuse without a terminal, got %v", err)
+	}
+	out = mustRun(t, s, "user", "delete", "shawn.spencer", "--yes")
+	if !strings.Contains(out, "Deleted user Shawn.Spencer") {
+		t.Fatalf("unexpected user delete output:\n%s", out)
+	}
+	if s.Config.CurrentUserName != "" {
+		t.Fatalf("expected deleting the current user to log them out, got %q", s.Config.CurrentUserName)
+	}
+	snapshots, err := filepath.Glob(filepath.Join(filepath.Dir(s.Config.Path), "gator", "snapshots", "gator-user-delete-*.tar.gz"))
+	if err != nil || len(snapshots) != 1 {
+		t.Fatalf("expected a snapshot before deleting, got %v (%v)", snapshots, err)
+	}
+	out = mustRun(t, s, "users")
+	if out != "* gus\n" {
+		t.Fatalf("unexpected users output:\n%s", out)
+	}
+	_, err = runCommand(t, s, "user", "show")
+	if err == nil || err.Error() != "not logged in; pass a name" {
+		t.Fatalf("expected user show to ask for a name when logged out, got %v", err)
+	}
+
+	mustRun(t, s, "register", "lassie")
+	mustRun(t, s, "follow", "https://example.com/gus")
+	out = mustRun(t, s, "user", "delete", "gus", "--yes")
+	if !strings.Contains(out, "Deleted user gus, with the feeds they added and their follows, and so 0 posts and 1 follows by 1 other users\n") {
+		t.Fatalf("expected the delete to count what other users lose, got:\n%s", out)
+	}
+}
+
+func TestLoggedInCommandsNeedAUser(t *testing.T) {
+	s := newTestState(t)
+	_, err := runCommand(t, s, "following")
+	if !errors.Is(err, sql.ErrNoRows) {
+		t.Fatalf("expected following to fail without a user, got %v", err)
+	}
+	_, err = runCommand(t, s, "addfeed", "Blog")
+	if err == nil || !strings.HasPrefix(err.Error(), "usage:") {
+		t.Fatalf("expected a usage error, got %v", err)
+	}
+}
+
+func TestFeedsAndFollows(t *testing.T) {
+	s := newTestState(t)
+	mustRun(t, s, "register", "shawn")
+	out := mustRun(t, s, "addfeed", "Psych", "https://example.com/psych")
+	if out != "shawn is now following Psych\n" {
+		t.Fatalf("unexpected addfeed output:\n%s", out)
+	}
+	_, err := runCommand(t, s, "addfeed", "Psych again", "https://example.com/psych")
+	if !storage.IsDuplicate(err) {
+		t.Fatalf("expected adding a feed twice to fail, got %v", err)
+	}
+
+	mustRun(t, s, "register", "gus")
+	out = mustRun(t, s, "following")
+	if out != "You are not following any feeds\n" {
+		t.Fatalf("unexpected following output:\n%s", out)
+	}
+	mustRun(t, s, "follow", "https://example.com/psych")
+	out = mustRun(t, s, "following")
+	if out != "You are following these feeds:\n\t- Psych\n" {
+		t.Fatalf("unexpected following output:\n%s", out)
+	}
+
+	s.Output = output.JSON
+	out = mustRun(t, s, "feeds")
+	var feeds []feedRecord
+	err = json.Unmarshal([]byte(out), &feeds)
+	if err != nil || len(feeds) != 1 || feeds[0].User != "shawn" || feeds[0].URL != "https://example.com/psych" {
+		t.Fatalf("unexpected feeds output %s (%v)", out, err)
+	}
+
+	s.Output = output.Text
+	s.UserOverride = "shawn"
+	mustRun(t, s, "unfollow", "https://example.com/psych")
+	out = mustRun(t, s, "following")
+	if out != "You are not following any feeds\n" {
+		t.Fatalf("expected --user shawn to have unfollowed, got:\n%s", out)
+	}
+	s.UserOverride = ""
+	out = mustRun(t, s, "following")
+	if !strings.Contains(out, "Psych") {
+		t.Fatalf("expected gus to still follow Psych, got:\n%s", out)
+	}
+
+	mustRun(t, s, "reset", "--yes")
+	users, err := s.Db.GetAllUsers(context.Background())
+	if err != nil || len(users) != 0 {
+		t.Fatalf("expected reset to delete every user, got %v (%v)", users, err)
+	}
+}
+
+func TestBrowseShowsNewestPostsFirst(t *testing.T) {
+	s := newTestState(t)
+	mustRun(t, s, "register", "shawn")
+	mustRun(t, s, "addfeed", "Psych", "https://example.com/psych")
+	feed, err := s.Db.GetFeedByUrl(context.Background(), "https://example.com/psych")
+	if err != nil {
+		t.Fatalf("error getting feed: %v", err)
+	}
+	now := time.Now()
+	for i, title := range []string{"Pilot", "Spellingg Bee", "Speak Now or Forever Hold Your Piece"} {
+		_, err = s.Db.CreatePost(context.Background(), database.CreatePostParams{
+			ID:          uuid.New(),
+			CreatedAt:   now,
+			UpdatedAt:   now,
+			Title:       title,
+			Url:         fmt.Sprintf("https://example.com/psych/%d", i),
+			PublishedAt: now.Add(time.Duration(i) * time.Hour),
+			FeedID:      feed.ID,
+		})
+		if err != nil {
+			t.Fatalf("error creating post: %v", err)
+		}
+	}
+
+	out := mustRun(t, s, "browse", "--limit", "2")
+	first := strings.Index(out, "Speak Now")
+	second := strings.Index(out, "Spellingg Bee")
+	if first < 0 || second < first || strings.Contains(out, "Pilot") {
+		t.Fatalf("expected the two newest posts, newest first, got:\n%s", out)
+	}
+
+	s.Output = output.JSON
+	out = mustRun(t, s, "browse", "5")
+	var posts []postRecord
+	err = json.Unmarshal([]byte(out), &posts)
+	if err != nil || len(posts) != 3 || posts[2].Title != "Pilot" {
+		t.Fatalf("unexpected browse output %s (%v)", out, err)
+	}
+}
+
+const testFeed = `<rss><channel><title>Psych</title>
+<item><title>Pilot</title><link>https://example.com/psych/1</link><pubDate>Fri, 07 Jul 2006 22:00:00 -0400</pubDate>
+<enclosure url="https://example.com/psych/1.mp3" type="audio/mpeg" length="1048576"/></item>
+<item><title>Spellingg Bee</title><link>https://example.com/psych/2</link><pubDate>Fri, 14 Jul 2006 22:00:00 -0400</pubDate></item>
+<item><title>Undated</title><link>https://example.com/psych/3</link><pubDate>someday</pubDate></item>
+</channel></rss>`
+
+func TestAggOneStoresNewPostsOnce(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, testFeed)
+	}))
+	defer server.Close()
+
+	s := newTestState(t)
+	mustRun(t, s, "register", "shawn")
+	mustRun(t, s, "addfeed", "Psych", server.URL)
+
+	out := mustRun(t, s, "aggone", server.URL)
+	if !strings.Contains(out, "New posts: 2\n") || !strings.Contains(out, "Parse errors: 1\n") {
+		t.Fatalf("unexpected first aggone output:\n%s", out)
+	}
+	out = mustRun(t, s, "aggone", server.URL)
+	if !strings.Contains(out, "New posts: 0\n") || !strings.Contains(out, "Duplicates: 2\n") {
+		t.Fatalf("unexpected second aggone output:\n%s", out)
+	}
+
+	feed, err := s.Db.GetFeedByUrl(context.Background(), server.URL)
+	if err != nil || !feed.LastFetchedAt.Valid {
+		t.Fatalf("expected the feed to be marked fetched, got %+v (%v)", feed, err)
+	}
+	out = mustRun(t, s, "browse")
+	if !strings.Contains(out, "Media: https://example.com/psych/1.mp3 (audio/mpeg, 1.0 MB)") {
+		t.Fatalf("expected the enclosure in browse output, got:\n%s", out)
+	}
+}
+
+// failingEnclosures is a Store whose enclosure batches fail.
+type failingEnclosures struct {
+	storage.Store
+}
+
+func (f failingEnclosures) InTx(ctx context.Context, fn func(storage.Store) error) error {
+	return f.Store.InTx(ctx, func(tx storage.Store) error {
+		return fn(failingEnclosures{tx})
+	})
+}
+
+func (f failingEnclosures) CreatePostEnclosures(ctx context.Context, args []database.CreatePostEnclosureParams) error {
+	return errors.New("disk full")
+}
+
+func TestAggOneKeepsFailedFeedsUnfetched(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, testFeed)
+	}))
+	defer server.Close()
+
+	s := newTestState(t)
+	mustRun(t, s, "register", "shawn")
+	mustRun(t, s, "addfeed", "Psych", server.URL)
+	store := s.Db
+	s.Db = failingEnclosures{store}
+	_, err := runCommand(t, s, "aggone", server.URL)
+	if err == nil || !strings.Contains(err.Error(), "disk full") {
+		t.Fatalf("expected the enclosure error, got %v", err)
+	}
+
+	feed, err := store.GetFeedByUrl(context.Background(), server.URL)
+	if err != nil || feed.LastFetchedAt.Valid {
+		t.Fatalf("expected the feed to stay unfetched, got %+v (%v)", feed, err)
+	}
+	user, _ := store.GetUserByName(context.Background(), "shawn")
+	posts, err := store.GetPostsForUser(context.Background(), database.GetPostsForUserParams{UserID: user.ID, Limit: 10})
+	if err != nil || len(posts) != 0 {
+		t.Fatalf("expected no posts from the failed fetch, got %+v (%v)", posts, err)
+	}
+
+	s.Db = store
+	out := mustRun(t, s, "aggone", server.URL)
+	if !strings.Contains(out, "New posts: 2\n") {
+		t.Fatalf("expected the next fetch to store the posts, got:\n%s", out)
+	}
+}
+
+func TestAggSkipsPastFeedsThatFailToFetch(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, testFeed)
+	}))
+	defer server.Close()
+	dead := httptest.NewServer(http.NotFoundHandler())
+	dead.Close()
+
+	s := newTestState(t)
+	mustRun(t, s, "register", "shawn")
+	mustRun(t, s, "addfeed", "Dead", dead.URL)
+	mustRun(t, s, "addfeed", "Psych", server.URL)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	s.ctx = ctx
+	done := make(chan error, 1)
+	var out string
+	go func() {
+		var err error
+		out, err = runCommand(t, s, "agg", "10ms")
+		done <- err
+	}()
+
+	deadline := time.After(5 * time.Second)
+	for {
+		feed, err := s.Db.GetFeedByUrl(context.Background(), server.URL)
+		if err == nil && feed.LastFetchedAt.Valid {
+			break
+		}
+		select {
+		case <-deadline:
+			cancel()
+			<-done
+			t.Fatalf("expected agg to get past the dead feed, got:\n%s", out)
+		case <-time.After(10 * time.Millisecond):
+		}
+	}
+	cancel()
+	err := <-done
+	if err != nil {
+		t.Fatalf("expected agg to keep running, got %v", err)
+	}
+	if !strings.Contains(out, "Error collecting feed Dead: ") {
+		t.Errorf("expected the failed fetch to be reported, got:\n%s", out)
+	}
+	feed, err := s.Db.GetFeedByUrl(context.Background(), dead.URL)
+	if err != nil || !feed.LastFetchedAt.Valid || !strings.HasPrefix(feed.LastFetchWarning.String, "fetch failed: ") {
+		t.Fatalf("expected the dead feed to be marked fetched with a warning, got %+v (%v)", feed, err)
+	}
+}
+
+// failingFeedList is a Store that can't list every feed, as prune does.
+type failingFeedList struct {
+	storage.Store
+}
+
+func (f failingFeedList) GetAllFeeds(ctx context.Context) ([]database.Feed, error) {
+	return nil, errors.New("connection reset")
+}
+
+func TestAggKeepsCollectingWhenPruningFails(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, testFeed)
+	}))
+	defer server.Close()
+
+	s := newTestState(t)
+	s.Config.Aggregator.KeepPosts = 1
+	s.Config.Aggregator.PruneInterval = "1h"
+	mustRun(t, s, "register", "shawn")
+	mustRun(t, s, "addfeed", "Psych", server.URL)
+	store := s.Db
+	s.Db = failingFeedList{store}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	s.ctx = ctx
+	done := make(chan error, 1)
+	var out string
+	go func() {
+		var err error
+		out, err = runCommand(t, s, "agg", "10ms")
+		done <- err
+	}()
+
+	deadline := time.After(5 * time.Second)
+	for {
+		feed, err := store.GetFeedByUrl(context.Background(), server.URL)
+		if err == nil && feed.LastFetchedAt.Valid {
+			break
+		}
+		select {
+		case <-deadline:
+			cancel()
+			<-done
+			t.Fatalf("expected agg to collect feeds after the failed prune, got:\n%s", out)
+		case <-time.After(10 * time.Millisecond):
+		}
+	}
+	cancel()
+	err := <-done
+	if err != nil {
+		t.Fatalf("expected agg to keep running, got %v", err)
+	}
+	if !strings.Contains(out, "Error pruning posts: connection reset\n") {
+		t.Errorf("expected the failed prune to be reported, got:\n%s", out)
+	}
+}
+
+func TestPruneKeepsUnreadPosts(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, testFeed)
+	}))
+	defer server.Close()
+
+	s := newTestState(t)
+	s.Config.Aggregator.KeepPosts = 1
+	mustRun(t, s, "register", "shawn")
+	mustRun(t, s, "addfeed", "Psych", server.URL)
+	mustRun(t, s, "aggone", server.URL)
+	for _, args := range [][]string{{"prune", "--dry-run"}, {"prune"}} {
+		out := mustRun(t, s, args[0], args[1:]...)
+		if out != "Nothing to prune\n1 posts kept because they are unread\n" {
+			t.Fatalf("expected %v to report the kept unread post, got:\n%s", args, out)
+		}
+	}
+
+	user, _ := s.Db.GetUserByName(context.Background(), "shawn")
+	posts, err := s.Db.GetPostsForUser(context.Background(), database.GetPostsForUserParams{UserID: user.ID, Limit: 10})
+	if err != nil || len(posts) != 2 {
+		t.Fatalf("expected two posts, got %+v (%v)", posts, err)
+	}
+	for _, post := range posts {
+		err = s.Db.SetPostRead(context.Background(), database.SetPostReadParams{ID: uuid.New(), CreatedAt: time.Now(), UpdatedAt: time.Now(), UserID: user.ID, PostID: post.ID, ReadAt: sql.NullTime{Time: time.Now(), Valid: true}})
+		if err != nil {
+			t.Fatalf("error marking post read: %v", err)
+		}
+	}
+	s.Config.Aggregator.FeedRetention = map[string]config.RetentionPolicy{server.URL: {}}
+	out := mustRun(t, s, "prune", "--dry-run")
+	if out != "Nothing to prune\n" {
+		t.Fatalf("expected the feed's policy to keep everything, got:\n%s", out)
+	}
+
+	s.Config.Aggregator.FeedRetention = nil
+	out = mustRun(t, s, "prune", "--dry-run")
+	if !strings.HasPrefix(out, "Would delete 1 posts from Psych\n") || !strings.Contains(out, "Pilot") {
+		t.Fatalf("unexpected dry run output:\n%s", out)
+	}
+	out = mustRun(t, s, "prune")
+	if out != "Deleted 1 posts from Psych\n" {
+		t.Fatalf("unexpected prune output:\n%s", out)
+	}
+	out = mustRun(t, s, "browse")
+	if strings.Contains(out, "Pilot") || !strings.Contains(out, "Spellingg Bee") {
+		t.Fatalf("expected only the newest post to be left, got:\n%s", out)
+	}
+
+	s.Output = output.JSON
+	out = mustRun(t, s, "prune")
+	if out != "[]\n" {
+		t.Fatalf("expected nothing left to prune, got %s", out)
+	}
+}
+
+func TestBrowseShowsTimesInConfiguredZone(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, testFeed)
+	}))
+	defer server.Close()
+
+	s := newTestState(t)
+	s.Config.Output.Timezone = "Asia/Tokyo"
+	mustRun(t, s, "register", "shawn")
+	mustRun(t, s, "addfeed", "Psych", server.URL)
+	mustRun(t, s, "aggone", server.URL)
+
+	out := mustRun(t, s, "browse", "5")
+	if !strings.Contains(out, "2006-07-08 11:00") || !strings.Contains(out, "2006-07-15 11:00") {
+		t.Fatalf("expected publish times in Tokyo time, got:\n%s", out)
+	}
+	s.Output = output.JSON
+	out = mustRun(t, s, "browse", "5")
+	if !strings.Contains(out, `"published_at": "2006-07-08T02:00:00Z"`) {
+		t.Fatalf("expected structured output in UTC, got %s", out)
+	}
+}
+
+func TestResetConfirmsAndSnapshots(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, testFeed)
+	}))
+	defer server.Close()
+
+	s := newTestState(t)
+	mustRun(t, s, "register", "gus")
+	mustRun(t, s, "addfeed", "Blog", "https://example.com/gus")
+	mustRun(t, s, "register", "shawn")
+	mustRun(t, s, "addfeed", "Psych", server.URL)
+	mustRun(t, s, "follow", "https://example.com/gus")
+	mustRun(t, s, "aggone", server.URL)
+
+	// Test stdin is never a terminal, so only --yes gets past confirming.
+	_, err := runCommand(t, s, "reset", "posts")
+	if err == nil || !strings.Contains(err.Error(), "--yes") {
+		t.Fatalf("expected reset to refuse without a terminal, got %v", err)
+	}
+	_, err = runCommand(t, s, "reset", "posts", "now", "--yes")
+	if err == nil || !strings.HasPrefix(err.Error(), "usage:") {
+		t.Fatalf("expected a usage error, got %v", err)
+	}
+	_, err = runCommand(t, s, "reset", "user", "lassiter", "--yes")
+	if !errors.Is(err, sql.ErrNoRows) {
+		t.Fatalf("expected resetting an unknown user to fail, got %v", err)
+	}
+	snapshots, _ := filepath.Glob(filepath.Join(filepath.Dir(s.Config.Path), "gator", "snapshots", "*"))
+	if len(snapshots) != 0 {
+		t.Fatalf("expected no snapshots before a reset went ahead, got %v", snapshots)
+	}
+
+	ctx := context.Background()
+	out := mustRun(t, s, "reset", "posts", "--yes")
+	if !strings.Contains(out, "Deleted every post\n") || !strings.Contains(out, " reset --yes && "+programName+" restore ") {
+		t.Fatalf("unexpected reset output:\n%s", out)
+	}
+	posts, err := s.Db.GetAllPosts(ctx)
+	if err != nil || len(posts) != 0 {
+		t.Fatalf("expected no posts, got %+v (%v)", posts, err)
+	}
+	feeds, err := s.Db.GetAllFeeds(ctx)
+	if err != nil || len(feeds) != 2 {
+		t.Fatalf("expected the feeds to be kept, got %+v (%v)", feeds, err)
+	}
+
+	mustRun(t, s, "reset", "user", "gus", "--yes")
+	users, err := s.Db.GetAllUsers(ctx)
+	if err != nil || len(users) != 1 || users[0].Name != "shawn" {
+		t.Fatalf("expected only shawn to be left, got %+v (%v)", users, err)
+	}
+	out = mustRun(t, s, "following")
+	if strings.Contains(out, "Blog") {
+		t.Fatalf("expected gus's feed to go with him, got:\n%s", out)
+	}
+
+	mustRun(t, s, "reset", "feeds", "--yes")
+	feeds, err = s.Db.GetAllFeeds(ctx)
+	if err != nil || len(feeds) != 0 {
+		t.Fatalf("expected no feeds, got %+v (%v)", feeds, err)
+	}
+
+	snapshots, err = filepath.Glob(filepath.Join(filepath.Dir(s.Config.Path), "gator", "snapshots", "gator-reset-*.tar.gz"))
+	if err != nil || len(snapshots) != 3 {
+		t.Fatalf("expected a snapshot for each reset, got %v (%v)", snapshots, err)
+	}
+	info, err := os.Stat(snapshots[0])
+	if err != nil || info.Mode().Perm() != 0600 {
+		t.Fatalf("expected a private snapshot, got %v (%v)", info, err)
+	}
+}
+
+func TestBackupAndRestore(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, testFeed)
+	}))
+	defer server.Close()
+
+	s := newTestState(t)
+	mustRun(t, s, "register", "shawn")
+	mustRun(t, s, "addfeed", "Psych", server.URL)
+	mustRun(t, s, "aggone", server.URL)
+	before := mustRun(t, s, "browse")
+
+	path := filepath.Join(t.TempDir(), "gator.tar.gz")
+	out := mustRun(t, s, "backup", path)
+	if !strings.HasPrefix(out, "Backed up 1 users, 1 feeds, 1 follows, ") {
+		t.Fatalf("unexpected backup output:\n%s", out)
+	}
+	_, err := runCommand(t, s, "backup", path)
+	if err == nil || !strings.Contains(err.Error(), "already exists") {
+		t.Fatalf("expected backup to refuse to overwrite, got %v", err)
+	}
+	_, err = runCommand(t, s, "restore", path)
+	if !errors.Is(err, backup.ErrNotEmpty) {
+		t.Fatalf("expected restore to refuse a full database, got %v", err)
+	}
+
+	mustRun(t, s, "reset", "--yes")
+	out = mustRun(t, s, "restore", path)
+	if !strings.HasPrefix(out, "Restored 1 users, 1 feeds, 1 follows, ") {
+		t.Fatalf("unexpected restore output:\n%s", out)
+	}
+	if after := mustRun(t, s, "browse"); after != before {
+		t.Fatalf("expected the same posts after restoring, got:\n%s\nwant:\n%s", after, before)
+	}
+}
+
+func TestBrowseWithoutPostsPrintsNothing(t *testing.T) {
+	s := newTestState(t)
+	mustRun(t, s, "register", "shawn")
+	if out := mustRun(t, s, "browse"); out != "" {
+		t.Fatalf("expected no output without posts, got %q", out)
+	}
+}
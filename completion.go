@@ -0,0 +1,279 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/WagnerJust/go-gator/internal/output"
+)
+
+// completeCommand is the hidden command the completion scripts call. It gets
+// the words typed after the program name, the last being the one under the
+// cursor, and prints one candidate per line.
+const completeCommand = "__complete"
+
+var completionScripts = map[string]string{
+	"bash": `# bash completion for {{prog}}
+# Load with: source <({{prog}} completion bash)
+_{{func}}() {
+    local line="${COMP_LINE:0:COMP_POINT}"
+    local -a words
+    read -ra words <<< "$line"
+    if [[ -z "$line" || "$line" == *[[:space:]] ]]; then
+        words+=("")
+    fi
+    local cur="${words[${#words[@]}-1]}"
+    local IFS=$'\n'
+    COMPREPLY=($({{prog}} {{complete}} "${words[@]:1}" 2>/dev/null))
+    # bash splits words at ':' so only the part of cur after the last one is
+    # being replaced; strip what comes before it from each URL.
+    if [[ "$cur" == *:* && "$COMP_WORDBREAKS" == *:* ]]; then
+        local prefix="${cur%"${cur##*:}"}"
+        COMPREPLY=("${COMPREPLY[@]#"$prefix"}")
+    fi
+}
+complete -o default -F _{{func}} {{prog}}
+`,
+	"zsh": `#compdef {{prog}}
+# zsh completion for {{prog}}
+# Load with: source <({{prog}} completion zsh)
+_{{func}}() {
+    local -a candidates
+    candidates=("${(@f)$({{prog}} {{complete}} "${(@)words[2,CURRENT]}" 2>/dev/null)}")
+    if [[ -n "${candidates[1]}" ]]; then
+        compadd -Q -- "${candidates[@]}"
+    else
+        _files
+    fi
+}
+compdef _{{func}} {{prog}}
+`,
+	"fish": `# fish completion for {{prog}}
+# Load with: {{prog}} completion fish | source
+function __{{func}}_complete
+    set -l tokens (commandline -opc)
+    set -l current (commandline -ct)
+    {{prog}} {{complete}} $tokens[2..-1] "$current" 2>/dev/null
+end
+complete -c {{prog}} -f -a '(__{{func}}_complete)'
+`,
+}
+
+func handlerCompletion(s *state, cmd Command) error {
+	script, ok := completionScripts[cmd.Args[0]]
+	if !ok {
+		return fmt.Errorf("unsupported shell %q (expected bash, zsh or fish)", cmd.Args[0])
+	}
+	script = strings.NewReplacer(
+		"{{prog}}", programName,
+		"{{func}}", strings.ReplaceAll(programName, "-", "_"),
+		"{{complete}}", completeCommand,
+	).Replace(script)
+	fmt.Print(script)
+	return nil
+}
+
+// handlerComplete prints the candidates for the last word in cmd.Args.
+// Lookup failures just mean fewer candidates; completion never errors.
+func (c *commands) handlerComplete(s *state, cmd Command) error {
+	for _, candidate := range c.complete(s, cmd.Args) {
+		fmt.Println(candidate)
+	}
+	return nil
+}
+
+func (c *commands) complete(s *state, words []string) []string {
+	if len(words) == 0 {
+		words = []string{""}
+	}
+	current := words[len(words)-1]
+	globalFlags := c.globalFlagSet()
+
+	// Walk the finished words to find the command, its positional arguments
+	// and whether the word being completed is a flag's value. Global flags
+	// on the line are collected so lookups use the config, profile, database
+	// and user they select.
+	globals := *c.globals
+	typedGlobals := flag.NewFlagSet(programName, flag.ContinueOnError)
+	globals.define(typedGlobals)
+	var info *commandInfo
+	var fs = globalFlags
+	var positional []string
+	var pendingFlag string
+	for _, word := range words[:len(words)-1] {
+		if pendingFlag != "" {
+			if typedGlobals.Lookup(pendingFlag) != nil {
+				typedGlobals.Set(pendingFlag, word)
+			}
+			pendingFlag = ""
+			continue
+		}
+		if word == "--" {
+			continue
+		}
+		if strings.HasPrefix(word, "-") {
+			name, value, hasValue := strings.Cut(strings.TrimLeft(word, "-"), "=")
+			if typedGlobals.Lookup(name) != nil && (hasValue || !takesValue(typedGlobals, name)) {
+				if !hasValue {
+					value = "true"
+				}
+				typedGlobals.Set(name, value)
+			}
+			if !hasValue && takesValue(fs, name) {
+				pendingFlag = name
+			}
+			continue
+		}
+		if info == nil {
+			found, ok := c.CmdRegister[word]
+			if !ok {
+				return nil
+			}
+			info = &found
+			fs = found.flagSet(&globalOptions{})
+			continue
+		}
+		positional = append(positional, word)
+	}
+	if globals != *c.globals {
+		*c.globals = globals
+		err := c.checkConfig(s, commandInfo{AnyConfig: true})
+		if err == nil {
+			err = c.applyGlobals(s)
+		}
+		if err != nil {
+			return nil
+		}
+	}
+
+	var candidates []string
+	switch {
+	case pendingFlag != "":
+		candidates = c.completeFlagValue(s, pendingFlag)
+	case strings.HasPrefix(current, "-"):
+		fs.VisitAll(func(f *flag.Flag) {
+			if len(f.Name) > 1 {
+				candidates = append(candidates, "--"+f.Name)
+			}
+		})
+	case info == nil:
+		candidates = c.visibleNames()
+	default:
+		candidates = c.completeArg(s, *info, len(positional))
+	}
+	return withPrefix(candidates, current)
+}
+
+func (c *commands) completeFlagValue(s *state, name string) []string {
+	switch name {
+	case "user":
+		return completeUserNames(s)
+	case "profile":
+		return s.Config.ProfileNames()
+	case "output", "o":
+		names := make([]string, len(output.Formats))
+		for i, format := range output.Formats {
+			names[i] = string(format)
+		}
+		return names
+	}
+	return nil
+}
+
+// completeArg returns candidates for a command's index'th positional
+// argument.
+func (c *commands) completeArg(s *state, info commandInfo, index int) []string {
+	if info.Name == "profile" && index == 1 {
+		return s.Config.ProfileNames()
+	}
+	if (info.Name == "reset" || info.Name == "user") && index == 1 {
+		return completeUserNames(s)
+	}
+	if index != 0 {
+		return nil
+	}
+	switch info.Name {
+	case "help":
+		return c.visibleNames()
+	case "completion":
+		names := make([]string, 0, len(completionScripts))
+		for name := range completionScripts {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		return names
+	case "config":
+		return []string{"validate"}
+	case "migrate":
+		return []string{"up", "down", "status", "redo"}
+	case "reset":
+		return []string{"all", "posts", "feeds", "user"}
+	case "profile":
+		return []string{"list", "use", "add", "remove"}
+	case "user":
+		return []string{"show", "rename", "delete"}
+	case "login":
+		return completeUserNames(s)
+	case "follow", "aggone":
+		feeds, err := s.Db.GetAllFeeds(context.Background())
+		if err != nil {
+			return nil
+		}
+		urls := make([]string, 0, len(feeds))
+		for _, feed := range feeds {
+			urls = append(urls, feed.Url)
+		}
+		return urls
+	case "unfollow":
+		user, err := s.Db.GetUserByName(context.Background(), s.currentUserName())
+		if err != nil {
+			return nil
+		}
+		follows, err := s.Db.GetFeedFollowsForUser(context.Background(), user.ID)
+		if err != nil {
+			return nil
+		}
+		urls := make([]string, 0, len(follows))
+		for _, follow := range follows {
+			urls = append(urls, follow.FeedUrl)
+		}
+		return urls
+	}
+	return nil
+}
+
+func completeUserNames(s *state) []string {
+	users, err := s.Db.GetAllUsers(context.Background())
+	if err != nil {
+		return nil
+	}
+	names := make([]string, 0, len(users))
+	for _, user := range users {
+		names = append(names, user.Name)
+	}
+	return names
+}
+
+// takesValue reports whether the named flag in fs needs a separate value
+// word, as opposed to a boolean flag.
+func takesValue(fs *flag.FlagSet, name string) bool {
+	f := fs.Lookup(name)
+	if f == nil {
+		return false
+	}
+	boolFlag, ok := f.Value.(interface{ IsBoolFlag() bool })
+	return !ok || !boolFlag.IsBoolFlag()
+}
+
+func withPrefix(candidates []string, prefix string) []string {
+	var matches []string
+	for _, candidate := range candidates {
+		if strings.HasPrefix(candidate, prefix) {
+			matches = append(matches, candidate)
+		}
+	}
+	return matches
+}
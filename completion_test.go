@@ -0,0 +1,119 @@
+package main
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/WagnerJust/go-gator/internal/config"
+	"github.com/WagnerJust/go-gator/internal/database"
+	"github.com/WagnerJust/go-gator/internal/migrate"
+	"github.com/WagnerJust/go-gator/internal/storage"
+	"github.com/google/uuid"
+)
+
+func complete(t *testing.T, s *state, words ...string) []string {
+	t.Helper()
+	c := newCommands(&globalOptions{ConfigPath: s.Config.Path})
+	registerCommands(c)
+	return c.complete(s, words)
+}
+
+func TestCompleteCommandsFlagsAndArguments(t *testing.T) {
+	s := newTestState(t)
+	mustRun(t, s, "register", "gus")
+	mustRun(t, s, "addfeed", "Blog", "https://example.com/gus")
+
+	cases := []struct {
+		words    []string
+		expected []string
+	}{
+		{[]string{"brow"}, []string{"browse"}},
+		{[]string{"browse", "--li"}, []string{"--limit"}},
+		{[]string{"--output", "j"}, []string{"json"}},
+		{[]string{"migrate", ""}, []string{"up", "down", "status", "redo"}},
+		{[]string{"user", "show", ""}, []string{"gus"}},
+		{[]string{"follow", "https://"}, []string{"https://example.com/gus"}},
+		{[]string{"nosuchcommand", ""}, nil},
+	}
+	for _, c := range cases {
+		if actual := complete(t, s, c.words...); !slices.Equal(actual, c.expected) {
+			t.Errorf("%q: expected %q, got %q", c.words, c.expected, actual)
+		}
+	}
+}
+
+// migratedDb creates a SQLite database at path holding one user.
+func migratedDb(t *testing.T, path, user string) {
+	t.Helper()
+	ctx := context.Background()
+	db, err := storage.Open(path)
+	if err != nil {
+		t.Fatalf("error opening database: %v", err)
+	}
+	defer db.Close()
+	migrator, err := migrate.New(db, storage.SQLite)
+	if err != nil {
+		t.Fatalf("error loading migrations: %v", err)
+	}
+	_, err = migrator.Up(ctx)
+	if err != nil {
+		t.Fatalf("error migrating: %v", err)
+	}
+	now := time.Now().UTC()
+	_, err = storage.New(db, storage.SQLite).CreateUser(ctx, database.CreateUserParams{ID: uuid.New(), CreatedAt: now, UpdatedAt: now, Name: user})
+	if err != nil {
+		t.Fatalf("error creating user: %v", err)
+	}
+}
+
+func TestCompleteUsesGlobalFlagsOnTheLine(t *testing.T) {
+	dir := t.TempDir()
+	local, team, other := filepath.Join(dir, "local.db"), filepath.Join(dir, "team.db"), filepath.Join(dir, "other.db")
+	migratedDb(t, local, "shawn")
+	migratedDb(t, team, "gus")
+	migratedDb(t, other, "lassiter")
+	writeConfig := func(name, dbUrl string) string {
+		path := filepath.Join(dir, name)
+		data := `{"db_url": "sqlite://` + dbUrl + `", "profiles": {"team": {"db_url": "sqlite://` + team + `"}}}`
+		err := os.WriteFile(path, []byte(data), 0600)
+		if err != nil {
+			t.Fatalf("error writing config: %v", err)
+		}
+		return path
+	}
+	mainConfig, otherConfig := writeConfig("config.json", local), writeConfig("other.json", other)
+
+	cases := []struct {
+		words    []string
+		expected []string
+	}{
+		{[]string{"login", ""}, []string{"shawn"}},
+		{[]string{"login", "--auto-migrate", "s"}, []string{"shawn"}},
+		{[]string{"--profile", "team", "login", ""}, []string{"gus"}},
+		{[]string{"login", "--profile=team", ""}, []string{"gus"}},
+		{[]string{"--db-url", "sqlite://" + other, "login", ""}, []string{"lassiter"}},
+		{[]string{"login", "--config", otherConfig, ""}, []string{"lassiter"}},
+		{[]string{"--config", otherConfig, "--profile", "team", "user", "show", ""}, []string{"gus"}},
+		{[]string{"--profile", "nosuchprofile", "login", ""}, nil},
+	}
+	for _, c := range cases {
+		globals := &globalOptions{ConfigPath: mainConfig}
+		commands := newCommands(globals)
+		registerCommands(commands)
+		s := &state{Config: config.NewConfigAt(mainConfig)}
+		s.loadConfig()
+		err := commands.applyGlobals(s)
+		if err != nil {
+			t.Fatalf("error applying globals: %v", err)
+		}
+		actual := commands.complete(s, c.words)
+		s.close()
+		if !slices.Equal(actual, c.expected) {
+			t.Errorf("%q: expected %q, got %q", c.words, c.expected, actual)
+		}
+	}
+}
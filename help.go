@@ -0,0 +1,127 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"text/tabwriter"
+)
+
+func (c *commands) handlerHelp(s *state, cmd Command) error {
+	if len(cmd.Args) == 0 {
+		c.printHelp(os.Stdout)
+		return nil
+	}
+	info, ok := c.CmdRegister[cmd.Args[0]]
+	if !ok {
+		return unknownCommandError(cmd.Args[0], c.visibleNames())
+	}
+	return c.printCommandHelp(os.Stdout, info)
+}
+
+// printHelp lists every visible command with its summary.
+func (c *commands) printHelp(w io.Writer) {
+	fmt.Fprintf(w, "Usage: %s [global flags] <command> [flags] [args]\n\n", programName)
+	fmt.Fprintln(w, "Commands:")
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	for _, name := range c.visibleNames() {
+		fmt.Fprintf(tw, "  %s\t%s\n", name, c.CmdRegister[name].Summary)
+	}
+	tw.Flush()
+	fmt.Fprintln(w, "\nGlobal flags:")
+	printFlags(w, c.globalFlagSet())
+	fmt.Fprintf(w, "\nRun '%s help <command>' for details on a command.\n", programName)
+}
+
+func (c *commands) printCommandHelp(w io.Writer, info commandInfo) error {
+	fmt.Fprintf(w, "Usage: %s\n\n%s\n", info.usageLine(), info.Summary)
+	if info.Flags != nil {
+		fmt.Fprintln(w, "\nFlags:")
+		printFlags(w, info.flagSet(nil))
+	}
+	fmt.Fprintf(w, "\nGlobal flags are also accepted; see '%s help'.\n", programName)
+	return nil
+}
+
+func (c *commands) globalFlagSet() *flag.FlagSet {
+	fs := flag.NewFlagSet(programName, flag.ContinueOnError)
+	(&globalOptions{}).define(fs)
+	return fs
+}
+
+// printFlags writes one line per flag in the --name style the parser
+// accepts, rather than flag.PrintDefaults' single-dash style.
+func printFlags(w io.Writer, fs *flag.FlagSet) {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fs.VisitAll(func(f *flag.Flag) {
+		valueName, usage := flag.UnquoteUsage(f)
+		name := "--" + f.Name
+		if len(f.Name) == 1 {
+			name = "-" + f.Name
+		}
+		if valueName != "" {
+			name += " " + valueName
+		}
+		if f.DefValue != "" && f.DefValue != "false" && f.DefValue != "0" {
+			usage += fmt.Sprintf(" (default %s)", f.DefValue)
+		}
+		fmt.Fprintf(tw, "  %s\t%s\n", name, usage)
+	})
+	tw.Flush()
+}
+
+// suggestCommands returns the known commands close enough to name to be a
+// likely typo: a small edit distance away, or starting with it.
+func suggestCommands(name string, known []string) []string {
+	type candidate struct {
+		name     string
+		distance int
+	}
+	maxDistance := 2
+	if len(name) <= 3 {
+		maxDistance = 1
+	}
+	var candidates []candidate
+	for _, command := range known {
+		distance := levenshtein(strings.ToLower(name), command)
+		if distance <= maxDistance || (len(name) > 1 && strings.HasPrefix(command, strings.ToLower(name))) {
+			candidates = append(candidates, candidate{command, distance})
+		}
+	}
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return candidates[i].distance < candidates[j].distance
+	})
+	suggestions := make([]string, 0, len(candidates))
+	for _, c := range candidates {
+		suggestions = append(suggestions, c.name)
+	}
+	if len(suggestions) > 3 {
+		suggestions = suggestions[:3]
+	}
+	return suggestions
+}
+
+// levenshtein is the number of single-rune edits turning a into b.
+func levenshtein(a, b string) int {
+	ar, br := []rune(a), []rune(b)
+	previous := make([]int, len(br)+1)
+	current := make([]int, len(br)+1)
+	for j := range previous {
+		previous[j] = j
+	}
+	for i := 1; i <= len(ar); i++ {
+		current[0] = i
+		for j := 1; j <= len(br); j++ {
+			cost := 1
+			if ar[i-1] == br[j-1] {
+				cost = 0
+			}
+			current[j] = min(previous[j]+1, current[j-1]+1, previous[j-1]+cost)
+		}
+		previous, current = current, previous
+	}
+	return previous[len(br)]
+}
@@ -0,0 +1,175 @@
+// Package backup saves a whole gator database to a snapshot, a gzipped tar
+// holding a manifest and one JSON Lines file per table, and restores it. It
+// only goes through storage.Store, so a snapshot looks the same whichever
+// database it came from and can be restored into either.
+package backup
+
+import (
+	"archive/tar"
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/WagnerJust/go-gator/internal/storage"
+)
+
+// Version is the snapshot format this build writes.
+const Version = 1
+
+// ManifestFile is the name of the manifest, the archive's first entry.
+const ManifestFile = "manifest.json"
+
+// Manifest describes a snapshot.
+type Manifest struct {
+	Version   int       `json:"version"`
+	CreatedAt time.Time `json:"created_at"`
+	// Counts is how many rows each table's file holds, keyed by file name.
+	Counts map[string]int `json:"counts"`
+}
+
+// Summary lists how many of each kind of row the snapshot holds.
+func (m Manifest) Summary() string {
+	kinds := []struct{ file, name string }{
+		{usersFile, "users"},
+		{feedsFile, "feeds"},
+		{followsFile, "follows"},
+		{postsFile, "posts"},
+		{enclosuresFile, "enclosures"},
+		{statesFile, "post states"},
+	}
+	counts := make([]string, len(kinds))
+	for i, kind := range kinds {
+		counts[i] = fmt.Sprintf("%d %s", m.Counts[kind.file], kind.name)
+	}
+	return strings.Join(counts, ", ")
+}
+
+// table is one JSON Lines file in a snapshot, and how to write its rows.
+type table struct {
+	file string
+	dump func(w io.Writer) (int, error)
+}
+
+// spooled is where a table's rows were spooled to.
+type spooled struct {
+	file   string
+	offset int64
+	size   int64
+}
+
+// Write saves everything in store to w, reading it all from one database
+// snapshot so the tables are consistent with each other. The manifest comes
+// first and counts every table's rows, so the rows are spooled to a
+// temporary file one table at a time rather than held in memory together.
+func Write(ctx context.Context, store storage.Store, w io.Writer) (Manifest, error) {
+	spool, err := os.CreateTemp("", "gator-backup-*.jsonl")
+	if err != nil {
+		return Manifest{}, err
+	}
+	defer os.Remove(spool.Name())
+	defer spool.Close()
+
+	manifest := Manifest{Version: Version, Counts: map[string]int{}}
+	var files []spooled
+	err = store.InSnapshot(ctx, func(tx storage.Store) error {
+		buffered := bufio.NewWriter(spool)
+		var offset int64
+		for _, t := range tables(ctx, tx) {
+			count, err := t.dump(buffered)
+			if err != nil {
+				return err
+			}
+			err = buffered.Flush()
+			if err != nil {
+				return err
+			}
+			end, err := spool.Seek(0, io.SeekCurrent)
+			if err != nil {
+				return err
+			}
+			manifest.Counts[t.file] = count
+			files = append(files, spooled{file: t.file, offset: offset, size: end - offset})
+			offset = end
+		}
+		return nil
+	})
+	if err != nil {
+		return Manifest{}, err
+	}
+
+	manifest.CreatedAt = time.Now().UTC()
+	gz := gzip.NewWriter(w)
+	archive := tar.NewWriter(gz)
+	data, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return Manifest{}, err
+	}
+	data = append(data, '\n')
+	err = writeFile(archive, ManifestFile, manifest.CreatedAt, int64(len(data)), bytes.NewReader(data))
+	if err != nil {
+		return Manifest{}, err
+	}
+	for _, f := range files {
+		err = writeFile(archive, f.file, manifest.CreatedAt, f.size, io.NewSectionReader(spool, f.offset, f.size))
+		if err != nil {
+			return Manifest{}, err
+		}
+	}
+	err = archive.Close()
+	if err != nil {
+		return Manifest{}, err
+	}
+	return manifest, gz.Close()
+}
+
+func writeFile(archive *tar.Writer, name string, modified time.Time, size int64, r io.Reader) error {
+	err := archive.WriteHeader(&tar.Header{
+		Name:    name,
+		Mode:    0600,
+		Size:    size,
+		ModTime: modified,
+	})
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(archive, r)
+	return err
+}
+
+// tables lists every table, parents before the rows that refer to them.
+func tables(ctx context.Context, store storage.Store) []table {
+	return []table{
+		{usersFile, dump(ctx, store.GetAllUsers, newUserRecord)},
+		{feedsFile, dump(ctx, store.GetAllFeeds, newFeedRecord)},
+		{followsFile, dump(ctx, store.GetAllFeedFollows, newFollowRecord)},
+		{postsFile, dump(ctx, store.GetAllPosts, newPostRecord)},
+		{enclosuresFile, dump(ctx, store.GetAllPostEnclosures, newEnclosureRecord)},
+		{statesFile, dump(ctx, store.GetAllPostStates, newStateRecord)},
+	}
+}
+
+// dump returns a function writing the rows get reads as JSON lines of their
+// records, one table's rows in memory at a time.
+func dump[T, R any](ctx context.Context, get func(context.Context) ([]T, error), record func(T) R) func(io.Writer) (int, error) {
+	return func(w io.Writer) (int, error) {
+		rows, err := get(ctx)
+		if err != nil {
+			return 0, err
+		}
+		encoder := json.NewEncoder(w)
+		for _, row := range rows {
+			err = encoder.Encode(record(row))
+			if err != nil {
+				return 0, err
+			}
+		}
+		return len(rows), nil
+	}
+}
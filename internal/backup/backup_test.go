@@ -0,0 +1,235 @@
+package backup
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"io"
+	"maps"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/WagnerJust/go-gator/internal/database"
+	"github.com/WagnerJust/go-gator/internal/migrate"
+	"github.com/WagnerJust/go-gator/internal/storage"
+	"github.com/google/uuid"
+)
+
+// fillStore adds a user following their feed, with one read post that has
+// an enclosure.
+func fillStore(t *testing.T, q storage.Store) {
+	t.Helper()
+	ctx := context.Background()
+	now := time.Date(2006, 7, 7, 22, 0, 0, 0, time.UTC)
+	user, err := q.CreateUser(ctx, database.CreateUserParams{ID: uuid.New(), CreatedAt: now, UpdatedAt: now, Name: "shawn"})
+	if err != nil {
+		t.Fatalf("error creating user: %v", err)
+	}
+	feed, err := q.CreateFeed(ctx, database.CreateFeedParams{ID: uuid.New(), CreatedAt: now, UpdatedAt: now, Name: "Psych", Url: "https://example.com/psych", UserID: user.ID})
+	if err != nil {
+		t.Fatalf("error creating feed: %v", err)
+	}
+	_, err = q.CreateFeedFollow(ctx, database.CreateFeedFollowParams{ID: uuid.New(), CreatedAt: now, UpdatedAt: now, UserID: user.ID, FeedID: feed.ID})
+	if err != nil {
+		t.Fatalf("error following feed: %v", err)
+	}
+	post, err := q.CreatePost(ctx, database.CreatePostParams{ID: uuid.New(), CreatedAt: now, UpdatedAt: now, Title: "Pilot", Url: "https://example.com/psych/1", PublishedAt: now, FeedID: feed.ID})
+	if err != nil {
+		t.Fatalf("error creating post: %v", err)
+	}
+	_, err = q.CreatePostEnclosure(ctx, database.CreatePostEnclosureParams{ID: uuid.New(), CreatedAt: now, UpdatedAt: now, PostID: post.ID, Url: "https://example.com/psych/1.mp3", Length: sql.NullInt64{Int64: 1 << 20, Valid: true}})
+	if err != nil {
+		t.Fatalf("error creating enclosure: %v", err)
+	}
+	err = q.SetPostRead(ctx, database.SetPostReadParams{ID: uuid.New(), CreatedAt: now, UpdatedAt: now, UserID: user.ID, PostID: post.ID, ReadAt: sql.NullTime{Time: now, Valid: true}})
+	if err != nil {
+		t.Fatalf("error marking post read: %v", err)
+	}
+}
+
+func TestWrite(t *testing.T) {
+	store := storage.NewMemory()
+	fillStore(t, store)
+	var snapshot bytes.Buffer
+	manifest, err := Write(context.Background(), store, &snapshot)
+	if err != nil {
+		t.Fatalf("error writing snapshot: %v", err)
+	}
+	if manifest.Version != Version || manifest.Counts[postsFile] != 1 || manifest.Counts[statesFile] != 1 {
+		t.Fatalf("unexpected manifest %+v", manifest)
+	}
+
+	names, files := readSnapshot(t, &snapshot)
+	want := []string{ManifestFile, usersFile, feedsFile, followsFile, postsFile, enclosuresFile, statesFile}
+	if !slices.Equal(names, want) {
+		t.Fatalf("expected files %v, got %v", want, names)
+	}
+
+	var post map[string]any
+	err = json.Unmarshal([]byte(strings.TrimSpace(files[postsFile])), &post)
+	if err != nil {
+		t.Fatalf("error decoding post: %v", err)
+	}
+	if post["title"] != "Pilot" || post["description"] != nil || post["published_at"] != "2006-07-07T22:00:00Z" {
+		t.Fatalf("unexpected post line %s", files[postsFile])
+	}
+	if !strings.Contains(files[enclosuresFile], `"length":1048576`) || !strings.Contains(files[statesFile], `"read_at":"2006-07-07T22:00:00Z"`) {
+		t.Fatalf("unexpected enclosure or state lines:\n%s%s", files[enclosuresFile], files[statesFile])
+	}
+}
+
+// readSnapshot returns the names of the files in snapshot, in order, and
+// their contents.
+func readSnapshot(t *testing.T, snapshot io.Reader) ([]string, map[string]string) {
+	t.Helper()
+	gz, err := gzip.NewReader(snapshot)
+	if err != nil {
+		t.Fatalf("error opening snapshot: %v", err)
+	}
+	archive := tar.NewReader(gz)
+	var names []string
+	files := map[string]string{}
+	for {
+		header, err := archive.Next()
+		if err == io.EOF {
+			return names, files
+		}
+		if err != nil {
+			t.Fatalf("error reading snapshot: %v", err)
+		}
+		data, err := io.ReadAll(archive)
+		if err != nil {
+			t.Fatalf("error reading %s: %v", header.Name, err)
+		}
+		names = append(names, header.Name)
+		files[header.Name] = string(data)
+	}
+}
+
+// writeSnapshot builds a snapshot from files, written in the order given.
+func writeSnapshot(t *testing.T, files ...[2]string) *bytes.Buffer {
+	t.Helper()
+	var snapshot bytes.Buffer
+	gz := gzip.NewWriter(&snapshot)
+	archive := tar.NewWriter(gz)
+	for _, file := range files {
+		err := archive.WriteHeader(&tar.Header{Name: file[0], Mode: 0600, Size: int64(len(file[1]))})
+		if err != nil {
+			t.Fatalf("error writing %s: %v", file[0], err)
+		}
+		_, err = archive.Write([]byte(file[1]))
+		if err != nil {
+			t.Fatalf("error writing %s: %v", file[0], err)
+		}
+	}
+	if err := archive.Close(); err != nil {
+		t.Fatalf("error closing snapshot: %v", err)
+	}
+	if err := gz.Close(); err != nil {
+		t.Fatalf("error closing snapshot: %v", err)
+	}
+	return &snapshot
+}
+
+func openSQLite(t *testing.T) storage.Store {
+	t.Helper()
+	dbUrl := "sqlite://" + filepath.Join(t.TempDir(), "gator.db")
+	db, err := storage.Open(dbUrl)
+	if err != nil {
+		t.Fatalf("error opening database: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+	migrator, err := migrate.New(db, storage.Driver(dbUrl))
+	if err != nil {
+		t.Fatalf("error loading migrations: %v", err)
+	}
+	_, err = migrator.Up(context.Background())
+	if err != nil {
+		t.Fatalf("error migrating: %v", err)
+	}
+	return storage.New(db, storage.Driver(dbUrl))
+}
+
+func TestRestoreAcrossStores(t *testing.T) {
+	cases := map[string][2]func(*testing.T) storage.Store{
+		"memory to sqlite": {func(*testing.T) storage.Store { return storage.NewMemory() }, openSQLite},
+		"sqlite to memory": {openSQLite, func(*testing.T) storage.Store { return storage.NewMemory() }},
+	}
+	for name, stores := range cases {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			from, to := stores[0](t), stores[1](t)
+			fillStore(t, from)
+			var snapshot bytes.Buffer
+			written, err := Write(ctx, from, &snapshot)
+			if err != nil {
+				t.Fatalf("error writing snapshot: %v", err)
+			}
+			_, want := readSnapshot(t, bytes.NewReader(snapshot.Bytes()))
+
+			restored, err := Restore(ctx, to, &snapshot)
+			if err != nil {
+				t.Fatalf("error restoring snapshot: %v", err)
+			}
+			if !maps.Equal(restored.Counts, written.Counts) {
+				t.Fatalf("expected counts %v, got %v", written.Counts, restored.Counts)
+			}
+			var again bytes.Buffer
+			_, err = Write(ctx, to, &again)
+			if err != nil {
+				t.Fatalf("error writing restored snapshot: %v", err)
+			}
+			_, got := readSnapshot(t, &again)
+			for file, lines := range want {
+				if file != ManifestFile && got[file] != lines {
+					t.Errorf("%s changed in the round trip:\n%s\n%s", file, lines, got[file])
+				}
+			}
+		})
+	}
+}
+
+func TestRestoreRefusals(t *testing.T) {
+	ctx := context.Background()
+	full := storage.NewMemory()
+	fillStore(t, full)
+	var snapshot bytes.Buffer
+	_, err := Write(ctx, full, &snapshot)
+	if err != nil {
+		t.Fatalf("error writing snapshot: %v", err)
+	}
+	_, err = Restore(ctx, full, bytes.NewReader(snapshot.Bytes()))
+	if !errors.Is(err, ErrNotEmpty) {
+		t.Fatalf("expected ErrNotEmpty restoring into a full store, got %v", err)
+	}
+
+	_, err = Restore(ctx, storage.NewMemory(), writeSnapshot(t, [2]string{ManifestFile, `{"version":99}`}))
+	if err == nil || !strings.Contains(err.Error(), "version 99") {
+		t.Fatalf("expected a newer snapshot to be refused, got %v", err)
+	}
+
+	_, files := readSnapshot(t, bytes.NewReader(snapshot.Bytes()))
+	empty := storage.NewMemory()
+	damaged := writeSnapshot(t, [2]string{ManifestFile, files[ManifestFile]}, [2]string{usersFile, files[usersFile]}, [2]string{feedsFile, files[feedsFile]})
+	_, err = Restore(ctx, empty, damaged)
+	if err == nil || !strings.Contains(err.Error(), "missing") {
+		t.Fatalf("expected a snapshot missing files to be refused, got %v", err)
+	}
+	users, err := empty.GetAllUsers(ctx)
+	if err != nil || len(users) != 0 {
+		t.Fatalf("expected a failed restore to leave no users, got %v (%v)", users, err)
+	}
+
+	extra := writeSnapshot(t, [2]string{ManifestFile, `{"version":1,"counts":{"users.jsonl":1}}`}, [2]string{"later.jsonl", "{}\n"}, [2]string{usersFile, files[usersFile]})
+	_, err = Restore(ctx, storage.NewMemory(), extra)
+	if err != nil {
+		t.Fatalf("expected unknown files to be skipped, got %v", err)
+	}
+}
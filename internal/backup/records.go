@@ -0,0 +1,235 @@
+package backup
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/WagnerJust/go-gator/internal/database"
+	"github.com/google/uuid"
+)
+
+// The files in a snapshot, one per table.
+const (
+	usersFile      = "users.jsonl"
+	feedsFile      = "feeds.jsonl"
+	followsFile    = "feed_follows.jsonl"
+	postsFile      = "posts.jsonl"
+	enclosuresFile = "post_enclosures.jsonl"
+	statesFile     = "post_states.jsonl"
+)
+
+// The record types below are the lines of each file. They mirror the tables'
+// columns, with NULL as null and times in UTC; add fields rather than
+// renaming them, and bump Version when old snapshots can't be read.
+
+type userRecord struct {
+	ID        uuid.UUID `json:"id"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+	Name      string    `json:"name"`
+}
+
+type feedRecord struct {
+	ID               uuid.UUID  `json:"id"`
+	CreatedAt        time.Time  `json:"created_at"`
+	UpdatedAt        time.Time  `json:"updated_at"`
+	Name             string     `json:"name"`
+	URL              string     `json:"url"`
+	UserID           uuid.UUID  `json:"user_id"`
+	LastFetchedAt    *time.Time `json:"last_fetched_at"`
+	LastFetchWarning *string    `json:"last_fetch_warning"`
+}
+
+type followRecord struct {
+	ID        uuid.UUID `json:"id"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+	UserID    uuid.UUID `json:"user_id"`
+	FeedID    uuid.UUID `json:"feed_id"`
+}
+
+type postRecord struct {
+	ID           uuid.UUID `json:"id"`
+	CreatedAt    time.Time `json:"created_at"`
+	UpdatedAt    time.Time `json:"updated_at"`
+	Title        string    `json:"title"`
+	URL          string    `json:"url"`
+	Description  *string   `json:"description"`
+	PublishedAt  time.Time `json:"published_at"`
+	FeedID       uuid.UUID `json:"feed_id"`
+	ThumbnailURL *string   `json:"thumbnail_url"`
+}
+
+type enclosureRecord struct {
+	ID        uuid.UUID `json:"id"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+	PostID    uuid.UUID `json:"post_id"`
+	URL       string    `json:"url"`
+	MimeType  *string   `json:"mime_type"`
+	Length    *int64    `json:"length"`
+	Duration  *string   `json:"duration"`
+	Episode   *string   `json:"episode"`
+	ImageURL  *string   `json:"image_url"`
+}
+
+type stateRecord struct {
+	ID        uuid.UUID  `json:"id"`
+	CreatedAt time.Time  `json:"created_at"`
+	UpdatedAt time.Time  `json:"updated_at"`
+	UserID    uuid.UUID  `json:"user_id"`
+	PostID    uuid.UUID  `json:"post_id"`
+	ReadAt    *time.Time `json:"read_at"`
+	Starred   bool       `json:"starred"`
+}
+
+func newUserRecord(u database.User) userRecord {
+	return userRecord{ID: u.ID, CreatedAt: u.CreatedAt.UTC(), UpdatedAt: u.UpdatedAt.UTC(), Name: u.Name}
+}
+
+func newFeedRecord(f database.Feed) feedRecord {
+	return feedRecord{
+		ID:               f.ID,
+		CreatedAt:        f.CreatedAt.UTC(),
+		UpdatedAt:        f.UpdatedAt.UTC(),
+		Name:             f.Name,
+		URL:              f.Url,
+		UserID:           f.UserID,
+		LastFetchedAt:    timePtr(f.LastFetchedAt),
+		LastFetchWarning: stringPtr(f.LastFetchWarning),
+	}
+}
+
+func newFollowRecord(f database.FeedFollow) followRecord {
+	return followRecord{ID: f.ID, CreatedAt: f.CreatedAt.UTC(), UpdatedAt: f.UpdatedAt.UTC(), UserID: f.UserID, FeedID: f.FeedID}
+}
+
+func newPostRecord(p database.Post) postRecord {
+	return postRecord{
+		ID:           p.ID,
+		CreatedAt:    p.CreatedAt.UTC(),
+		UpdatedAt:    p.UpdatedAt.UTC(),
+		Title:        p.Title,
+		URL:          p.Url,
+		Description:  stringPtr(p.Description),
+		PublishedAt:  p.PublishedAt.UTC(),
+		FeedID:       p.FeedID,
+		ThumbnailURL: stringPtr(p.ThumbnailUrl),
+	}
+}
+
+func newEnclosureRecord(e database.PostEnclosure) enclosureRecord {
+	record := enclosureRecord{
+		ID:        e.ID,
+		CreatedAt: e.CreatedAt.UTC(),
+		UpdatedAt: e.UpdatedAt.UTC(),
+		PostID:    e.PostID,
+		URL:       e.Url,
+		MimeType:  stringPtr(e.MimeType),
+		Duration:  stringPtr(e.Duration),
+		Episode:   stringPtr(e.Episode),
+		ImageURL:  stringPtr(e.ImageUrl),
+	}
+	if e.Length.Valid {
+		record.Length = &e.Length.Int64
+	}
+	return record
+}
+
+func newStateRecord(s database.PostState) stateRecord {
+	return stateRecord{
+		ID:        s.ID,
+		CreatedAt: s.CreatedAt.UTC(),
+		UpdatedAt: s.UpdatedAt.UTC(),
+		UserID:    s.UserID,
+		PostID:    s.PostID,
+		ReadAt:    timePtr(s.ReadAt),
+		Starred:   s.Starred,
+	}
+}
+
+func stringPtr(s sql.NullString) *string {
+	if !s.Valid {
+		return nil
+	}
+	return &s.String
+}
+
+func timePtr(t sql.NullTime) *time.Time {
+	if !t.Valid {
+		return nil
+	}
+	utc := t.Time.UTC()
+	return &utc
+}
+
+func (r feedRecord) params() database.RestoreFeedParams {
+	return database.RestoreFeedParams{
+		ID:               r.ID,
+		CreatedAt:        r.CreatedAt,
+		UpdatedAt:        r.UpdatedAt,
+		LastFetchedAt:    nullTime(r.LastFetchedAt),
+		Name:             r.Name,
+		Url:              r.URL,
+		UserID:           r.UserID,
+		LastFetchWarning: nullString(r.LastFetchWarning),
+	}
+}
+
+func (r postRecord) params() database.CreatePostParams {
+	return database.CreatePostParams{
+		ID:           r.ID,
+		CreatedAt:    r.CreatedAt,
+		UpdatedAt:    r.UpdatedAt,
+		Title:        r.Title,
+		Url:          r.URL,
+		Description:  nullString(r.Description),
+		PublishedAt:  r.PublishedAt,
+		FeedID:       r.FeedID,
+		ThumbnailUrl: nullString(r.ThumbnailURL),
+	}
+}
+
+func (r enclosureRecord) params() database.CreatePostEnclosureParams {
+	params := database.CreatePostEnclosureParams{
+		ID:        r.ID,
+		CreatedAt: r.CreatedAt,
+		UpdatedAt: r.UpdatedAt,
+		PostID:    r.PostID,
+		Url:       r.URL,
+		MimeType:  nullString(r.MimeType),
+		Duration:  nullString(r.Duration),
+		Episode:   nullString(r.Episode),
+		ImageUrl:  nullString(r.ImageURL),
+	}
+	if r.Length != nil {
+		params.Length = sql.NullInt64{Int64: *r.Length, Valid: true}
+	}
+	return params
+}
+
+func (r stateRecord) params() database.RestorePostStateParams {
+	return database.RestorePostStateParams{
+		ID:        r.ID,
+		CreatedAt: r.CreatedAt,
+		UpdatedAt: r.UpdatedAt,
+		UserID:    r.UserID,
+		PostID:    r.PostID,
+		ReadAt:    nullTime(r.ReadAt),
+		Starred:   r.Starred,
+	}
+}
+
+func nullString(s *string) sql.NullString {
+	if s == nil {
+		return sql.NullString{}
+	}
+	return sql.NullString{String: *s, Valid: true}
+}
+
+func nullTime(t *time.Time) sql.NullTime {
+	if t == nil {
+		return sql.NullTime{}
+	}
+	return sql.NullTime{Time: *t, Valid: true}
+}
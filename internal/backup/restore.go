@@ -0,0 +1,175 @@
+package backup
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/WagnerJust/go-gator/internal/database"
+	"github.com/WagnerJust/go-gator/internal/storage"
+)
+
+// ErrNotEmpty is returned by Restore when the store already has users or
+// feeds, which the snapshot's rows could clash with.
+var ErrNotEmpty = errors.New("restore needs an empty database")
+
+// postBatchSize is how many posts or enclosures Restore stores per
+// statement.
+const postBatchSize = 500
+
+// Restore loads the snapshot in r into store, which must be empty, in one
+// transaction, so a snapshot that fails part way leaves nothing behind.
+func Restore(ctx context.Context, store storage.Store, r io.Reader) (Manifest, error) {
+	gz, err := gzip.NewReader(r)
+	if err != nil {
+		return Manifest{}, fmt.Errorf("not a gator snapshot: %w", err)
+	}
+	archive := tar.NewReader(gz)
+	header, err := archive.Next()
+	if err != nil {
+		return Manifest{}, fmt.Errorf("not a gator snapshot: %w", err)
+	}
+	if header.Name != ManifestFile {
+		return Manifest{}, fmt.Errorf("not a gator snapshot: expected %s first, found %s", ManifestFile, header.Name)
+	}
+	var manifest Manifest
+	err = json.NewDecoder(archive).Decode(&manifest)
+	if err != nil {
+		return Manifest{}, fmt.Errorf("error reading %s: %w", ManifestFile, err)
+	}
+	if manifest.Version < 1 || manifest.Version > Version {
+		return Manifest{}, fmt.Errorf("snapshot version %d isn't one this gator understands (up to %d); upgrade gator", manifest.Version, Version)
+	}
+
+	err = store.InTx(ctx, func(tx storage.Store) error {
+		users, err := tx.GetAllUsers(ctx)
+		if err != nil {
+			return err
+		}
+		feeds, err := tx.GetAllFeeds(ctx)
+		if err != nil {
+			return err
+		}
+		if len(users) > 0 || len(feeds) > 0 {
+			return ErrNotEmpty
+		}
+
+		restored := map[string]bool{}
+		for {
+			header, err := archive.Next()
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				return err
+			}
+			count, err := restoreFile(ctx, tx, header.Name, archive)
+			if err != nil {
+				return fmt.Errorf("error restoring %s: %w", header.Name, err)
+			}
+			if count < 0 {
+				// Files this version doesn't know, from a later one.
+				continue
+			}
+			if count != manifest.Counts[header.Name] {
+				return fmt.Errorf("%s has %d rows but the manifest lists %d; the snapshot is damaged", header.Name, count, manifest.Counts[header.Name])
+			}
+			restored[header.Name] = true
+		}
+		for file, count := range manifest.Counts {
+			if count > 0 && !restored[file] {
+				return fmt.Errorf("the snapshot is missing %s", file)
+			}
+		}
+		return nil
+	})
+	return manifest, err
+}
+
+// restoreFile stores the rows of one of a snapshot's files, returning how
+// many there were, or -1 for a file it doesn't know.
+func restoreFile(ctx context.Context, tx storage.Store, name string, r io.Reader) (int, error) {
+	switch name {
+	case usersFile:
+		return eachLine(r, func(u userRecord) error {
+			_, err := tx.CreateUser(ctx, database.CreateUserParams{ID: u.ID, CreatedAt: u.CreatedAt, UpdatedAt: u.UpdatedAt, Name: u.Name})
+			return err
+		})
+	case feedsFile:
+		return eachLine(r, func(f feedRecord) error {
+			return tx.RestoreFeed(ctx, f.params())
+		})
+	case followsFile:
+		return eachLine(r, func(f followRecord) error {
+			_, err := tx.CreateFeedFollow(ctx, database.CreateFeedFollowParams{ID: f.ID, CreatedAt: f.CreatedAt, UpdatedAt: f.UpdatedAt, UserID: f.UserID, FeedID: f.FeedID})
+			return err
+		})
+	case postsFile:
+		var batch []database.CreatePostParams
+		flush := func() error {
+			created, err := tx.CreatePosts(ctx, batch)
+			if err == nil && len(created) != len(batch) {
+				err = errors.New("the snapshot has posts with the same URL")
+			}
+			batch = batch[:0]
+			return err
+		}
+		count, err := eachLine(r, func(p postRecord) error {
+			batch = append(batch, p.params())
+			if len(batch) == postBatchSize {
+				return flush()
+			}
+			return nil
+		})
+		if err == nil {
+			err = flush()
+		}
+		return count, err
+	case enclosuresFile:
+		var batch []database.CreatePostEnclosureParams
+		count, err := eachLine(r, func(e enclosureRecord) error {
+			batch = append(batch, e.params())
+			if len(batch) == postBatchSize {
+				err := tx.CreatePostEnclosures(ctx, batch)
+				batch = batch[:0]
+				return err
+			}
+			return nil
+		})
+		if err == nil {
+			err = tx.CreatePostEnclosures(ctx, batch)
+		}
+		return count, err
+	case statesFile:
+		return eachLine(r, func(s stateRecord) error {
+			return tx.RestorePostState(ctx, s.params())
+		})
+	}
+	return -1, nil
+}
+
+// eachLine decodes r's JSON lines as R, calling fn with each, and returns
+// how many there were.
+func eachLine[R any](r io.Reader, fn func(R) error) (int, error) {
+	decoder := json.NewDecoder(r)
+	count := 0
+	for {
+		var record R
+		err := decoder.Decode(&record)
+		if err == io.EOF {
+			return count, nil
+		}
+		count++
+		if err != nil {
+			return count, fmt.Errorf("line %d: %w", count, err)
+		}
+		err = fn(record)
+		if err != nil {
+			return count, fmt.Errorf("line %d: %w", count, err)
+		}
+	}
+}
@@ -2,20 +2,46 @@ package config
 
 import (
 	"encoding/json"
+	"fmt"
 	"os"
+	"path/filepath"
 )
 
 type Config struct {
 	DbUrl string `json:"db_url"`
 	CurrentUserName string `json:"current_user_name"`
+	DownloadDir string `json:"download_dir,omitempty"`
+	// SnapshotDir is where reset saves a snapshot of the database first.
+	SnapshotDir string `json:"snapshot_dir,omitempty"`
+	Fetcher FetcherConfig `json:"fetcher,omitzero"`
+	Aggregator AggregatorConfig `json:"aggregator,omitzero"`
+	Output OutputConfig `json:"output,omitzero"`
+	Logging LoggingConfig `json:"logging,omitzero"`
+	// Profiles are named alternatives to the top-level db_url and
+	// current_user_name, which make up the "default" profile.
+	Profiles map[string]Profile `json:"profiles,omitempty"`
+	ActiveProfile string `json:"active_profile,omitempty"`
+	// Path is the file the config is read from and saved to. Empty means
+	// the default location; see getConfigFilePath.
+	Path string `json:"-"`
+	// Selected overrides ActiveProfile for this run, from --profile.
+	Selected string `json:"-"`
 }
 const configFileName = ".gatorconfig.json"
 
+// ConfigPathEnv names a config file to use instead of the default location.
+const ConfigPathEnv = "GATOR_CONFIG"
+
 
 func NewConfig() *Config {
 	return &Config{}
 }
 
+// NewConfigAt returns a config that is read from and saved to path.
+func NewConfigAt(path string) *Config {
+	return &Config{Path: path}
+}
+
 func (c *Config) String() string {
  data, err := json.MarshalIndent(c, "", "  ")
  if err != nil {
@@ -24,50 +50,113 @@ func (c *Config) String() string {
  return string(data)
 }
 
-func getConfigFilePath() (string, error) {
-	path, err := os.UserHomeDir()
+// GetDownloadDir returns the directory podcast and media downloads are saved
+// to, defaulting to ~/Downloads/gator when download_dir isn't set.
+func (c *Config) GetDownloadDir() (string, error) {
+	if c.DownloadDir != "" {
+		return c.DownloadDir, nil
+	}
+	home, err := os.UserHomeDir()
 	if err != nil {
 		return "", err
 	}
-	path = path + "/" + configFileName
-	return path, nil
+	return filepath.Join(home, "Downloads", "gator"), nil
 }
 
-func (c *Config) Read () (error) {
-	path, err := getConfigFilePath()
-	if err != nil {
-		return err
+// GetSnapshotDir returns the directory database snapshots are saved to,
+// defaulting to $XDG_DATA_HOME/gator/snapshots (XDG_DATA_HOME defaulting to
+// ~/.local/share) when snapshot_dir isn't set, wherever the config is.
+func (c *Config) GetSnapshotDir() (string, error) {
+	if c.SnapshotDir != "" {
+		return c.SnapshotDir, nil
 	}
+	dataHome := xdgDir("XDG_DATA_HOME")
+	if dataHome == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return "", err
+		}
+		dataHome = filepath.Join(home, ".local", "share")
+	}
+	return filepath.Join(dataHome, "gator", "snapshots"), nil
+}
 
-	data, err := os.ReadFile(path)
-	if err != nil {
-		return err
+// getConfigFilePath finds the config file: $GATOR_CONFIG if set, otherwise
+// $XDG_CONFIG_HOME/gator/config.json (XDG_CONFIG_HOME defaulting to
+// ~/.config), falling back to the legacy ~/.gatorconfig.json when only that
+// exists. New configs are created at the XDG path. HOME is only needed when
+// neither variable is set.
+func getConfigFilePath() (string, error) {
+	if path := os.Getenv(ConfigPathEnv); path != "" {
+		return path, nil
 	}
-	return json.Unmarshal(data, c)
+	home, homeErr := os.UserHomeDir()
+	configHome := xdgDir("XDG_CONFIG_HOME")
+	if configHome == "" {
+		if homeErr != nil {
+			return "", homeErr
+		}
+		configHome = filepath.Join(home, ".config")
+	}
+	xdgPath := filepath.Join(configHome, "gator", "config.json")
+	if _, err := os.Stat(xdgPath); err == nil {
+		return xdgPath, nil
+	}
+	if homeErr == nil {
+		legacyPath := filepath.Join(home, configFileName)
+		if _, err := os.Stat(legacyPath); err == nil {
+			return legacyPath, nil
+		}
+	}
+	return xdgPath, nil
 }
 
-func (c *Config) SetUser (user string) (error) {
-	c.CurrentUserName = user
-	path, err := getConfigFilePath()
-	if err != nil {
-		return err
+// xdgDir returns the directory an XDG base directory variable names, or ""
+// when it is unset or relative, as the spec says relative paths are invalid
+// and to be ignored.
+func xdgDir(name string) string {
+	dir := os.Getenv(name)
+	if !filepath.IsAbs(dir) {
+		return ""
 	}
+	return dir
+}
 
-	file, err := os.Create(path)
-	if err != nil {
-		return err
+// FilePath returns the file the config is read from and saved to.
+func (c *Config) FilePath() (string, error) {
+	if c.Path != "" {
+		return c.Path, nil
 	}
+	return getConfigFilePath()
+}
 
-	data, err := json.Marshal(c)
+func (c *Config) Read () (error) {
+	path, err := c.FilePath()
 	if err != nil {
 		return err
 	}
 
-	_, err = file.Write(data)
-	defer file.Close()
-
+	data, err := os.ReadFile(path)
 	if err != nil {
 		return err
 	}
-	return nil
+	return json.Unmarshal(data, c)
+}
+
+// SetUser logs user in on the profile in use and saves the config.
+func (c *Config) SetUser (user string) (error) {
+	return c.update(func(c *Config) error {
+		name := c.ProfileName()
+		if name == DefaultProfile {
+			c.CurrentUserName = user
+			return nil
+		}
+		if !c.HasProfile(name) {
+			return fmt.Errorf("no profile named %q", name)
+		}
+		profile := c.Profiles[name]
+		profile.CurrentUserName = user
+		c.Profiles[name] = profile
+		return nil
+	})
 }
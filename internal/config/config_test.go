@@ -1,6 +1,8 @@
 package config
 
 import (
+	"os"
+	"path/filepath"
 	"testing"
 
 	"github.com/google/go-cmp/cmp"
@@ -57,3 +59,100 @@ func TestSetUserConfig(t *testing.T) {
 		t.Fatalf("config mismatch (-want +got):\n%s", diff)
 	}
 }
+
+func TestConfigAtPath(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "gator.json")
+	config := NewConfigAt(path)
+	config.DbUrl = "postgres://elsewhere"
+	err := config.SetUser("BurtonGuster")
+	if err != nil {
+		t.Fatalf("error setting user: %v", err)
+	}
+
+	reread := NewConfigAt(path)
+	err = reread.Read()
+	if err != nil {
+		t.Fatalf("error reading config: %v", err)
+	}
+	diff := cmp.Diff(config, reread)
+	if diff != "" {
+		t.Fatalf("config mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestConfigFilePathPrecedence(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("XDG_CONFIG_HOME", "")
+	t.Setenv(ConfigPathEnv, "")
+	xdgPath := filepath.Join(home, ".config", "gator", "config.json")
+	legacyPath := filepath.Join(home, configFileName)
+
+	expectPath := func(expected string) {
+		t.Helper()
+		actual, err := getConfigFilePath()
+		if err != nil {
+			t.Fatalf("error getting config file path: %v", err)
+		}
+		if actual != expected {
+			t.Fatalf("expected %s, got %s", expected, actual)
+		}
+	}
+
+	expectPath(xdgPath)
+
+	err := os.WriteFile(legacyPath, []byte("{}"), 0600)
+	if err != nil {
+		t.Fatalf("error writing legacy config: %v", err)
+	}
+	expectPath(legacyPath)
+
+	err = NewConfigAt(xdgPath).SetUser("GusFersnickety")
+	if err != nil {
+		t.Fatalf("error writing xdg config: %v", err)
+	}
+	expectPath(xdgPath)
+
+	configHome := t.TempDir()
+	t.Setenv("XDG_CONFIG_HOME", configHome)
+	expectPath(legacyPath)
+
+	// A relative XDG_CONFIG_HOME is ignored, leaving ~/.config.
+	t.Setenv("XDG_CONFIG_HOME", "relative/config")
+	expectPath(xdgPath)
+
+	// Containers often have no HOME; XDG_CONFIG_HOME is enough then.
+	t.Setenv("HOME", "")
+	t.Setenv("XDG_CONFIG_HOME", configHome)
+	expectPath(filepath.Join(configHome, "gator", "config.json"))
+	t.Setenv("XDG_CONFIG_HOME", "")
+	if _, err := getConfigFilePath(); err == nil {
+		t.Fatalf("expected an error with neither HOME nor XDG_CONFIG_HOME")
+	}
+
+	t.Setenv(ConfigPathEnv, "/etc/gator.json")
+	expectPath("/etc/gator.json")
+}
+
+func TestSnapshotDirDefaultsToDataHome(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("XDG_DATA_HOME", "")
+	config := NewConfigAt(filepath.Join(home, configFileName))
+
+	dir, err := config.GetSnapshotDir()
+	if err != nil || dir != filepath.Join(home, ".local", "share", "gator", "snapshots") {
+		t.Fatalf("expected snapshots under ~/.local/share, got %s (%v)", dir, err)
+	}
+	dataHome := t.TempDir()
+	t.Setenv("XDG_DATA_HOME", dataHome)
+	dir, err = config.GetSnapshotDir()
+	if err != nil || dir != filepath.Join(dataHome, "gator", "snapshots") {
+		t.Fatalf("expected snapshots under XDG_DATA_HOME, got %s (%v)", dir, err)
+	}
+	config.SnapshotDir = "/var/backups/gator"
+	dir, err = config.GetSnapshotDir()
+	if err != nil || dir != "/var/backups/gator" {
+		t.Fatalf("expected snapshot_dir to win, got %s (%v)", dir, err)
+	}
+}
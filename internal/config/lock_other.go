@@ -0,0 +1,9 @@
+//go:build !unix && !windows
+
+package config
+
+// lockFile is a no-op where there's no file locking; writes are still
+// atomic, so the worst case is a lost update rather than a corrupt file.
+func lockFile(path string) (func(), error) {
+	return func() {}, nil
+}
@@ -0,0 +1,130 @@
+package config
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/WagnerJust/go-gator/internal/storage"
+)
+
+// DefaultProfile is the name of the profile stored in the top-level db_url
+// and current_user_name keys.
+const DefaultProfile = "default"
+
+// ProfileEnv selects a profile, like --profile.
+const ProfileEnv = "GATOR_PROFILE"
+
+// Profile is a database and the user logged in to it.
+type Profile struct {
+	DbUrl           string `json:"db_url"`
+	CurrentUserName string `json:"current_user_name"`
+}
+
+// ProfileName is the profile in use: Selected, else ActiveProfile, else
+// DefaultProfile.
+func (c *Config) ProfileName() string {
+	if c.Selected != "" {
+		return c.Selected
+	}
+	if c.ActiveProfile != "" {
+		return c.ActiveProfile
+	}
+	return DefaultProfile
+}
+
+// Current returns the profile in use. An unknown profile is empty; Validate
+// reports it.
+func (c *Config) Current() Profile {
+	return c.profile(c.ProfileName())
+}
+
+func (c *Config) profile(name string) Profile {
+	if name == DefaultProfile {
+		return Profile{DbUrl: c.DbUrl, CurrentUserName: c.CurrentUserName}
+	}
+	return c.Profiles[name]
+}
+
+// HasProfile reports whether a profile called name exists.
+func (c *Config) HasProfile(name string) bool {
+	if name == DefaultProfile {
+		return true
+	}
+	_, ok := c.Profiles[name]
+	return ok
+}
+
+// ProfileNames lists every profile, the default first and the rest sorted.
+func (c *Config) ProfileNames() []string {
+	names := make([]string, 0, len(c.Profiles)+1)
+	names = append(names, DefaultProfile)
+	for name := range c.Profiles {
+		names = append(names, name)
+	}
+	sort.Strings(names[1:])
+	return names
+}
+
+// GetProfile returns the named profile.
+func (c *Config) GetProfile(name string) (Profile, error) {
+	if !c.HasProfile(name) {
+		return Profile{}, fmt.Errorf("no profile named %q", name)
+	}
+	return c.profile(name), nil
+}
+
+// AddProfile adds a profile connecting to dbUrl and saves the config.
+func (c *Config) AddProfile(name, dbUrl string) error {
+	if name == "" {
+		return fmt.Errorf("profile name can't be empty")
+	}
+	if err := storage.CheckDbUrl(dbUrl); err != nil {
+		return &KeyError{Key: "profiles." + name + ".db_url", Message: err.Error()}
+	}
+	return c.update(func(c *Config) error {
+		if c.HasProfile(name) {
+			return fmt.Errorf("profile %q already exists", name)
+		}
+		if c.Profiles == nil {
+			c.Profiles = map[string]Profile{}
+		}
+		c.Profiles[name] = Profile{DbUrl: dbUrl}
+		return nil
+	})
+}
+
+// RemoveProfile deletes a profile and saves the config. Removing the active
+// profile makes the default one active again.
+func (c *Config) RemoveProfile(name string) error {
+	if name == DefaultProfile {
+		return fmt.Errorf("the %s profile can't be removed", DefaultProfile)
+	}
+	return c.update(func(c *Config) error {
+		if !c.HasProfile(name) {
+			return fmt.Errorf("no profile named %q", name)
+		}
+		delete(c.Profiles, name)
+		if c.ActiveProfile == name {
+			c.ActiveProfile = ""
+		}
+		if c.Selected == name {
+			c.Selected = ""
+		}
+		return nil
+	})
+}
+
+// UseProfile makes name the active profile and saves the config.
+func (c *Config) UseProfile(name string) error {
+	return c.update(func(c *Config) error {
+		if !c.HasProfile(name) {
+			return fmt.Errorf("no profile named %q", name)
+		}
+		c.ActiveProfile = name
+		if name == DefaultProfile {
+			c.ActiveProfile = ""
+		}
+		c.Selected = ""
+		return nil
+	})
+}
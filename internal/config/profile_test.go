@@ -0,0 +1,74 @@
+package config
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestProfiles(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.json")
+	config := NewConfigAt(path)
+	config.DbUrl = "postgres://localhost/gator"
+	err := config.SetUser("shawn")
+	if err != nil {
+		t.Fatalf("error saving config: %v", err)
+	}
+	err = config.AddProfile("team", "postgres://db.internal/gator")
+	if err != nil {
+		t.Fatalf("error adding profile: %v", err)
+	}
+	if config.AddProfile("team", "postgres://elsewhere") == nil {
+		t.Fatalf("expected adding a duplicate profile to fail")
+	}
+	for _, dbUrl := range []string{"postgres//typo", ""} {
+		err = config.AddProfile("work", dbUrl)
+		var keyErr *KeyError
+		if !errors.As(err, &keyErr) || keyErr.Key != "profiles.work.db_url" {
+			t.Fatalf("expected a profiles.work.db_url error for %q, got %v", dbUrl, err)
+		}
+	}
+	if config.HasProfile("work") {
+		t.Fatalf("expected a profile with a bad db_url not to be added")
+	}
+	err = config.UseProfile("team")
+	if err != nil {
+		t.Fatalf("error switching profile: %v", err)
+	}
+	err = config.SetUser("gus")
+	if err != nil {
+		t.Fatalf("error setting user: %v", err)
+	}
+
+	reread := NewConfigAt(path)
+	err = reread.Read()
+	if err != nil {
+		t.Fatalf("error reading config: %v", err)
+	}
+	expected := Profile{DbUrl: "postgres://db.internal/gator", CurrentUserName: "gus"}
+	if diff := cmp.Diff(expected, reread.Current()); diff != "" {
+		t.Fatalf("active profile mismatch (-want +got):\n%s", diff)
+	}
+	if reread.CurrentUserName != "shawn" {
+		t.Fatalf("expected the default profile's user to be untouched, got %q", reread.CurrentUserName)
+	}
+
+	reread.Selected = DefaultProfile
+	if reread.Current().DbUrl != "postgres://localhost/gator" {
+		t.Fatalf("expected Selected to override the active profile")
+	}
+	reread.Selected = ""
+
+	err = reread.RemoveProfile("team")
+	if err != nil {
+		t.Fatalf("error removing profile: %v", err)
+	}
+	if reread.ProfileName() != DefaultProfile {
+		t.Fatalf("expected removing the active profile to fall back to %s, got %s", DefaultProfile, reread.ProfileName())
+	}
+	if reread.RemoveProfile(DefaultProfile) == nil {
+		t.Fatalf("expected removing the default profile to fail")
+	}
+}
@@ -0,0 +1,194 @@
+package config
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+)
+
+// Save writes the config to its file. The file holds database credentials,
+// so it is only readable by its owner, and it is never left half-written: the
+// new contents go to a temporary file that is synced and renamed over the
+// old one. A lock file serializes concurrent writers, and keys this version
+// doesn't know about, say from a newer gator, are carried over.
+func (c *Config) Save() error {
+	path, unlock, err := c.lock()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+	return c.write(path)
+}
+
+// update applies change to the config and saves it. The file is re-read
+// under the lock and change applied to what it holds, so another gator
+// saving in the meantime doesn't have its change undone. Until the file
+// exists, change is applied to c as it is.
+func (c *Config) update(change func(*Config) error) error {
+	path, unlock, err := c.lock()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	latest := *c
+	data, err := os.ReadFile(path)
+	if err == nil {
+		latest = Config{Path: c.Path, Selected: c.Selected}
+		err = json.Unmarshal(data, &latest)
+		if err != nil {
+			return err
+		}
+	} else if !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	err = change(&latest)
+	if err != nil {
+		return err
+	}
+	err = latest.write(path)
+	if err != nil {
+		return err
+	}
+	*c = latest
+	return nil
+}
+
+// lock takes the config's lock, returning the file to write, with symlinks
+// resolved so a linked config is replaced rather than the link.
+func (c *Config) lock() (string, func(), error) {
+	path, err := c.FilePath()
+	if err != nil {
+		return "", nil, err
+	}
+	if resolved, err := filepath.EvalSymlinks(path); err == nil {
+		path = resolved
+	}
+	err = os.MkdirAll(filepath.Dir(path), 0700)
+	if err != nil {
+		return "", nil, err
+	}
+	unlock, err := lockFile(path + ".lock")
+	if err != nil {
+		return "", nil, err
+	}
+	return path, unlock, nil
+}
+
+func (c *Config) write(path string) error {
+	data, err := json.Marshal(c)
+	if err != nil {
+		return err
+	}
+	data, err = preserveUnknownKeys(path, data)
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(path, data)
+}
+
+// preserveUnknownKeys adds the keys in the file at path that Config doesn't
+// know to data, returning it indented. An unreadable or missing file has
+// nothing to preserve.
+func preserveUnknownKeys(path string, data []byte) ([]byte, error) {
+	var fresh map[string]any
+	err := json.Unmarshal(data, &fresh)
+	if err != nil {
+		return nil, err
+	}
+	existing, err := os.ReadFile(path)
+	if err == nil {
+		var old map[string]any
+		if json.Unmarshal(existing, &old) == nil {
+			mergeUnknown(fresh, old, reflect.TypeOf(Config{}))
+		}
+	} else if !errors.Is(err, os.ErrNotExist) {
+		return nil, err
+	}
+	data, err = json.MarshalIndent(fresh, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append(data, '\n'), nil
+}
+
+// mergeUnknown copies the keys in old with no field in t into fresh,
+// recursing into sections. Known keys are left as fresh has them, so
+// clearing a field still removes it from the file.
+func mergeUnknown(fresh, old map[string]any, t reflect.Type) {
+	fields := jsonFields(t)
+	for key, value := range old {
+		fieldType, known := fields[key]
+		if !known {
+			fresh[key] = value
+			continue
+		}
+		oldSection, ok := value.(map[string]any)
+		if !ok || fieldType.Kind() != reflect.Struct {
+			continue
+		}
+		freshSection, ok := fresh[key].(map[string]any)
+		if !ok {
+			freshSection = map[string]any{}
+		}
+		mergeUnknown(freshSection, oldSection, fieldType)
+		if len(freshSection) > 0 {
+			fresh[key] = freshSection
+		}
+	}
+}
+
+// jsonFields maps the json key of each of t's fields to the field's type.
+// The top level also has the version key.
+func jsonFields(t reflect.Type) map[string]reflect.Type {
+	fields := map[string]reflect.Type{}
+	for i := 0; i < t.NumField(); i++ {
+		name, _, _ := strings.Cut(t.Field(i).Tag.Get("json"), ",")
+		if name != "" && name != "-" {
+			fields[name] = t.Field(i).Type
+		}
+	}
+	if t == reflect.TypeOf(Config{}) {
+		fields["version"] = reflect.TypeOf(0)
+	}
+	return fields
+}
+
+func writeFileAtomic(path string, data []byte) error {
+	dir := filepath.Dir(path)
+	temp, err := os.CreateTemp(dir, "."+filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	tempPath := temp.Name()
+	defer os.Remove(tempPath)
+
+	err = temp.Chmod(0600)
+	if err == nil {
+		_, err = temp.Write(data)
+	}
+	if err == nil {
+		err = temp.Sync()
+	}
+	closeErr := temp.Close()
+	if err != nil {
+		return err
+	}
+	if closeErr != nil {
+		return closeErr
+	}
+	err = os.Rename(tempPath, path)
+	if err != nil {
+		return err
+	}
+	// Make the rename itself durable. Not every platform can sync a
+	// directory, so failures here are ignored.
+	if d, err := os.Open(dir); err == nil {
+		d.Sync()
+		d.Close()
+	}
+	return nil
+}
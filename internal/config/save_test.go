@@ -0,0 +1,160 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+)
+
+func TestSaveIsPrivateAndKeepsUnknownKeys(t *testing.T) {
+	path := writeConfigFile(t, `{"version": 1, "db_url": "postgres://example", "download_dir": "/tmp/old", "theme": "dark", "fetcher": {"timeout": "5s", "retries": 3}}`)
+	err := os.Chmod(path, 0644)
+	if err != nil {
+		t.Fatalf("error loosening permissions: %v", err)
+	}
+
+	config := NewConfigAt(path)
+	err = config.Read()
+	if err != nil {
+		t.Fatalf("error reading config: %v", err)
+	}
+	config.DownloadDir = ""
+	err = config.Save()
+	if err != nil {
+		t.Fatalf("error saving config: %v", err)
+	}
+	err = config.SetUser("Vick")
+	if err != nil {
+		t.Fatalf("error saving config: %v", err)
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatalf("error checking config: %v", err)
+	}
+	if info.Mode().Perm() != 0600 {
+		t.Errorf("expected 0600 permissions, got %v", info.Mode().Perm())
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("error reading config: %v", err)
+	}
+	var saved map[string]any
+	err = json.Unmarshal(data, &saved)
+	if err != nil {
+		t.Fatalf("saved config isn't JSON: %v\n%s", err, data)
+	}
+	fetcher, _ := saved["fetcher"].(map[string]any)
+	if saved["theme"] != "dark" || fetcher["retries"] != float64(3) || fetcher["timeout"] != "5s" {
+		t.Errorf("expected unknown keys to be kept:\n%s", data)
+	}
+	if _, ok := saved["download_dir"]; ok {
+		t.Errorf("expected cleared download_dir to be removed:\n%s", data)
+	}
+	if saved["current_user_name"] != "Vick" {
+		t.Errorf("expected the new user to be saved:\n%s", data)
+	}
+
+	entries, err := os.ReadDir(filepath.Dir(path))
+	if err != nil {
+		t.Fatalf("error listing config dir: %v", err)
+	}
+	for _, entry := range entries {
+		if filepath.Ext(entry.Name()) == ".tmp" {
+			t.Errorf("temporary file %s left behind", entry.Name())
+		}
+	}
+}
+
+func TestConcurrentSavesLeaveValidConfig(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.json")
+	var wg sync.WaitGroup
+	for i := range 20 {
+		wg.Go(func() {
+			config := NewConfigAt(path)
+			config.DbUrl = "postgres://example"
+			err := config.SetUser(fmt.Sprintf("user%d", i))
+			if err != nil {
+				t.Errorf("error saving config: %v", err)
+			}
+		})
+	}
+	wg.Wait()
+
+	config := NewConfigAt(path)
+	err := config.Read()
+	if err != nil {
+		t.Fatalf("config corrupted by concurrent saves: %v", err)
+	}
+	if config.DbUrl != "postgres://example" || config.CurrentUserName == "" {
+		t.Fatalf("unexpected config after concurrent saves: %+v", config)
+	}
+}
+
+func TestSavesKeepOtherWritersChanges(t *testing.T) {
+	path := writeConfigFile(t, `{"version": 1, "db_url": "postgres://example"}`)
+	first := NewConfigAt(path)
+	second := NewConfigAt(path)
+	for _, config := range []*Config{first, second} {
+		err := config.Read()
+		if err != nil {
+			t.Fatalf("error reading config: %v", err)
+		}
+	}
+
+	err := first.AddProfile("work", "postgres://db.internal/gator")
+	if err != nil {
+		t.Fatalf("error adding profile: %v", err)
+	}
+	err = second.SetUser("Shawn")
+	if err != nil {
+		t.Fatalf("error saving config: %v", err)
+	}
+
+	reread := NewConfigAt(path)
+	err = reread.Read()
+	if err != nil {
+		t.Fatalf("error reading config: %v", err)
+	}
+	if !reread.HasProfile("work") || reread.CurrentUserName != "Shawn" {
+		t.Fatalf("expected both saves to be kept, got %+v", reread)
+	}
+	if !second.HasProfile("work") {
+		t.Errorf("expected the saving config to pick up the other change, got %+v", second)
+	}
+}
+
+func TestSaveReplacesTheLinkedFile(t *testing.T) {
+	target := writeConfigFile(t, `{"version": 1, "db_url": "postgres://example"}`)
+	link := filepath.Join(t.TempDir(), "config.json")
+	err := os.Symlink(target, link)
+	if err != nil {
+		t.Skipf("can't create symlinks: %v", err)
+	}
+
+	config := NewConfigAt(link)
+	err = config.Read()
+	if err != nil {
+		t.Fatalf("error reading config: %v", err)
+	}
+	err = config.SetUser("Gus")
+	if err != nil {
+		t.Fatalf("error saving config: %v", err)
+	}
+
+	info, err := os.Lstat(link)
+	if err != nil || info.Mode()&os.ModeSymlink == 0 {
+		t.Fatalf("expected %s to still be a symlink, got %v, %v", link, info, err)
+	}
+	reread := NewConfigAt(target)
+	err = reread.Read()
+	if err != nil {
+		t.Fatalf("error reading config: %v", err)
+	}
+	if reread.CurrentUserName != "Gus" {
+		t.Fatalf("expected the linked file to be saved, got %+v", reread)
+	}
+}
@@ -0,0 +1,363 @@
+package config
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/WagnerJust/go-gator/internal/output"
+	"github.com/WagnerJust/go-gator/internal/storage"
+)
+
+// CurrentVersion is the config schema version this build reads and writes.
+// It is stored in the file's "version" key rather than on Config, which
+// always holds the current schema once read.
+const CurrentVersion = 1
+
+// Defaults used when a key is left unset.
+const (
+	DefaultFetchTimeout = 30 * time.Second
+	DefaultMaxBodyBytes = 10 << 20
+	DefaultWorkers      = 1
+)
+
+// FetcherConfig tunes how feeds are downloaded.
+type FetcherConfig struct {
+	// Timeout is a duration such as "30s".
+	Timeout      string `json:"timeout,omitempty"`
+	UserAgent    string `json:"user_agent,omitempty"`
+	Proxy        string `json:"proxy,omitempty"`
+	MaxBodyBytes int64  `json:"max_body_bytes,omitempty"`
+}
+
+// AggregatorConfig tunes agg.
+type AggregatorConfig struct {
+	Workers int `json:"workers,omitempty"`
+	// Interval is agg's default time between requests, e.g. "1m".
+	Interval string `json:"interval,omitempty"`
+	// Retention is how long posts are kept, e.g. "90d". Empty keeps them
+	// forever.
+	Retention string `json:"retention,omitempty"`
+	// KeepPosts is how many of each feed's newest posts are kept; zero keeps
+	// them all.
+	KeepPosts int `json:"keep_posts,omitempty"`
+	// FeedRetention replaces Retention and KeepPosts for the feeds whose
+	// URLs it's keyed by.
+	FeedRetention map[string]RetentionPolicy `json:"feed_retention,omitempty"`
+	// PruneInterval is how often agg prunes posts, e.g. "24h". Empty leaves
+	// pruning to the prune command.
+	PruneInterval string `json:"prune_interval,omitempty"`
+}
+
+// RetentionPolicy is which of a feed's posts are kept: those newer than
+// Retention and among its KeepPosts newest. Starred posts and posts a
+// follower hasn't read are kept regardless.
+type RetentionPolicy struct {
+	Retention string `json:"retention,omitempty"`
+	KeepPosts int    `json:"keep_posts,omitempty"`
+}
+
+// OutputConfig sets output defaults.
+type OutputConfig struct {
+	// Format is the default for --output.
+	Format string `json:"format,omitempty"`
+	// Color is "auto", "always" or "never".
+	Color string `json:"color,omitempty"`
+	// Timezone is the zone times are shown in, an IANA name such as
+	// "Europe/Berlin". Empty is the system's zone.
+	Timezone string `json:"timezone,omitempty"`
+}
+
+// LoggingConfig controls diagnostics.
+type LoggingConfig struct {
+	// Level is "info" or "debug"; debug is the same as --verbose.
+	Level string `json:"level,omitempty"`
+	// File receives diagnostics instead of stderr.
+	File string `json:"file,omitempty"`
+}
+
+// TimeoutDuration returns the fetch timeout, or DefaultFetchTimeout.
+func (f FetcherConfig) TimeoutDuration() time.Duration {
+	timeout, err := ParseDuration(f.Timeout)
+	if err != nil || f.Timeout == "" {
+		return DefaultFetchTimeout
+	}
+	return timeout
+}
+
+// MaxBody returns the body size limit, or DefaultMaxBodyBytes.
+func (f FetcherConfig) MaxBody() int64 {
+	if f.MaxBodyBytes <= 0 {
+		return DefaultMaxBodyBytes
+	}
+	return f.MaxBodyBytes
+}
+
+// WorkerCount returns how many feeds agg fetches at once, or DefaultWorkers.
+func (a AggregatorConfig) WorkerCount() int {
+	if a.Workers <= 0 {
+		return DefaultWorkers
+	}
+	return a.Workers
+}
+
+// IntervalDuration returns agg's default interval, or zero when unset.
+func (a AggregatorConfig) IntervalDuration() time.Duration {
+	interval, _ := ParseDuration(a.Interval)
+	return interval
+}
+
+// RetentionDuration returns how long posts are kept, or zero for forever.
+func (a AggregatorConfig) RetentionDuration() time.Duration {
+	retention, _ := ParseDuration(a.Retention)
+	return retention
+}
+
+// RetentionFor returns the retention policy for the feed at url.
+func (a AggregatorConfig) RetentionFor(url string) RetentionPolicy {
+	if policy, ok := a.FeedRetention[url]; ok {
+		return policy
+	}
+	return RetentionPolicy{Retention: a.Retention, KeepPosts: a.KeepPosts}
+}
+
+// PruneIntervalDuration returns how often agg prunes, or zero for never.
+func (a AggregatorConfig) PruneIntervalDuration() time.Duration {
+	interval, _ := ParseDuration(a.PruneInterval)
+	return interval
+}
+
+// MaxAge returns how long posts are kept, or zero for forever.
+func (r RetentionPolicy) MaxAge() time.Duration {
+	retention, _ := ParseDuration(r.Retention)
+	return retention
+}
+
+// KeepsAll reports whether the policy never prunes anything.
+func (r RetentionPolicy) KeepsAll() bool {
+	return r.MaxAge() <= 0 && r.KeepPosts <= 0
+}
+
+// Location returns the zone times are shown in, or the system's zone when
+// Timezone is unset or unknown.
+func (o OutputConfig) Location() *time.Location {
+	if o.Timezone == "" {
+		return time.Local
+	}
+	location, err := time.LoadLocation(o.Timezone)
+	if err != nil {
+		return time.Local
+	}
+	return location
+}
+
+// ParseDuration parses a Go duration such as "90m", also accepting a whole
+// number of days such as "30d". An empty string is zero.
+func ParseDuration(value string) (time.Duration, error) {
+	if value == "" {
+		return 0, nil
+	}
+	if days, ok := strings.CutSuffix(value, "d"); ok {
+		n, err := strconv.Atoi(days)
+		if err != nil {
+			return 0, fmt.Errorf("invalid duration %q", value)
+		}
+		return time.Duration(n) * 24 * time.Hour, nil
+	}
+	return time.ParseDuration(value)
+}
+
+// KeyError is a problem with one config key, named by its dotted path.
+type KeyError struct {
+	Key     string
+	Message string
+}
+
+func (e *KeyError) Error() string {
+	return e.Key + ": " + e.Message
+}
+
+// Validate checks every key's value, returning one KeyError per problem
+// joined together.
+func (c *Config) Validate() error {
+	var problems []error
+	problem := func(key, format string, args ...any) {
+		problems = append(problems, &KeyError{Key: key, Message: fmt.Sprintf(format, args...)})
+	}
+	checkDuration := func(key, value string) {
+		d, err := ParseDuration(value)
+		if err != nil {
+			problem(key, "%q is not a duration like \"30s\", \"5m\" or \"30d\"", value)
+		} else if d < 0 {
+			problem(key, "must not be negative")
+		}
+	}
+
+	checkDbUrl := func(key, value string) {
+		if err := storage.CheckDbUrl(value); err != nil {
+			problem(key, "%v", err)
+		}
+	}
+
+	// The top-level db_url may be left to GATOR_DB_URL or --db-url;
+	// CheckFile reports it missing.
+	if c.DbUrl != "" {
+		checkDbUrl("db_url", c.DbUrl)
+	}
+	for _, name := range c.ProfileNames()[1:] {
+		checkDbUrl("profiles."+name+".db_url", c.Profiles[name].DbUrl)
+	}
+	if _, ok := c.Profiles[DefaultProfile]; ok {
+		problem("profiles."+DefaultProfile, "is reserved for the top-level db_url and current_user_name")
+	}
+	if c.ActiveProfile != "" && !c.HasProfile(c.ActiveProfile) {
+		problem("active_profile", "no profile named %q", c.ActiveProfile)
+	}
+
+	checkDuration("fetcher.timeout", c.Fetcher.Timeout)
+	if c.Fetcher.Proxy != "" {
+		u, err := url.Parse(c.Fetcher.Proxy)
+		if err != nil || u.Scheme == "" || u.Host == "" {
+			problem("fetcher.proxy", "%q is not a URL like \"http://proxy:8080\"", c.Fetcher.Proxy)
+		}
+	}
+	if c.Fetcher.MaxBodyBytes < 0 {
+		problem("fetcher.max_body_bytes", "must not be negative")
+	}
+
+	if c.Aggregator.Workers < 0 {
+		problem("aggregator.workers", "must not be negative")
+	}
+	checkDuration("aggregator.interval", c.Aggregator.Interval)
+	checkDuration("aggregator.retention", c.Aggregator.Retention)
+	if c.Aggregator.KeepPosts < 0 {
+		problem("aggregator.keep_posts", "must not be negative")
+	}
+	feedURLs := make([]string, 0, len(c.Aggregator.FeedRetention))
+	for feedURL := range c.Aggregator.FeedRetention {
+		feedURLs = append(feedURLs, feedURL)
+	}
+	sort.Strings(feedURLs)
+	for _, feedURL := range feedURLs {
+		key := "aggregator.feed_retention." + feedURL
+		checkDuration(key+".retention", c.Aggregator.FeedRetention[feedURL].Retention)
+		if c.Aggregator.FeedRetention[feedURL].KeepPosts < 0 {
+			problem(key+".keep_posts", "must not be negative")
+		}
+	}
+	checkDuration("aggregator.prune_interval", c.Aggregator.PruneInterval)
+
+	if _, err := output.ParseFormat(c.Output.Format); err != nil {
+		problem("output.format", "%v", err)
+	}
+	switch c.Output.Color {
+	case "", "auto", "always", "never":
+	default:
+		problem("output.color", "%q must be auto, always or never", c.Output.Color)
+	}
+	if c.Output.Timezone != "" {
+		if _, err := time.LoadLocation(c.Output.Timezone); err != nil {
+			problem("output.timezone", "%q is not a time zone like \"Europe/Berlin\" or \"UTC\"", c.Output.Timezone)
+		}
+	}
+
+	switch c.Logging.Level {
+	case "", "info", "debug":
+	default:
+		problem("logging.level", "%q must be info or debug", c.Logging.Level)
+	}
+	return errors.Join(problems...)
+}
+
+// configFile is Config as stored on disk, with the schema version alongside.
+type configFile struct {
+	Version int `json:"version"`
+	*fileFields
+}
+
+type fileFields Config
+
+func (c Config) MarshalJSON() ([]byte, error) {
+	return json.Marshal(configFile{Version: CurrentVersion, fileFields: (*fileFields)(&c)})
+}
+
+func (c *Config) UnmarshalJSON(data []byte) error {
+	file := configFile{fileFields: (*fileFields)(c)}
+	err := json.Unmarshal(data, &file)
+	if err != nil {
+		var typeErr *json.UnmarshalTypeError
+		if errors.As(err, &typeErr) && typeErr.Field != "" {
+			return &KeyError{Key: typeErr.Field, Message: fmt.Sprintf("expected %s, got %s", typeErr.Type, typeErr.Value)}
+		}
+		return err
+	}
+	// Files without a version predate versioning and match version 1.
+	if file.Version > CurrentVersion {
+		return &KeyError{Key: "version", Message: fmt.Sprintf("%d is newer than this gator understands (%d); upgrade gator", file.Version, CurrentVersion)}
+	}
+	return nil
+}
+
+// CheckFile reads the config at path strictly: on top of Validate, keys the
+// schema doesn't know are reported, typically typos.
+func CheckFile(path string) error {
+	config := NewConfigAt(path)
+	err := config.Read()
+	if err != nil {
+		return err
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	var raw map[string]any
+	err = json.Unmarshal(data, &raw)
+	if err != nil {
+		return err
+	}
+	var problems []error
+	for _, key := range unknownKeys(raw, reflect.TypeOf(Config{}), "") {
+		problems = append(problems, &KeyError{Key: key, Message: "unknown key"})
+	}
+	if config.DbUrl == "" {
+		problems = append(problems, &KeyError{Key: "db_url", Message: "is not set, so every command needs GATOR_DB_URL or --db-url"})
+	}
+	return errors.Join(append(problems, config.Validate())...)
+}
+
+// unknownKeys lists the dotted keys in raw that have no field in t.
+func unknownKeys(raw map[string]any, t reflect.Type, prefix string) []string {
+	fields := jsonFields(t)
+	var unknown []string
+	for key, value := range raw {
+		fieldType, ok := fields[key]
+		if !ok {
+			unknown = append(unknown, prefix+key)
+			continue
+		}
+		nested, ok := value.(map[string]any)
+		if !ok {
+			continue
+		}
+		switch {
+		case fieldType.Kind() == reflect.Struct:
+			unknown = append(unknown, unknownKeys(nested, fieldType, prefix+key+".")...)
+		case fieldType.Kind() == reflect.Map && fieldType.Elem().Kind() == reflect.Struct:
+			// Sections such as profiles are keyed by name, each entry a struct.
+			for name, entry := range nested {
+				if entry, ok := entry.(map[string]any); ok {
+					unknown = append(unknown, unknownKeys(entry, fieldType.Elem(), prefix+key+"."+name+".")...)
+				}
+			}
+		}
+	}
+	sort.Strings(unknown)
+	return unknown
+}
@@ -0,0 +1,176 @@
+package config
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func writeConfigFile(t *testing.T, contents string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "config.json")
+	err := os.WriteFile(path, []byte(contents), 0600)
+	if err != nil {
+		t.Fatalf("error writing config: %v", err)
+	}
+	return path
+}
+
+func TestSectionsRoundTrip(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.json")
+	config := NewConfigAt(path)
+	config.DbUrl = "postgres://example"
+	config.Fetcher = FetcherConfig{Timeout: "10s", UserAgent: "gator-test", MaxBodyBytes: 2048}
+	config.Aggregator = AggregatorConfig{Workers: 4, Interval: "5m", Retention: "30d"}
+	config.Output = OutputConfig{Format: "json", Color: "never"}
+	config.Logging = LoggingConfig{Level: "debug"}
+	err := config.SetUser("Lassiter")
+	if err != nil {
+		t.Fatalf("error saving config: %v", err)
+	}
+
+	reread := NewConfigAt(path)
+	err = reread.Read()
+	if err != nil {
+		t.Fatalf("error reading config: %v", err)
+	}
+	diff := cmp.Diff(config, reread)
+	if diff != "" {
+		t.Fatalf("config mismatch (-want +got):\n%s", diff)
+	}
+	if reread.Fetcher.TimeoutDuration() != 10*time.Second || reread.Aggregator.RetentionDuration() != 30*24*time.Hour {
+		t.Fatalf("unexpected durations in %+v", reread)
+	}
+}
+
+func TestValidateNamesKeys(t *testing.T) {
+	config := &Config{
+		Fetcher:    FetcherConfig{Timeout: "soon", Proxy: "not a url"},
+		Aggregator: AggregatorConfig{Workers: -1},
+		Output:     OutputConfig{Format: "xml", Color: "sometimes", Timezone: "Mars/Olympus_Mons"},
+		Logging:    LoggingConfig{Level: "loud"},
+	}
+	err := config.Validate()
+	if err == nil {
+		t.Fatalf("expected validation errors")
+	}
+	for _, key := range []string{"fetcher.timeout", "fetcher.proxy", "aggregator.workers", "output.format", "output.color", "output.timezone", "logging.level"} {
+		if !strings.Contains(err.Error(), key+":") {
+			t.Errorf("expected a problem with %s in:\n%v", key, err)
+		}
+	}
+	if (&Config{}).Validate() != nil {
+		t.Errorf("expected an empty config to be valid")
+	}
+	if err := (&Config{Output: OutputConfig{Timezone: "Asia/Tokyo"}}).Validate(); err != nil {
+		t.Errorf("expected an IANA time zone to be valid, got %v", err)
+	}
+	if err := (&Config{DbUrl: "~/gator.db"}).Validate(); err != nil {
+		t.Errorf("expected a SQLite file path to be valid, got %v", err)
+	}
+	for _, dbUrl := range []string{"host=localhost dbname=gator", "sqlite:///tmp/gator.db", "postgres:///gator?host=/run/postgresql"} {
+		if err := (&Config{DbUrl: dbUrl}).Validate(); err != nil {
+			t.Errorf("expected %q to be valid, got %v", dbUrl, err)
+		}
+	}
+	for _, dbUrl := range []string{"mysql://localhost/gator", "postgres:///gator", "postgres://%zz", "postgres//user@host/db", "localhost:5432/gator"} {
+		if err := (&Config{DbUrl: dbUrl}).Validate(); err == nil || !strings.Contains(err.Error(), "db_url:") {
+			t.Errorf("expected %q to be rejected, got %v", dbUrl, err)
+		}
+	}
+}
+
+func TestRetentionFor(t *testing.T) {
+	aggregator := AggregatorConfig{
+		Retention: "30d",
+		KeepPosts: 100,
+		FeedRetention: map[string]RetentionPolicy{
+			"https://example.com/daily":   {KeepPosts: 10},
+			"https://example.com/archive": {},
+		},
+	}
+	policy := aggregator.RetentionFor("https://example.com/blog")
+	if policy.MaxAge() != 30*24*time.Hour || policy.KeepPosts != 100 {
+		t.Errorf("expected the global policy, got %+v", policy)
+	}
+	policy = aggregator.RetentionFor("https://example.com/daily")
+	if policy.MaxAge() != 0 || policy.KeepPosts != 10 {
+		t.Errorf("expected the feed's policy to replace the global one, got %+v", policy)
+	}
+	if !aggregator.RetentionFor("https://example.com/archive").KeepsAll() {
+		t.Errorf("expected an empty feed policy to keep everything")
+	}
+
+	config := &Config{Aggregator: AggregatorConfig{
+		KeepPosts:     -1,
+		PruneInterval: "daily",
+		FeedRetention: map[string]RetentionPolicy{"https://example.com/daily": {Retention: "a week"}},
+	}}
+	err := config.Validate()
+	for _, key := range []string{"aggregator.keep_posts", "aggregator.prune_interval", "aggregator.feed_retention.https://example.com/daily.retention"} {
+		if err == nil || !strings.Contains(err.Error(), key+":") {
+			t.Errorf("expected a problem with %s in:\n%v", key, err)
+		}
+	}
+}
+
+func TestReadRejectsWrongTypesAndNewerVersions(t *testing.T) {
+	var keyErr *KeyError
+	err := NewConfigAt(writeConfigFile(t, `{"fetcher": {"timeout": 30}}`)).Read()
+	if !errors.As(err, &keyErr) || keyErr.Key != "fetcher.timeout" {
+		t.Fatalf("expected a fetcher.timeout error, got %v", err)
+	}
+	err = NewConfigAt(writeConfigFile(t, `{"version": 99}`)).Read()
+	if !errors.As(err, &keyErr) || keyErr.Key != "version" {
+		t.Fatalf("expected a version error, got %v", err)
+	}
+	err = NewConfigAt(writeConfigFile(t, `{"db_url": "postgres://example"}`)).Read()
+	if err != nil {
+		t.Fatalf("expected an unversioned config to read, got %v", err)
+	}
+}
+
+func TestCheckFileReportsUnknownKeys(t *testing.T) {
+	path := writeConfigFile(t, `{"version": 1, "db_url": "postgres://example", "fetcher": {"timout": "5s"}, "colour": "never"}`)
+	err := CheckFile(path)
+	if err == nil {
+		t.Fatalf("expected unknown keys to be reported")
+	}
+	expected := "colour: unknown key\nfetcher.timout: unknown key"
+	if err.Error() != expected {
+		t.Fatalf("expected %q, got %q", expected, err.Error())
+	}
+}
+
+func TestCheckFileReportsMissingAndUnusableDbUrls(t *testing.T) {
+	path := writeConfigFile(t, `{"version": 1, "profiles": {"work": {"db_url": "localhost:5432/gator"}, "empty": {"db_url": ""}}}`)
+	err := CheckFile(path)
+	if err == nil {
+		t.Fatalf("expected db_url problems to be reported")
+	}
+	for _, key := range []string{"db_url: is not set", "profiles.work.db_url: ", "profiles.empty.db_url: db_url is not set"} {
+		if !strings.Contains(err.Error(), key) {
+			t.Errorf("expected %q in:\n%v", key, err)
+		}
+	}
+	if (&Config{}).Validate() != nil {
+		t.Errorf("expected an empty top-level db_url to be left to GATOR_DB_URL or --db-url")
+	}
+}
+
+func TestCheckFileReportsUnknownKeysInNamedSections(t *testing.T) {
+	path := writeConfigFile(t, `{"version": 1, "db_url": "~/gator.db", "profiles": {"work": {"db_url": "postgres://db.internal/gator", "curent_user_name": "gus"}}, "aggregator": {"feed_retention": {"https://example.com/rss": {"retention": "7d", "keep": 5}}}}`)
+	err := CheckFile(path)
+	if err == nil {
+		t.Fatalf("expected unknown keys to be reported")
+	}
+	expected := "aggregator.feed_retention.https://example.com/rss.keep: unknown key\nprofiles.work.curent_user_name: unknown key"
+	if err.Error() != expected {
+		t.Fatalf("expected %q, got %q", expected, err.Error())
+	}
+}
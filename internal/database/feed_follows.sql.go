@@ -84,9 +84,42 @@ func (q *Queries) DeleteFeedFollowByUser(ctx context.Context, arg DeleteFeedFoll
 	return err
 }
 
+const getAllFeedFollows = `-- name: GetAllFeedFollows :many
+SELECT id, created_at, updated_at, user_id, feed_id FROM feed_follows ORDER BY created_at, id
+`
+
+func (q *Queries) GetAllFeedFollows(ctx context.Context) ([]FeedFollow, error) {
+	rows, err := q.db.QueryContext(ctx, getAllFeedFollows)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []FeedFollow
+	for rows.Next() {
+		var i FeedFollow
+		if err := rows.Scan(
+			&i.ID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.UserID,
+			&i.FeedID,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const getFeedFollowsForUser = `-- name: GetFeedFollowsForUser :many
 
-SELECT follow.id, follow.created_at, follow.updated_at, follow.user_id, follow.feed_id, feeds.name AS feed_name, users.name AS user_name
+SELECT follow.id, follow.created_at, follow.updated_at, follow.user_id, follow.feed_id, feeds.name AS feed_name, users.name AS user_name, feeds.url AS feed_url
 FROM feed_follows follow
 JOIN feeds ON follow.feed_id = feeds.id
 JOIN users ON follow.user_id = users.id
@@ -101,6 +134,7 @@ type GetFeedFollowsForUserRow struct {
 	FeedID    uuid.UUID
 	FeedName  string
 	UserName  string
+	FeedUrl   string
 }
 
 func (q *Queries) GetFeedFollowsForUser(ctx context.Context, id uuid.UUID) ([]GetFeedFollowsForUserRow, error) {
@@ -120,6 +154,7 @@ func (q *Queries) GetFeedFollowsForUser(ctx context.Context, id uuid.UUID) ([]Ge
 			&i.FeedID,
 			&i.FeedName,
 			&i.UserName,
+			&i.FeedUrl,
 		); err != nil {
 			return nil, err
 		}
@@ -22,7 +22,7 @@ VALUES (
     $4,
     $5,
     $6
-) RETURNING id, created_at, updated_at, last_fetched_at, name, url, user_id
+) RETURNING id, created_at, updated_at, last_fetched_at, name, url, user_id, last_fetch_warning
 `
 
 type CreateFeedParams struct {
@@ -52,12 +52,22 @@ func (q *Queries) CreateFeed(ctx context.Context, arg CreateFeedParams) (Feed, e
 		&i.Name,
 		&i.Url,
 		&i.UserID,
+		&i.LastFetchWarning,
 	)
 	return i, err
 }
 
+const deleteAllFeeds = `-- name: DeleteAllFeeds :exec
+DELETE FROM feeds
+`
+
+func (q *Queries) DeleteAllFeeds(ctx context.Context) error {
+	_, err := q.db.ExecContext(ctx, deleteAllFeeds)
+	return err
+}
+
 const getAllFeeds = `-- name: GetAllFeeds :many
-SELECT id, created_at, updated_at, last_fetched_at, name, url, user_id FROM feeds
+SELECT id, created_at, updated_at, last_fetched_at, name, url, user_id, last_fetch_warning FROM feeds
 `
 
 func (q *Queries) GetAllFeeds(ctx context.Context) ([]Feed, error) {
@@ -77,6 +87,7 @@ func (q *Queries) GetAllFeeds(ctx context.Context) ([]Feed, error) {
 			&i.Name,
 			&i.Url,
 			&i.UserID,
+			&i.LastFetchWarning,
 		); err != nil {
 			return nil, err
 		}
@@ -92,18 +103,19 @@ func (q *Queries) GetAllFeeds(ctx context.Context) ([]Feed, error) {
 }
 
 const getAllFeedsWithUsers = `-- name: GetAllFeedsWithUsers :many
-SELECT f.id, f.created_at, f.updated_at, f.last_fetched_at, f.name, f.url, f.user_id, u.name AS user_name FROM feeds f JOIN users u ON u.id = f.user_id
+SELECT f.id, f.created_at, f.updated_at, f.last_fetched_at, f.name, f.url, f.user_id, f.last_fetch_warning, u.name AS user_name FROM feeds f JOIN users u ON u.id = f.user_id
 `
 
 type GetAllFeedsWithUsersRow struct {
-	ID            uuid.UUID
-	CreatedAt     time.Time
-	UpdatedAt     time.Time
-	LastFetchedAt sql.NullTime
-	Name          string
-	Url           string
-	UserID        uuid.UUID
-	UserName      string
+	ID               uuid.UUID
+	CreatedAt        time.Time
+	UpdatedAt        time.Time
+	LastFetchedAt    sql.NullTime
+	Name             string
+	Url              string
+	UserID           uuid.UUID
+	LastFetchWarning sql.NullString
+	UserName         string
 }
 
 func (q *Queries) GetAllFeedsWithUsers(ctx context.Context) ([]GetAllFeedsWithUsersRow, error) {
@@ -123,6 +135,7 @@ func (q *Queries) GetAllFeedsWithUsers(ctx context.Context) ([]GetAllFeedsWithUs
 			&i.Name,
 			&i.Url,
 			&i.UserID,
+			&i.LastFetchWarning,
 			&i.UserName,
 		); err != nil {
 			return nil, err
@@ -138,8 +151,28 @@ func (q *Queries) GetAllFeedsWithUsers(ctx context.Context) ([]GetAllFeedsWithUs
 	return items, nil
 }
 
+const getFeedByID = `-- name: GetFeedByID :one
+SELECT id, created_at, updated_at, last_fetched_at, name, url, user_id, last_fetch_warning FROM feeds WHERE id = $1
+`
+
+func (q *Queries) GetFeedByID(ctx context.Context, id uuid.UUID) (Feed, error) {
+	row := q.db.QueryRowContext(ctx, getFeedByID, id)
+	var i Feed
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.LastFetchedAt,
+		&i.Name,
+		&i.Url,
+		&i.UserID,
+		&i.LastFetchWarning,
+	)
+	return i, err
+}
+
 const getFeedByUrl = `-- name: GetFeedByUrl :one
-SELECT id, created_at, updated_at, last_fetched_at, name, url, user_id FROM feeds WHERE url = $1
+SELECT id, created_at, updated_at, last_fetched_at, name, url, user_id, last_fetch_warning FROM feeds WHERE url = $1
 `
 
 func (q *Queries) GetFeedByUrl(ctx context.Context, url string) (Feed, error) {
@@ -153,12 +186,13 @@ func (q *Queries) GetFeedByUrl(ctx context.Context, url string) (Feed, error) {
 		&i.Name,
 		&i.Url,
 		&i.UserID,
+		&i.LastFetchWarning,
 	)
 	return i, err
 }
 
 const getNextFeedToFetch = `-- name: GetNextFeedToFetch :one
-SELECT id, created_at, updated_at, last_fetched_at, name, url, user_id FROM feeds ORDER BY last_fetched_at ASC NULLS FIRST LIMIT 1
+SELECT id, created_at, updated_at, last_fetched_at, name, url, user_id, last_fetch_warning FROM feeds ORDER BY last_fetched_at ASC NULLS FIRST LIMIT 1
 `
 
 func (q *Queries) GetNextFeedToFetch(ctx context.Context) (Feed, error) {
@@ -172,10 +206,47 @@ func (q *Queries) GetNextFeedToFetch(ctx context.Context) (Feed, error) {
 		&i.Name,
 		&i.Url,
 		&i.UserID,
+		&i.LastFetchWarning,
 	)
 	return i, err
 }
 
+const getNextFeedsToFetch = `-- name: GetNextFeedsToFetch :many
+SELECT id, created_at, updated_at, last_fetched_at, name, url, user_id, last_fetch_warning FROM feeds ORDER BY last_fetched_at ASC NULLS FIRST LIMIT $1
+`
+
+func (q *Queries) GetNextFeedsToFetch(ctx context.Context, limit int32) ([]Feed, error) {
+	rows, err := q.db.QueryContext(ctx, getNextFeedsToFetch, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Feed
+	for rows.Next() {
+		var i Feed
+		if err := rows.Scan(
+			&i.ID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.LastFetchedAt,
+			&i.Name,
+			&i.Url,
+			&i.UserID,
+			&i.LastFetchWarning,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const markFeedFetched = `-- name: MarkFeedFetched :exec
 UPDATE feeds SET last_fetched_at = CURRENT_TIMESTAMP, updated_at = CURRENT_TIMESTAMP WHERE id = $1
 `
@@ -184,3 +255,47 @@ func (q *Queries) MarkFeedFetched(ctx context.Context, id uuid.UUID) error {
 	_, err := q.db.ExecContext(ctx, markFeedFetched, id)
 	return err
 }
+
+const restoreFeed = `-- name: RestoreFeed :exec
+INSERT INTO feeds (id, created_at, updated_at, last_fetched_at, name, url, user_id, last_fetch_warning)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+`
+
+type RestoreFeedParams struct {
+	ID               uuid.UUID
+	CreatedAt        time.Time
+	UpdatedAt        time.Time
+	LastFetchedAt    sql.NullTime
+	Name             string
+	Url              string
+	UserID           uuid.UUID
+	LastFetchWarning sql.NullString
+}
+
+func (q *Queries) RestoreFeed(ctx context.Context, arg RestoreFeedParams) error {
+	_, err := q.db.ExecContext(ctx, restoreFeed,
+		arg.ID,
+		arg.CreatedAt,
+		arg.UpdatedAt,
+		arg.LastFetchedAt,
+		arg.Name,
+		arg.Url,
+		arg.UserID,
+		arg.LastFetchWarning,
+	)
+	return err
+}
+
+const setFeedFetchWarning = `-- name: SetFeedFetchWarning :exec
+UPDATE feeds SET last_fetch_warning = $2 WHERE id = $1
+`
+
+type SetFeedFetchWarningParams struct {
+	ID               uuid.UUID
+	LastFetchWarning sql.NullString
+}
+
+func (q *Queries) SetFeedFetchWarning(ctx context.Context, arg SetFeedFetchWarningParams) error {
+	_, err := q.db.ExecContext(ctx, setFeedFetchWarning, arg.ID, arg.LastFetchWarning)
+	return err
+}
@@ -0,0 +1,155 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.30.0
+// source: post_enclosures.sql
+
+package database
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const createPostEnclosure = `-- name: CreatePostEnclosure :one
+INSERT INTO post_enclosures (id, created_at, updated_at, post_id, url, mime_type, length, duration, episode, image_url)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+RETURNING id, created_at, updated_at, post_id, url, mime_type, length, duration, episode, image_url
+`
+
+type CreatePostEnclosureParams struct {
+	ID        uuid.UUID
+	CreatedAt time.Time
+	UpdatedAt time.Time
+	PostID    uuid.UUID
+	Url       string
+	MimeType  sql.NullString
+	Length    sql.NullInt64
+	Duration  sql.NullString
+	Episode   sql.NullString
+	ImageUrl  sql.NullString
+}
+
+func (q *Queries) CreatePostEnclosure(ctx context.Context, arg CreatePostEnclosureParams) (PostEnclosure, error) {
+	row := q.db.QueryRowContext(ctx, createPostEnclosure,
+		arg.ID,
+		arg.CreatedAt,
+		arg.UpdatedAt,
+		arg.PostID,
+		arg.Url,
+		arg.MimeType,
+		arg.Length,
+		arg.Duration,
+		arg.Episode,
+		arg.ImageUrl,
+	)
+	var i PostEnclosure
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.PostID,
+		&i.Url,
+		&i.MimeType,
+		&i.Length,
+		&i.Duration,
+		&i.Episode,
+		&i.ImageUrl,
+	)
+	return i, err
+}
+
+const getAllPostEnclosures = `-- name: GetAllPostEnclosures :many
+SELECT id, created_at, updated_at, post_id, url, mime_type, length, duration, episode, image_url FROM post_enclosures ORDER BY created_at, id
+`
+
+func (q *Queries) GetAllPostEnclosures(ctx context.Context) ([]PostEnclosure, error) {
+	rows, err := q.db.QueryContext(ctx, getAllPostEnclosures)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []PostEnclosure
+	for rows.Next() {
+		var i PostEnclosure
+		if err := rows.Scan(
+			&i.ID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.PostID,
+			&i.Url,
+			&i.MimeType,
+			&i.Length,
+			&i.Duration,
+			&i.Episode,
+			&i.ImageUrl,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getEnclosuresForPost = `-- name: GetEnclosuresForPost :many
+SELECT id, created_at, updated_at, post_id, url, mime_type, length, duration, episode, image_url FROM post_enclosures WHERE post_id = $1 ORDER BY created_at
+`
+
+func (q *Queries) GetEnclosuresForPost(ctx context.Context, postID uuid.UUID) ([]PostEnclosure, error) {
+	rows, err := q.db.QueryContext(ctx, getEnclosuresForPost, postID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []PostEnclosure
+	for rows.Next() {
+		var i PostEnclosure
+		if err := rows.Scan(
+			&i.ID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.PostID,
+			&i.Url,
+			&i.MimeType,
+			&i.Length,
+			&i.Duration,
+			&i.Episode,
+			&i.ImageUrl,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const insertPostEnclosures = `-- name: InsertPostEnclosures :exec
+INSERT INTO post_enclosures (id, created_at, updated_at, post_id, url, mime_type, length, duration, episode, image_url)
+SELECT (e->>'id')::uuid, (e->>'created_at')::timestamptz, (e->>'updated_at')::timestamptz,
+    (e->>'post_id')::uuid, e->>'url', e->>'mime_type', (e->>'length')::bigint,
+    e->>'duration', e->>'episode', e->>'image_url'
+FROM json_array_elements($1::json) AS e
+ON CONFLICT DO NOTHING
+`
+
+// InsertPostEnclosures adds a batch of enclosures, given as a JSON array of
+// objects keyed by column name, skipping those already stored.
+func (q *Queries) InsertPostEnclosures(ctx context.Context, enclosures json.RawMessage) error {
+	_, err := q.db.ExecContext(ctx, insertPostEnclosures, enclosures)
+	return err
+}
@@ -0,0 +1,262 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.30.0
+// source: post_states.sql
+
+package database
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const getAllPostStates = `-- name: GetAllPostStates :many
+SELECT id, created_at, updated_at, user_id, post_id, read_at, starred FROM post_states ORDER BY created_at, id
+`
+
+func (q *Queries) GetAllPostStates(ctx context.Context) ([]PostState, error) {
+	rows, err := q.db.QueryContext(ctx, getAllPostStates)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []PostState
+	for rows.Next() {
+		var i PostState
+		if err := rows.Scan(
+			&i.ID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.UserID,
+			&i.PostID,
+			&i.ReadAt,
+			&i.Starred,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getPostsWithStateForUser = `-- name: GetPostsWithStateForUser :many
+SELECT p.id, p.created_at, p.updated_at, p.title, p.url, p.description, p.published_at, p.feed_id, p.thumbnail_url,
+    (ps.read_at IS NOT NULL)::boolean AS read,
+    COALESCE(ps.starred, false)::boolean AS starred,
+    f.name AS feed_name
+FROM posts p
+JOIN feeds f ON f.id = p.feed_id
+JOIN feed_follows ff ON ff.feed_id = p.feed_id
+LEFT JOIN post_states ps ON ps.post_id = p.id AND ps.user_id = ff.user_id
+WHERE ff.user_id = $1
+    AND ($2::uuid IS NULL OR p.feed_id = $2)
+    AND (NOT $3::boolean OR ps.read_at IS NULL)
+    AND (NOT $4::boolean OR COALESCE(ps.starred, false))
+ORDER BY p.published_at DESC
+LIMIT $5
+`
+
+type GetPostsWithStateForUserParams struct {
+	UserID      uuid.UUID
+	FeedID      uuid.NullUUID
+	UnreadOnly  bool
+	StarredOnly bool
+	Limit       int32
+}
+
+type GetPostsWithStateForUserRow struct {
+	ID           uuid.UUID
+	CreatedAt    time.Time
+	UpdatedAt    time.Time
+	Title        string
+	Url          string
+	Description  sql.NullString
+	PublishedAt  time.Time
+	FeedID       uuid.UUID
+	ThumbnailUrl sql.NullString
+	Read         bool
+	Starred      bool
+	FeedName     string
+}
+
+func (q *Queries) GetPostsWithStateForUser(ctx context.Context, arg GetPostsWithStateForUserParams) ([]GetPostsWithStateForUserRow, error) {
+	rows, err := q.db.QueryContext(ctx, getPostsWithStateForUser,
+		arg.UserID,
+		arg.FeedID,
+		arg.UnreadOnly,
+		arg.StarredOnly,
+		arg.Limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetPostsWithStateForUserRow
+	for rows.Next() {
+		var i GetPostsWithStateForUserRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Title,
+			&i.Url,
+			&i.Description,
+			&i.PublishedAt,
+			&i.FeedID,
+			&i.ThumbnailUrl,
+			&i.Read,
+			&i.Starred,
+			&i.FeedName,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getUnreadCountsForUser = `-- name: GetUnreadCountsForUser :many
+SELECT f.id, f.name, f.url,
+    COUNT(p.id) FILTER (WHERE ps.read_at IS NULL) AS unread
+FROM feed_follows ff
+JOIN feeds f ON f.id = ff.feed_id
+LEFT JOIN posts p ON p.feed_id = f.id
+LEFT JOIN post_states ps ON ps.post_id = p.id AND ps.user_id = ff.user_id
+WHERE ff.user_id = $1
+GROUP BY f.id, f.name, f.url
+ORDER BY f.name
+`
+
+type GetUnreadCountsForUserRow struct {
+	ID     uuid.UUID
+	Name   string
+	Url    string
+	Unread int64
+}
+
+func (q *Queries) GetUnreadCountsForUser(ctx context.Context, userID uuid.UUID) ([]GetUnreadCountsForUserRow, error) {
+	rows, err := q.db.QueryContext(ctx, getUnreadCountsForUser, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetUnreadCountsForUserRow
+	for rows.Next() {
+		var i GetUnreadCountsForUserRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.Name,
+			&i.Url,
+			&i.Unread,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const restorePostState = `-- name: RestorePostState :exec
+INSERT INTO post_states (id, created_at, updated_at, user_id, post_id, read_at, starred)
+VALUES ($1, $2, $3, $4, $5, $6, $7)
+`
+
+type RestorePostStateParams struct {
+	ID        uuid.UUID
+	CreatedAt time.Time
+	UpdatedAt time.Time
+	UserID    uuid.UUID
+	PostID    uuid.UUID
+	ReadAt    sql.NullTime
+	Starred   bool
+}
+
+func (q *Queries) RestorePostState(ctx context.Context, arg RestorePostStateParams) error {
+	_, err := q.db.ExecContext(ctx, restorePostState,
+		arg.ID,
+		arg.CreatedAt,
+		arg.UpdatedAt,
+		arg.UserID,
+		arg.PostID,
+		arg.ReadAt,
+		arg.Starred,
+	)
+	return err
+}
+
+const setPostRead = `-- name: SetPostRead :exec
+INSERT INTO post_states (id, created_at, updated_at, user_id, post_id, read_at)
+VALUES ($1, $2, $3, $4, $5, $6)
+ON CONFLICT (user_id, post_id) DO UPDATE
+SET read_at = EXCLUDED.read_at, updated_at = EXCLUDED.updated_at
+`
+
+type SetPostReadParams struct {
+	ID        uuid.UUID
+	CreatedAt time.Time
+	UpdatedAt time.Time
+	UserID    uuid.UUID
+	PostID    uuid.UUID
+	ReadAt    sql.NullTime
+}
+
+func (q *Queries) SetPostRead(ctx context.Context, arg SetPostReadParams) error {
+	_, err := q.db.ExecContext(ctx, setPostRead,
+		arg.ID,
+		arg.CreatedAt,
+		arg.UpdatedAt,
+		arg.UserID,
+		arg.PostID,
+		arg.ReadAt,
+	)
+	return err
+}
+
+const setPostStarred = `-- name: SetPostStarred :exec
+INSERT INTO post_states (id, created_at, updated_at, user_id, post_id, starred)
+VALUES ($1, $2, $3, $4, $5, $6)
+ON CONFLICT (user_id, post_id) DO UPDATE
+SET starred = EXCLUDED.starred, updated_at = EXCLUDED.updated_at
+`
+
+type SetPostStarredParams struct {
+	ID        uuid.UUID
+	CreatedAt time.Time
+	UpdatedAt time.Time
+	UserID    uuid.UUID
+	PostID    uuid.UUID
+	Starred   bool
+}
+
+func (q *Queries) SetPostStarred(ctx context.Context, arg SetPostStarredParams) error {
+	_, err := q.db.ExecContext(ctx, setPostStarred,
+		arg.ID,
+		arg.CreatedAt,
+		arg.UpdatedAt,
+		arg.UserID,
+		arg.PostID,
+		arg.Starred,
+	)
+	return err
+}
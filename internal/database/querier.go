@@ -0,0 +1,67 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.30.0
+
+package database
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/google/uuid"
+)
+
+type Querier interface {
+	CreateFeed(ctx context.Context, arg CreateFeedParams) (Feed, error)
+	CreateFeedFollow(ctx context.Context, arg CreateFeedFollowParams) (CreateFeedFollowRow, error)
+	CreatePost(ctx context.Context, arg CreatePostParams) (Post, error)
+	CreatePostEnclosure(ctx context.Context, arg CreatePostEnclosureParams) (PostEnclosure, error)
+	CreateUser(ctx context.Context, arg CreateUserParams) (User, error)
+	DeleteAllFeeds(ctx context.Context) error
+	DeleteAllPosts(ctx context.Context) error
+	DeleteAllUsers(ctx context.Context) error
+	DeleteFeedFollowByUser(ctx context.Context, arg DeleteFeedFollowByUserParams) error
+	// DeletePostsByID deletes the posts whose IDs are in a JSON array, along with
+	// their enclosures and states.
+	DeletePostsByID(ctx context.Context, ids json.RawMessage) error
+	DeleteUser(ctx context.Context, id uuid.UUID) error
+	GetAllFeedFollows(ctx context.Context) ([]FeedFollow, error)
+	GetAllFeeds(ctx context.Context) ([]Feed, error)
+	GetAllFeedsWithUsers(ctx context.Context) ([]GetAllFeedsWithUsersRow, error)
+	GetAllPostEnclosures(ctx context.Context) ([]PostEnclosure, error)
+	GetAllPostStates(ctx context.Context) ([]PostState, error)
+	GetAllPosts(ctx context.Context) ([]Post, error)
+	GetAllUsers(ctx context.Context) ([]User, error)
+	GetEnclosuresForPost(ctx context.Context, postID uuid.UUID) ([]PostEnclosure, error)
+	GetFeedByID(ctx context.Context, id uuid.UUID) (Feed, error)
+	GetFeedByUrl(ctx context.Context, url string) (Feed, error)
+	GetFeedFollowsForUser(ctx context.Context, id uuid.UUID) ([]GetFeedFollowsForUserRow, error)
+	GetNextFeedToFetch(ctx context.Context) (Feed, error)
+	GetNextFeedsToFetch(ctx context.Context, limit int32) ([]Feed, error)
+	GetPostByID(ctx context.Context, id uuid.UUID) (Post, error)
+	GetPostsForUser(ctx context.Context, arg GetPostsForUserParams) ([]Post, error)
+	GetPostsWithStateForUser(ctx context.Context, arg GetPostsWithStateForUserParams) ([]GetPostsWithStateForUserRow, error)
+	// GetPrunablePosts lists a feed's posts that are older than published_before
+	// or beyond its keep_last newest (zero keeps all), leaving out posts anyone
+	// has starred. unread marks the posts a follower hasn't read, which are kept.
+	GetPrunablePosts(ctx context.Context, arg GetPrunablePostsParams) ([]GetPrunablePostsRow, error)
+	GetUnreadCountsForUser(ctx context.Context, userID uuid.UUID) ([]GetUnreadCountsForUserRow, error)
+	GetUserByName(ctx context.Context, lower string) (User, error)
+	GetUserDeletionImpact(ctx context.Context, userID uuid.UUID) (GetUserDeletionImpactRow, error)
+	GetUserStats(ctx context.Context, userID uuid.UUID) (GetUserStatsRow, error)
+	// InsertPostEnclosures adds a batch of enclosures, given as a JSON array of
+	// objects keyed by column name, skipping those already stored.
+	InsertPostEnclosures(ctx context.Context, enclosures json.RawMessage) error
+	// InsertPosts adds a batch of posts, given as a JSON array of objects keyed
+	// by column name, skipping those already stored.
+	InsertPosts(ctx context.Context, posts json.RawMessage) ([]Post, error)
+	MarkFeedFetched(ctx context.Context, id uuid.UUID) error
+	RenameUser(ctx context.Context, arg RenameUserParams) (User, error)
+	RestoreFeed(ctx context.Context, arg RestoreFeedParams) error
+	RestorePostState(ctx context.Context, arg RestorePostStateParams) error
+	SetFeedFetchWarning(ctx context.Context, arg SetFeedFetchWarningParams) error
+	SetPostRead(ctx context.Context, arg SetPostReadParams) error
+	SetPostStarred(ctx context.Context, arg SetPostStarredParams) error
+}
+
+var _ Querier = (*Queries)(nil)
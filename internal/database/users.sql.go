@@ -56,6 +56,15 @@ func (q *Queries) DeleteAllUsers(ctx context.Context) error {
 	return err
 }
 
+const deleteUser = `-- name: DeleteUser :exec
+DELETE FROM users WHERE id = $1
+`
+
+func (q *Queries) DeleteUser(ctx context.Context, id uuid.UUID) error {
+	_, err := q.db.ExecContext(ctx, deleteUser, id)
+	return err
+}
+
 const getAllUsers = `-- name: GetAllUsers :many
 SELECT id, created_at, updated_at, name FROM USERS
 `
@@ -89,11 +98,84 @@ func (q *Queries) GetAllUsers(ctx context.Context) ([]User, error) {
 }
 
 const getUserByName = `-- name: GetUserByName :one
-SELECT id, created_at, updated_at, name FROM users WHERE name = $1 LIMIT 1
+SELECT id, created_at, updated_at, name FROM users WHERE lower(name) = lower($1) LIMIT 1
+`
+
+func (q *Queries) GetUserByName(ctx context.Context, lower string) (User, error) {
+	row := q.db.QueryRowContext(ctx, getUserByName, lower)
+	var i User
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Name,
+	)
+	return i, err
+}
+
+const getUserDeletionImpact = `-- name: GetUserDeletionImpact :one
+SELECT
+    (SELECT COUNT(*) FROM posts JOIN feeds ON posts.feed_id = feeds.id WHERE feeds.user_id = $1) AS posts,
+    (SELECT COUNT(*) FROM feed_follows JOIN feeds ON feed_follows.feed_id = feeds.id
+        WHERE feeds.user_id = $1 AND feed_follows.user_id <> $1) AS other_follows,
+    (SELECT COUNT(DISTINCT feed_follows.user_id) FROM feed_follows JOIN feeds ON feed_follows.feed_id = feeds.id
+        WHERE feeds.user_id = $1 AND feed_follows.user_id <> $1) AS other_followers
 `
 
-func (q *Queries) GetUserByName(ctx context.Context, name string) (User, error) {
-	row := q.db.QueryRowContext(ctx, getUserByName, name)
+type GetUserDeletionImpactRow struct {
+	Posts          int64
+	OtherFollows   int64
+	OtherFollowers int64
+}
+
+func (q *Queries) GetUserDeletionImpact(ctx context.Context, userID uuid.UUID) (GetUserDeletionImpactRow, error) {
+	row := q.db.QueryRowContext(ctx, getUserDeletionImpact, userID)
+	var i GetUserDeletionImpactRow
+	err := row.Scan(&i.Posts, &i.OtherFollows, &i.OtherFollowers)
+	return i, err
+}
+
+const getUserStats = `-- name: GetUserStats :one
+SELECT
+    (SELECT COUNT(*) FROM feeds WHERE feeds.user_id = $1) AS feeds_added,
+    (SELECT COUNT(*) FROM feed_follows WHERE feed_follows.user_id = $1) AS follows,
+    (SELECT COUNT(*) FROM post_states WHERE post_states.user_id = $1 AND read_at IS NOT NULL) AS read_posts,
+    (SELECT COUNT(*) FROM post_states WHERE post_states.user_id = $1 AND starred) AS starred_posts
+`
+
+type GetUserStatsRow struct {
+	FeedsAdded   int64
+	Follows      int64
+	ReadPosts    int64
+	StarredPosts int64
+}
+
+func (q *Queries) GetUserStats(ctx context.Context, userID uuid.UUID) (GetUserStatsRow, error) {
+	row := q.db.QueryRowContext(ctx, getUserStats, userID)
+	var i GetUserStatsRow
+	err := row.Scan(
+		&i.FeedsAdded,
+		&i.Follows,
+		&i.ReadPosts,
+		&i.StarredPosts,
+	)
+	return i, err
+}
+
+const renameUser = `-- name: RenameUser :one
+UPDATE users SET name = $2, updated_at = $3
+WHERE id = $1
+RETURNING id, created_at, updated_at, name
+`
+
+type RenameUserParams struct {
+	ID        uuid.UUID
+	Name      string
+	UpdatedAt time.Time
+}
+
+func (q *Queries) RenameUser(ctx context.Context, arg RenameUserParams) (User, error) {
+	row := q.db.QueryRowContext(ctx, renameUser, arg.ID, arg.Name, arg.UpdatedAt)
 	var i User
 	err := row.Scan(
 		&i.ID,
@@ -0,0 +1,142 @@
+package download
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+const partialSuffix = ".part"
+
+// File downloads fileURL into dir and returns the path of the finished file.
+// Bytes are written to a ".part" file first, so an interrupted download is
+// resumed with a Range request the next time File is called for the same URL.
+// fallbackName is used when the URL path doesn't end in a usable file name.
+func File(ctx context.Context, fileURL, dir, fallbackName string) (string, error) {
+	name := fileName(fileURL, fallbackName)
+	dest := filepath.Join(dir, name)
+	if _, err := os.Stat(dest); err == nil {
+		return dest, nil
+	}
+
+	err := os.MkdirAll(dir, 0755)
+	if err != nil {
+		return "", err
+	}
+
+	partial := dest + partialSuffix
+	file, err := os.OpenFile(partial, os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+
+	info, err := file.Stat()
+	if err != nil {
+		return "", err
+	}
+	offset := info.Size()
+
+	req, err := http.NewRequestWithContext(ctx, "GET", fileURL, nil)
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("user-agent", "go-gator")
+	if offset > 0 {
+		req.Header.Set("range", fmt.Sprintf("bytes=%d-", offset))
+	}
+
+	var client http.Client
+	res, err := client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer res.Body.Close()
+
+	switch res.StatusCode {
+	case http.StatusOK:
+		// The server ignored the range (or there was nothing to resume), so
+		// start over from the first byte.
+		offset = 0
+	case http.StatusPartialContent:
+		start, err := rangeStart(res.Header.Get("content-range"))
+		if err != nil {
+			return "", fmt.Errorf("error resuming %s: %w", fileURL, err)
+		}
+		if start != offset {
+			return "", fmt.Errorf("error resuming %s: asked for byte %d onwards, got %d onwards", fileURL, offset, start)
+		}
+	case http.StatusRequestedRangeNotSatisfiable:
+		// The partial file already holds every byte the server has.
+		if offset == 0 {
+			return "", fmt.Errorf("unexpected status downloading %s: %s", fileURL, res.Status)
+		}
+		return dest, finish(file, partial, dest)
+	default:
+		return "", fmt.Errorf("unexpected status downloading %s: %s", fileURL, res.Status)
+	}
+
+	err = file.Truncate(offset)
+	if err != nil {
+		return "", err
+	}
+	_, err = file.Seek(offset, io.SeekStart)
+	if err != nil {
+		return "", err
+	}
+	_, err = io.Copy(file, res.Body)
+	if err != nil {
+		return "", err
+	}
+	return dest, finish(file, partial, dest)
+}
+
+func finish(file *os.File, partial, dest string) error {
+	err := file.Close()
+	if err != nil && !errors.Is(err, os.ErrClosed) {
+		return err
+	}
+	return os.Rename(partial, dest)
+}
+
+// rangeStart returns the first byte position of a Content-Range header such
+// as "bytes 100-199/200".
+func rangeStart(header string) (int64, error) {
+	var start int64
+	_, err := fmt.Sscanf(header, "bytes %d-", &start)
+	if err != nil {
+		return 0, fmt.Errorf("bad content-range %q", header)
+	}
+	return start, nil
+}
+
+func fileName(fileURL, fallback string) string {
+	u, err := url.Parse(fileURL)
+	if err != nil {
+		return fallback
+	}
+	name := path.Base(u.Path)
+	if name == "/" || name == "." {
+		return fallback
+	}
+	if unescaped, err := url.PathUnescape(name); err == nil {
+		name = unescaped
+	}
+	name = strings.Map(func(r rune) rune {
+		if r == '/' || r == '\\' || r < ' ' {
+			return '_'
+		}
+		return r
+	}, name)
+	if name == "" || name == "." || name == ".." {
+		return fallback
+	}
+	return name
+}
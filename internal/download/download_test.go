@@ -0,0 +1,128 @@
+package download
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const episode = "0123456789abcdefghijklmnopqrstuvwxyz"
+
+func rangeServer(t *testing.T) *httptest.Server {
+	t.Helper()
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := 0
+		if header := r.Header.Get("range"); header != "" {
+			_, err := fmt.Sscanf(header, "bytes=%d-", &start)
+			if err != nil {
+				t.Errorf("bad range header %q", header)
+			}
+			if start >= len(episode) {
+				w.WriteHeader(http.StatusRequestedRangeNotSatisfiable)
+				return
+			}
+			w.Header().Set("content-range", fmt.Sprintf("bytes %d-%d/%d", start, len(episode)-1, len(episode)))
+			w.WriteHeader(http.StatusPartialContent)
+		}
+		w.Write([]byte(episode[start:]))
+	}))
+}
+
+func TestFileResumesPartialDownload(t *testing.T) {
+	server := rangeServer(t)
+	defer server.Close()
+	dir := t.TempDir()
+
+	partial := filepath.Join(dir, "episode.mp3"+partialSuffix)
+	err := os.WriteFile(partial, []byte(episode[:10]), 0644)
+	if err != nil {
+		t.Fatalf("error writing partial file: %v", err)
+	}
+
+	path, err := File(context.Background(), server.URL+"/audio/episode.mp3", dir, "fallback")
+	if err != nil {
+		t.Fatalf("error downloading: %v", err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("error reading download: %v", err)
+	}
+	if string(data) != episode {
+		t.Fatalf("expected %q, got %q", episode, data)
+	}
+	if _, err := os.Stat(partial); !os.IsNotExist(err) {
+		t.Fatalf("expected partial file to be removed, got %v", err)
+	}
+}
+
+func TestFileCompletesWhenPartialIsWhole(t *testing.T) {
+	server := rangeServer(t)
+	defer server.Close()
+	dir := t.TempDir()
+
+	err := os.WriteFile(filepath.Join(dir, "episode.mp3"+partialSuffix), []byte(episode), 0644)
+	if err != nil {
+		t.Fatalf("error writing partial file: %v", err)
+	}
+
+	path, err := File(context.Background(), server.URL+"/episode.mp3", dir, "fallback")
+	if err != nil {
+		t.Fatalf("error downloading: %v", err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("error reading download: %v", err)
+	}
+	if string(data) != episode {
+		t.Fatalf("expected %q, got %q", episode, data)
+	}
+}
+
+func TestFileRejectsMisplacedRange(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// Resumes from the start whatever was asked for.
+		w.Header().Set("content-range", fmt.Sprintf("bytes 0-%d/%d", len(episode)-1, len(episode)))
+		w.WriteHeader(http.StatusPartialContent)
+		w.Write([]byte(episode))
+	}))
+	defer server.Close()
+	dir := t.TempDir()
+
+	partial := filepath.Join(dir, "episode.mp3"+partialSuffix)
+	err := os.WriteFile(partial, []byte(episode[:10]), 0644)
+	if err != nil {
+		t.Fatalf("error writing partial file: %v", err)
+	}
+	_, err = File(context.Background(), server.URL+"/episode.mp3", dir, "fallback")
+	if err == nil || !strings.Contains(err.Error(), "byte 10") {
+		t.Fatalf("expected a mismatched range to be refused, got %v", err)
+	}
+	data, err := os.ReadFile(partial)
+	if err != nil || string(data) != episode[:10] {
+		t.Fatalf("expected the partial file to be untouched, got %q (%v)", data, err)
+	}
+}
+
+func TestFileNameFallsBack(t *testing.T) {
+	cases := map[string]string{
+		"https://example.com/shows/ep%201.mp3?token=abc": "ep 1.mp3",
+		"https://example.com/":                           "fallback",
+		"https://example.com":                            "fallback",
+		"https://example.com/%2E":                        "fallback",
+		"https://example.com/%2E%2E":                     "fallback",
+	}
+	for input, expected := range cases {
+		actual := fileName(input, "fallback")
+		if actual != expected {
+			t.Errorf("%s: expected %q, got %q", input, expected, actual)
+		}
+	}
+	if strings.ContainsRune(fileName("https://example.com/a%2Fb.mp3", "x"), '/') {
+		t.Errorf("expected escaped slashes to be replaced")
+	}
+}
@@ -0,0 +1,164 @@
+// Package migrate applies gator's embedded schema migrations.
+package migrate
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"io/fs"
+	"path"
+	"time"
+
+	"github.com/WagnerJust/go-gator/internal/storage"
+	"github.com/WagnerJust/go-gator/sql/schema"
+	sqliteschema "github.com/WagnerJust/go-gator/sql/sqlite/schema"
+	"github.com/pressly/goose/v3"
+)
+
+// Applied describes one migration that was run.
+type Applied struct {
+	Version   int64
+	Name      string
+	Direction string
+	Duration  time.Duration
+}
+
+// Status describes one migration and whether the database has it.
+type Status struct {
+	Version int64
+	Name    string
+	// AppliedAt is zero for pending migrations.
+	AppliedAt time.Time
+}
+
+// BehindError means the database is missing migrations this build has.
+type BehindError struct {
+	Current int64
+	Latest  int64
+}
+
+func (e *BehindError) Error() string {
+	return fmt.Sprintf("database schema is at version %d, but version %d is needed", e.Current, e.Latest)
+}
+
+// AheadError means the database was migrated by a newer build.
+type AheadError struct {
+	Current int64
+	Latest  int64
+}
+
+func (e *AheadError) Error() string {
+	return fmt.Sprintf("database schema is at version %d, newer than the %d this build knows", e.Current, e.Latest)
+}
+
+// Migrator runs the embedded migrations against one database. It uses
+// goose's version table, so databases set up with the goose CLI carry on
+// where they left off.
+type Migrator struct {
+	provider *goose.Provider
+}
+
+// New returns a Migrator for db, opened with the storage driver named by
+// driver. Each driver has its own copy of the migrations.
+func New(db *sql.DB, driver string) (*Migrator, error) {
+	dialect, migrations := goose.DialectPostgres, fs.FS(schema.Migrations)
+	if driver == storage.SQLite {
+		dialect, migrations = goose.DialectSQLite3, sqliteschema.Migrations
+	}
+	provider, err := goose.NewProvider(dialect, db, migrations)
+	if err != nil {
+		return nil, err
+	}
+	return &Migrator{provider: provider}, nil
+}
+
+// Latest is the newest migration version this build has.
+func (m *Migrator) Latest() int64 {
+	sources := m.provider.ListSources()
+	if len(sources) == 0 {
+		return 0
+	}
+	return sources[len(sources)-1].Version
+}
+
+// Check returns a *BehindError or *AheadError when the database schema
+// doesn't match this build.
+func (m *Migrator) Check(ctx context.Context) error {
+	current, err := m.provider.GetDBVersion(ctx)
+	if err != nil {
+		return err
+	}
+	latest := m.Latest()
+	if current > latest {
+		return &AheadError{Current: current, Latest: latest}
+	}
+	pending, err := m.provider.HasPending(ctx)
+	if err != nil {
+		return err
+	}
+	if pending {
+		return &BehindError{Current: current, Latest: latest}
+	}
+	return nil
+}
+
+// Up applies every pending migration.
+func (m *Migrator) Up(ctx context.Context) ([]Applied, error) {
+	results, err := m.provider.Up(ctx)
+	return applied(results), err
+}
+
+// Down rolls back the most recent migration.
+func (m *Migrator) Down(ctx context.Context) (Applied, error) {
+	result, err := m.provider.Down(ctx)
+	if err != nil {
+		return Applied{}, err
+	}
+	return applied([]*goose.MigrationResult{result})[0], nil
+}
+
+// Redo rolls back the most recent migration and applies it again.
+func (m *Migrator) Redo(ctx context.Context) ([]Applied, error) {
+	down, err := m.Down(ctx)
+	if err != nil {
+		return nil, err
+	}
+	up, err := m.provider.UpByOne(ctx)
+	if err != nil {
+		return []Applied{down}, err
+	}
+	return append([]Applied{down}, applied([]*goose.MigrationResult{up})...), nil
+}
+
+// Status lists every migration, oldest first.
+func (m *Migrator) Status(ctx context.Context) ([]Status, error) {
+	results, err := m.provider.Status(ctx)
+	if err != nil {
+		return nil, err
+	}
+	statuses := make([]Status, 0, len(results))
+	for _, result := range results {
+		status := Status{Version: result.Source.Version, Name: path.Base(result.Source.Path)}
+		if result.State == goose.StateApplied {
+			status.AppliedAt = result.AppliedAt
+		}
+		statuses = append(statuses, status)
+	}
+	return statuses, nil
+}
+
+func applied(results []*goose.MigrationResult) []Applied {
+	list := make([]Applied, 0, len(results))
+	for _, result := range results {
+		if result == nil || result.Source == nil {
+			continue
+		}
+		list = append(list, Applied{
+			Version:   result.Source.Version,
+			Name:      path.Base(result.Source.Path),
+			Direction: result.Direction,
+			Duration:  result.Duration,
+		})
+	}
+	return list
+}
@@ -0,0 +1,116 @@
+package migrate
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/WagnerJust/go-gator/internal/storage"
+)
+
+func TestEmbeddedMigrations(t *testing.T) {
+	// Listing the embedded migrations doesn't touch the database, so a
+	// connection that was never dialed is enough.
+	db, err := sql.Open("postgres", "postgres://localhost/unused")
+	if err != nil {
+		t.Fatalf("error opening database: %v", err)
+	}
+	defer db.Close()
+
+	migrator, err := New(db, storage.Postgres)
+	if err != nil {
+		t.Fatalf("error loading migrations: %v", err)
+	}
+	sources := migrator.provider.ListSources()
+	if len(sources) == 0 {
+		t.Fatalf("expected embedded migrations")
+	}
+	for i, source := range sources {
+		if source.Version != int64(i+1) {
+			t.Fatalf("expected migration %d to have version %d, got %d (%s)", i, i+1, source.Version, source.Path)
+		}
+	}
+	if migrator.Latest() != int64(len(sources)) {
+		t.Fatalf("expected latest version %d, got %d", len(sources), migrator.Latest())
+	}
+}
+
+func TestSQLiteMigrations(t *testing.T) {
+	db, err := storage.Open(filepath.Join(t.TempDir(), "gator.db"))
+	if err != nil {
+		t.Fatalf("error opening database: %v", err)
+	}
+	defer db.Close()
+
+	migrator, err := New(db, storage.SQLite)
+	if err != nil {
+		t.Fatalf("error loading migrations: %v", err)
+	}
+	pgDb, _ := sql.Open(storage.Postgres, "postgres://localhost/unused")
+	defer pgDb.Close()
+	pgMigrator, err := New(pgDb, storage.Postgres)
+	if err != nil {
+		t.Fatalf("error loading migrations: %v", err)
+	}
+	if migrator.Latest() != pgMigrator.Latest() {
+		t.Fatalf("expected the SQLite migrations to match Postgres' %d, got %d", pgMigrator.Latest(), migrator.Latest())
+	}
+
+	ctx := context.Background()
+	var behind *BehindError
+	if err := migrator.Check(ctx); !errors.As(err, &behind) {
+		t.Fatalf("expected a new database to be behind, got %v", err)
+	}
+	_, err = migrator.Up(ctx)
+	if err != nil {
+		t.Fatalf("error migrating up: %v", err)
+	}
+	if err := migrator.Check(ctx); err != nil {
+		t.Fatalf("expected the schema to be current, got %v", err)
+	}
+	for range migrator.Latest() {
+		_, err = migrator.Down(ctx)
+		if err != nil {
+			t.Fatalf("error migrating down: %v", err)
+		}
+	}
+}
+
+func TestSQLiteTimesMoveToUTC(t *testing.T) {
+	db, err := storage.Open(filepath.Join(t.TempDir(), "gator.db"))
+	if err != nil {
+		t.Fatalf("error opening database: %v", err)
+	}
+	defer db.Close()
+	migrator, err := New(db, storage.SQLite)
+	if err != nil {
+		t.Fatalf("error loading migrations: %v", err)
+	}
+	ctx := context.Background()
+	_, err = migrator.provider.UpTo(ctx, 8)
+	if err != nil {
+		t.Fatalf("error migrating to version 8: %v", err)
+	}
+	_, err = db.ExecContext(ctx, `INSERT INTO users (id, created_at, updated_at, name)
+		VALUES ('6ba7b810-9dad-11d1-80b4-00c04fd430c8', '2006-07-07 22:00:00.5-04:00', CURRENT_TIMESTAMP, 'shawn')`)
+	if err != nil {
+		t.Fatalf("error inserting user: %v", err)
+	}
+	_, err = migrator.Up(ctx)
+	if err != nil {
+		t.Fatalf("error migrating up: %v", err)
+	}
+	var createdAt string
+	err = db.QueryRowContext(ctx, "SELECT CAST(created_at AS TEXT) FROM users").Scan(&createdAt)
+	if err != nil || createdAt != "2006-07-08 02:00:00.500+00:00" {
+		t.Fatalf("expected the time in UTC, got %q (%v)", createdAt, err)
+	}
+	var created time.Time
+	err = db.QueryRowContext(ctx, "SELECT created_at FROM users").Scan(&created)
+	if err != nil || !created.Equal(time.Date(2006, 7, 8, 2, 0, 0, 5e8, time.UTC)) {
+		t.Fatalf("expected the driver to read the UTC time back, got %v (%v)", created, err)
+	}
+}
@@ -0,0 +1,190 @@
+package output
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"reflect"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Format is how listing commands print their results.
+type Format string
+
+const (
+	// Text is each command's own human-readable output.
+	Text  Format = "text"
+	JSON  Format = "json"
+	YAML  Format = "yaml"
+	CSV   Format = "csv"
+	Table Format = "table"
+)
+
+// Formats lists every supported format, in the order help text shows them.
+var Formats = []Format{Text, JSON, YAML, CSV, Table}
+
+// ParseFormat validates a --output value. An empty value means Text.
+func ParseFormat(value string) (Format, error) {
+	if value == "" {
+		return Text, nil
+	}
+	for _, format := range Formats {
+		if strings.EqualFold(value, string(format)) {
+			return format, nil
+		}
+	}
+	names := make([]string, len(Formats))
+	for i, format := range Formats {
+		names[i] = string(format)
+	}
+	return "", fmt.Errorf("unknown output format %q (expected one of %s)", value, strings.Join(names, ", "))
+}
+
+// Write prints records, a struct or a slice of structs, in the given format.
+// Field names come from the json struct tags so every format uses the same,
+// stable names. Text isn't handled here; commands print that themselves.
+func Write(w io.Writer, format Format, records any) error {
+	switch format {
+	case JSON:
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(records)
+	case YAML:
+		// Go through JSON so YAML keys match the json tags and keep their
+		// order; YAML is a superset of JSON, so the node tree parses as is.
+		data, err := json.Marshal(records)
+		if err != nil {
+			return err
+		}
+		var node yaml.Node
+		err = yaml.Unmarshal(data, &node)
+		if err != nil {
+			return err
+		}
+		blockStyle(&node)
+		encoder := yaml.NewEncoder(w)
+		encoder.SetIndent(2)
+		err = encoder.Encode(&node)
+		if err != nil {
+			return err
+		}
+		return encoder.Close()
+	case CSV:
+		header, rows := tabulate(records)
+		writer := csv.NewWriter(w)
+		err := writer.Write(header)
+		if err != nil {
+			return err
+		}
+		err = writer.WriteAll(rows)
+		if err != nil {
+			return err
+		}
+		return writer.Error()
+	case Table:
+		header, rows := tabulate(records)
+		writer := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+		for i := range header {
+			header[i] = strings.ToUpper(header[i])
+		}
+		fmt.Fprintln(writer, strings.Join(header, "\t"))
+		for _, row := range rows {
+			fmt.Fprintln(writer, strings.Join(row, "\t"))
+		}
+		return writer.Flush()
+	}
+	return fmt.Errorf("output format %q can't be written generically", format)
+}
+
+// blockStyle drops the flow style and quoting the JSON source left on node.
+func blockStyle(node *yaml.Node) {
+	node.Style = 0
+	for _, child := range node.Content {
+		blockStyle(child)
+	}
+}
+
+type column struct {
+	name  string
+	index int
+}
+
+// tabulate flattens records into a header and string rows for CSV and table
+// output.
+func tabulate(records any) ([]string, [][]string) {
+	value := reflect.ValueOf(records)
+	if value.Kind() != reflect.Slice {
+		single := reflect.MakeSlice(reflect.SliceOf(value.Type()), 1, 1)
+		single.Index(0).Set(value)
+		value = single
+	}
+	columns := columnsOf(value.Type().Elem())
+
+	header := make([]string, len(columns))
+	for i, c := range columns {
+		header[i] = c.name
+	}
+	rows := make([][]string, value.Len())
+	for i := range rows {
+		record := reflect.Indirect(value.Index(i))
+		row := make([]string, len(columns))
+		for j, c := range columns {
+			row[j] = formatValue(record.Field(c.index))
+		}
+		rows[i] = row
+	}
+	return header, rows
+}
+
+func columnsOf(t reflect.Type) []column {
+	if t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	var columns []column
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if name == "-" {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+		columns = append(columns, column{name: name, index: i})
+	}
+	return columns
+}
+
+func formatValue(value reflect.Value) string {
+	if value.Kind() == reflect.Pointer || value.Kind() == reflect.Interface {
+		if value.IsNil() {
+			return ""
+		}
+		value = value.Elem()
+	}
+	switch v := value.Interface().(type) {
+	case time.Time:
+		if v.IsZero() {
+			return ""
+		}
+		return v.Format(time.RFC3339)
+	case fmt.Stringer:
+		return v.String()
+	}
+	if value.Kind() == reflect.Slice && value.Type().Elem().Kind() != reflect.Uint8 {
+		parts := make([]string, value.Len())
+		for i := range parts {
+			parts[i] = formatValue(value.Index(i))
+		}
+		return strings.Join(parts, " ")
+	}
+	return fmt.Sprint(value.Interface())
+}
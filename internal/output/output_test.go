@@ -0,0 +1,103 @@
+package output
+
+import (
+	"bytes"
+	"testing"
+	"time"
+)
+
+type record struct {
+	Name      string    `json:"name"`
+	CreatedAt time.Time `json:"created_at"`
+	Tags      []string  `json:"tags"`
+	Note      *string   `json:"note"`
+	Hidden    string    `json:"-"`
+}
+
+func records() []record {
+	created := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	note := "yes, really"
+	return []record{
+		{Name: "alice", CreatedAt: created, Tags: []string{"a", "b"}, Note: &note, Hidden: "x"},
+		{Name: "bob", CreatedAt: created},
+	}
+}
+
+func TestWrite(t *testing.T) {
+	cases := map[Format]string{
+		JSON: `[
+  {
+    "name": "alice",
+    "created_at": "2024-05-01T12:00:00Z",
+    "tags": [
+      "a",
+      "b"
+    ],
+    "note": "yes, really"
+  },
+  {
+    "name": "bob",
+    "created_at": "2024-05-01T12:00:00Z",
+    "tags": null,
+    "note": null
+  }
+]
+`,
+		YAML: `- name: alice
+  created_at: "2024-05-01T12:00:00Z"
+  tags:
+    - a
+    - b
+  note: yes, really
+- name: bob
+  created_at: "2024-05-01T12:00:00Z"
+  tags: null
+  note: null
+`,
+		CSV: `name,created_at,tags,note
+alice,2024-05-01T12:00:00Z,a b,"yes, really"
+bob,2024-05-01T12:00:00Z,,
+`,
+		Table: `NAME   CREATED_AT            TAGS  NOTE
+alice  2024-05-01T12:00:00Z  a b   yes, really
+bob    2024-05-01T12:00:00Z        
+`,
+	}
+	for format, expected := range cases {
+		var b bytes.Buffer
+		err := Write(&b, format, records())
+		if err != nil {
+			t.Fatalf("%s: error writing: %v", format, err)
+		}
+		if b.String() != expected {
+			t.Errorf("%s: expected:\n%s\ngot:\n%s", format, expected, b.String())
+		}
+	}
+}
+
+func TestWriteSingleRecordAsTable(t *testing.T) {
+	var b bytes.Buffer
+	err := Write(&b, CSV, records()[1])
+	if err != nil {
+		t.Fatalf("error writing: %v", err)
+	}
+	expected := "name,created_at,tags,note\nbob,2024-05-01T12:00:00Z,,\n"
+	if b.String() != expected {
+		t.Fatalf("expected %q, got %q", expected, b.String())
+	}
+}
+
+func TestParseFormat(t *testing.T) {
+	format, err := ParseFormat("JSON")
+	if err != nil || format != JSON {
+		t.Fatalf("expected json, got %q (%v)", format, err)
+	}
+	format, err = ParseFormat("")
+	if err != nil || format != Text {
+		t.Fatalf("expected text, got %q (%v)", format, err)
+	}
+	_, err = ParseFormat("xml")
+	if err == nil {
+		t.Fatalf("expected an error for xml")
+	}
+}
@@ -0,0 +1,376 @@
+package render
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/WagnerJust/go-gator/internal/sanitize"
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// Options controls how HTML is laid out for a terminal.
+type Options struct {
+	// Width is the column text is wrapped at; zero or less disables wrapping.
+	Width int
+	// Color enables ANSI bold/italic/dim styling.
+	Color bool
+}
+
+const (
+	ansiBold   = "\x1b[1m"
+	ansiItalic = "\x1b[3m"
+	ansiDim    = "\x1b[2m"
+	ansiCyan   = "\x1b[36m"
+	ansiReset  = "\x1b[0m"
+)
+
+var ansiRegex = regexp.MustCompile(`\x1b\[[0-9;]*m`)
+
+// Bold returns s in bold when color is enabled.
+func (o Options) Bold(s string) string {
+	return o.style(ansiBold, s)
+}
+
+// Title returns s styled as a post title.
+func (o Options) Title(s string) string {
+	return o.style(ansiBold+ansiCyan, s)
+}
+
+// Dim returns s in a faint style when color is enabled.
+func (o Options) Dim(s string) string {
+	return o.style(ansiDim, s)
+}
+
+// Rule returns a horizontal line as wide as the output.
+func (o Options) Rule() string {
+	width := o.Width
+	if width <= 0 || width > 80 {
+		width = 80
+	}
+	return o.Dim(strings.Repeat("─", width))
+}
+
+func (o Options) style(code, s string) string {
+	if !o.Color || s == "" {
+		return s
+	}
+	return code + s + ansiReset
+}
+
+// HTML converts an HTML fragment into wrapped terminal text: paragraphs are
+// separated by blank lines, lists get bullets or numbers, blockquotes a bar,
+// <pre> blocks are kept verbatim and indented, and links are replaced by
+// numbered footnotes listed at the end.
+func HTML(s string, opts Options) string {
+	nodes, err := html.ParseFragment(strings.NewReader(s), &html.Node{
+		Type:     html.ElementNode,
+		Data:     "body",
+		DataAtom: atom.Body,
+	})
+	if err != nil {
+		return s
+	}
+
+	c := &converter{opts: opts}
+	for _, node := range nodes {
+		c.walk(node)
+	}
+	c.flushLine()
+
+	if len(c.links) > 0 {
+		c.lines = append(c.lines, "")
+		for i, link := range c.links {
+			c.lines = append(c.lines, opts.Dim(fmt.Sprintf("[%d] %s", i+1, link)))
+		}
+	}
+	return strings.Join(c.lines, "\n")
+}
+
+type list struct {
+	ordered bool
+	next    int
+}
+
+type converter struct {
+	opts      Options
+	lines     []string
+	para      strings.Builder
+	indent    string
+	marker    string
+	needBlank bool
+	blank     string
+	lists     []list
+	links     []string
+}
+
+func (c *converter) walk(n *html.Node) {
+	switch n.Type {
+	case html.TextNode:
+		c.para.WriteString(n.Data)
+	case html.ElementNode:
+		c.element(n)
+	default:
+		c.children(n)
+	}
+}
+
+func (c *converter) children(n *html.Node) {
+	for child := n.FirstChild; child != nil; child = child.NextSibling {
+		c.walk(child)
+	}
+}
+
+func (c *converter) element(n *html.Node) {
+	if sanitize.Dropped(n.Data) {
+		return
+	}
+	switch n.Data {
+	case "br":
+		c.flushLine()
+	case "hr":
+		c.block()
+		c.emit(c.opts.Rule())
+		c.block()
+	case "h1", "h2", "h3", "h4", "h5", "h6":
+		c.block()
+		c.styled(ansiBold, n)
+		c.block()
+	case "b", "strong":
+		c.styled(ansiBold, n)
+	case "i", "em":
+		c.styled(ansiItalic, n)
+	case "ul", "ol":
+		if len(c.lists) > 0 {
+			c.flushLine()
+		} else {
+			c.block()
+		}
+		l := list{ordered: n.Data == "ol", next: 1}
+		if start := attr(n, "start"); start != "" {
+			fmt.Sscanf(start, "%d", &l.next)
+		}
+		c.lists = append(c.lists, l)
+		c.children(n)
+		c.lists = c.lists[:len(c.lists)-1]
+		if len(c.lists) > 0 {
+			c.flushLine()
+		} else {
+			c.block()
+		}
+	case "li":
+		c.flushLine()
+		marker := "• "
+		if len(c.lists) > 0 {
+			l := &c.lists[len(c.lists)-1]
+			if l.ordered {
+				marker = fmt.Sprintf("%d. ", l.next)
+				l.next++
+			}
+		}
+		previous := c.indent
+		c.indent += strings.Repeat(" ", utf8.RuneCountInString(marker))
+		c.marker = marker
+		c.children(n)
+		c.flushLine()
+		c.marker = ""
+		c.indent = previous
+	case "blockquote":
+		c.block()
+		previous := c.indent
+		c.indent += "│ "
+		c.children(n)
+		c.flushLine()
+		c.indent = previous
+		c.block()
+	case "pre":
+		c.block()
+		text := strings.TrimRight(textContent(n), "\n")
+		for _, line := range strings.Split(text, "\n") {
+			c.emit(c.indent + "    " + c.opts.Dim(line))
+		}
+		c.block()
+	case "a":
+		before := c.para.Len()
+		c.children(n)
+		href := attr(n, "href")
+		text := strings.TrimSpace(c.para.String()[before:])
+		if href != "" && href != text {
+			c.links = append(c.links, href)
+			c.para.WriteString(fmt.Sprintf("[%d]", len(c.links)))
+		}
+	case "img":
+		if alt := strings.TrimSpace(attr(n, "alt")); alt != "" {
+			c.para.WriteString(" [image: " + alt + "] ")
+		} else {
+			c.para.WriteString(" [image] ")
+		}
+	case "td", "th":
+		c.para.WriteString(" ")
+		c.children(n)
+		c.para.WriteString(" ")
+	case "p", "div", "section", "article", "header", "footer", "figure", "figcaption",
+		"table", "tr", "dl", "dt", "dd", "caption":
+		c.block()
+		c.children(n)
+		c.block()
+	default:
+		c.children(n)
+	}
+}
+
+func (c *converter) styled(code string, n *html.Node) {
+	if c.opts.Color {
+		c.para.WriteString(code)
+	}
+	c.children(n)
+	if c.opts.Color {
+		c.para.WriteString(ansiReset)
+	}
+}
+
+// block ends the current paragraph and asks for a blank line before whatever
+// is written next.
+func (c *converter) block() {
+	c.flushLine()
+	if len(c.lines) == 0 {
+		return
+	}
+	// Nested blocks can ask for the same blank line at different depths;
+	// the outermost one decides what it looks like.
+	blank := strings.TrimRight(c.indent, " ")
+	if !c.needBlank || len(blank) < len(c.blank) {
+		c.blank = blank
+	}
+	c.needBlank = true
+}
+
+// flushLine wraps and writes out any pending inline text.
+func (c *converter) flushLine() {
+	text := c.para.String()
+	c.para.Reset()
+	if strings.TrimSpace(ansiRegex.ReplaceAllString(text, "")) == "" {
+		return
+	}
+
+	width := c.opts.Width - utf8.RuneCountInString(c.indent)
+	for i, line := range wrap(text, width) {
+		prefix := c.indent
+		if i == 0 && c.marker != "" {
+			prefix = strings.TrimSuffix(c.indent, strings.Repeat(" ", utf8.RuneCountInString(c.marker))) + c.marker
+			c.marker = ""
+		}
+		c.emit(prefix + line)
+	}
+}
+
+func (c *converter) emit(line string) {
+	if c.needBlank {
+		c.lines = append(c.lines, c.blank)
+		c.needBlank = false
+	}
+	c.lines = append(c.lines, line)
+}
+
+// wrap splits text into lines of at most width visible characters, breaking
+// only between words. ANSI escapes don't count towards the width.
+func wrap(text string, width int) []string {
+	words := strings.Fields(text)
+	if width <= 0 {
+		return []string{strings.Join(words, " ")}
+	}
+	var lines []string
+	var line strings.Builder
+	lineWidth := 0
+	pending := ""
+	for _, word := range words {
+		wordWidth := VisibleWidth(word)
+		if wordWidth == 0 {
+			// A style escape separated from its text by a space; glue it
+			// to the next word so it doesn't count as one.
+			pending += word
+			continue
+		}
+		word = pending + word
+		pending = ""
+		if lineWidth > 0 && lineWidth+1+wordWidth > width {
+			lines = append(lines, line.String())
+			line.Reset()
+			lineWidth = 0
+		}
+		if lineWidth > 0 {
+			line.WriteString(" ")
+			lineWidth++
+		}
+		line.WriteString(word)
+		lineWidth += wordWidth
+	}
+	line.WriteString(pending)
+	if line.Len() > 0 {
+		lines = append(lines, line.String())
+	}
+	return lines
+}
+
+// VisibleWidth is the number of characters s takes up on screen, not counting
+// ANSI escapes.
+func VisibleWidth(s string) int {
+	return utf8.RuneCountInString(ansiRegex.ReplaceAllString(s, ""))
+}
+
+// Truncate cuts s down to at most width visible characters, keeping ANSI
+// escapes intact, and pads it with spaces to exactly width.
+func Truncate(s string, width int) string {
+	if width <= 0 {
+		return ""
+	}
+	var b strings.Builder
+	visible := 0
+	styled := false
+	for len(s) > 0 && visible < width {
+		if loc := ansiRegex.FindStringIndex(s); loc != nil && loc[0] == 0 {
+			b.WriteString(s[:loc[1]])
+			styled = true
+			s = s[loc[1]:]
+			continue
+		}
+		r, size := utf8.DecodeRuneInString(s)
+		if r == '\t' {
+			r = ' '
+		}
+		b.WriteRune(r)
+		visible++
+		s = s[size:]
+	}
+	if styled {
+		b.WriteString(ansiReset)
+	}
+	b.WriteString(strings.Repeat(" ", width-visible))
+	return b.String()
+}
+
+func attr(n *html.Node, key string) string {
+	for _, a := range n.Attr {
+		if a.Key == key {
+			return a.Val
+		}
+	}
+	return ""
+}
+
+func textContent(n *html.Node) string {
+	if n.Type == html.TextNode {
+		return n.Data
+	}
+	var b strings.Builder
+	for child := n.FirstChild; child != nil; child = child.NextSibling {
+		if child.Type == html.ElementNode && child.Data == "br" {
+			b.WriteString("\n")
+			continue
+		}
+		b.WriteString(textContent(child))
+	}
+	return b.String()
+}
@@ -0,0 +1,78 @@
+package render
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestHTML(t *testing.T) {
+	input := `<h2>Heading</h2>` +
+		`<p>A fairly long paragraph that should wrap onto a second line at thirty columns.</p>` +
+		`<ul><li>first</li><li>second <a href="https://example.com/x">link</a></li></ul>` +
+		`<ol start="3"><li>third</li></ol>` +
+		`<blockquote><p>quoted</p><p>text</p></blockquote>` +
+		`<pre>func main() {
+	fmt.Println("hi")
+}</pre>` +
+		`<p>see <a href="https://example.com/">https://example.com/</a></p>`
+
+	expected := strings.Join([]string{
+		"Heading",
+		"",
+		"A fairly long paragraph that",
+		"should wrap onto a second line",
+		"at thirty columns.",
+		"",
+		"• first",
+		"• second link[1]",
+		"",
+		"3. third",
+		"",
+		"│ quoted",
+		"│",
+		"│ text",
+		"",
+		"    func main() {",
+		"    \tfmt.Println(\"hi\")",
+		"    }",
+		"",
+		"see https://example.com/",
+		"",
+		"[1] https://example.com/x",
+	}, "\n")
+
+	actual := HTML(input, Options{Width: 30})
+	if actual != expected {
+		t.Fatalf("expected:\n%s\ngot:\n%s", expected, actual)
+	}
+}
+
+func TestHTMLColor(t *testing.T) {
+	actual := HTML(`<p>plain <b>bold</b></p>`, Options{Width: 80, Color: true})
+	expected := "plain " + ansiBold + "bold" + ansiReset
+	if actual != expected {
+		t.Fatalf("expected %q, got %q", expected, actual)
+	}
+}
+
+func TestWrapIgnoresEscapes(t *testing.T) {
+	lines := wrap(ansiBold+"aaaa"+ansiReset+" bbbb cccc", 9)
+	if len(lines) != 2 || VisibleWidth(lines[0]) != 9 {
+		t.Fatalf("unexpected wrap %q", lines)
+	}
+}
+
+func TestTruncate(t *testing.T) {
+	cases := map[string]string{
+		"short":                                 "short     ",
+		"exactly ten":                           "exactly te",
+		ansiBold + "bold text here" + ansiReset: ansiBold + "bold text " + ansiReset,
+		"naïve café":                            "naïve café",
+	}
+	for input, expected := range cases {
+		actual := Truncate(input, 10)
+		if actual != expected {
+			t.Errorf("%q: expected %q, got %q", input, expected, actual)
+		}
+	}
+}
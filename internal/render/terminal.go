@@ -0,0 +1,88 @@
+package render
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+
+	"golang.org/x/term"
+)
+
+const defaultWidth = 80
+
+// Terminal describes the stdout gator is writing to.
+type Terminal struct {
+	IsTTY  bool
+	Width  int
+	Height int
+}
+
+// Stdout inspects os.Stdout. When it isn't a terminal, Width falls back to
+// $COLUMNS or 80 and Height is zero.
+func Stdout() Terminal {
+	fd := int(os.Stdout.Fd())
+	t := Terminal{Width: defaultWidth}
+	if columns, err := strconv.Atoi(os.Getenv("COLUMNS")); err == nil && columns > 0 {
+		t.Width = columns
+	}
+	if !term.IsTerminal(fd) {
+		return t
+	}
+	t.IsTTY = true
+	width, height, err := term.GetSize(fd)
+	if err == nil {
+		t.Width = width
+		t.Height = height
+	}
+	return t
+}
+
+// Options returns rendering options suited to the terminal. Color is only
+// used on a TTY and can be turned off with NO_COLOR.
+func (t Terminal) Options() Options {
+	return Options{
+		Width: t.Width,
+		Color: t.IsTTY && os.Getenv("NO_COLOR") == "",
+	}
+}
+
+// Page writes text to stdout. On a terminal, output taller than the screen
+// is piped through $PAGER (less by default) instead. Empty text prints
+// nothing.
+func (t Terminal) Page(text string) error {
+	if text == "" {
+		return nil
+	}
+	if !strings.HasSuffix(text, "\n") {
+		text += "\n"
+	}
+	if !t.IsTTY || t.Height <= 0 || strings.Count(text, "\n") < t.Height {
+		_, err := fmt.Fprint(os.Stdout, text)
+		return err
+	}
+
+	pager := strings.Fields(os.Getenv("PAGER"))
+	if len(pager) == 0 {
+		pager = []string{"less"}
+	}
+	cmd := exec.Command(pager[0], pager[1:]...)
+	cmd.Stdin = strings.NewReader(text)
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	cmd.Env = os.Environ()
+	if os.Getenv("LESS") == "" {
+		// Pass colors through and exit straight away if it fits after all.
+		cmd.Env = append(cmd.Env, "LESS=FRX")
+	}
+	err := cmd.Run()
+	if err != nil {
+		var notFound *exec.Error
+		if errors.As(err, &notFound) {
+			_, err = fmt.Fprint(os.Stdout, text)
+		}
+	}
+	return err
+}
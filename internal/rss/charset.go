@@ -0,0 +1,67 @@
+package rss
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"mime"
+	"regexp"
+	"strings"
+
+	"golang.org/x/net/html/charset"
+)
+
+var prologEncodingRegex = regexp.MustCompile(`^\s*<\?xml[^>]*\bencoding\s*=\s*["']([A-Za-z0-9._:-]+)["']`)
+
+// newFeedDecoder returns an xml.Decoder that yields UTF-8 whatever encoding
+// the feed was served in. An encoding declared in the XML prolog wins because
+// it's written by whoever produced the document, while servers routinely send
+// a generic charset in Content-Type; the header is only used when the prolog
+// doesn't declare anything.
+func newFeedDecoder(data []byte, contentType string) (*xml.Decoder, error) {
+	if prologEncoding(data) == "" {
+		label := contentTypeCharset(contentType)
+		if label != "" && !isUTF8(label) {
+			reader, err := charset.NewReaderLabel(label, bytes.NewReader(data))
+			if err != nil {
+				return nil, fmt.Errorf("unsupported charset %q: %w", label, err)
+			}
+			return xml.NewDecoder(reader), nil
+		}
+	}
+
+	decoder := xml.NewDecoder(bytes.NewReader(data))
+	decoder.CharsetReader = func(label string, input io.Reader) (io.Reader, error) {
+		reader, err := charset.NewReaderLabel(label, input)
+		if err != nil {
+			return nil, fmt.Errorf("unsupported charset %q: %w", label, err)
+		}
+		return reader, nil
+	}
+	return decoder, nil
+}
+
+func prologEncoding(data []byte) string {
+	match := prologEncodingRegex.FindSubmatch(data)
+	if match == nil {
+		return ""
+	}
+	return string(match[1])
+}
+
+func contentTypeCharset(contentType string) string {
+	if contentType == "" {
+		return ""
+	}
+	_, params, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return ""
+	}
+	return params["charset"]
+}
+
+func isUTF8(label string) bool {
+	label = strings.ToLower(strings.TrimSpace(label))
+	return label == "utf-8" || label == "utf8"
+}
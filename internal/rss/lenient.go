@@ -0,0 +1,123 @@
+package rss
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+)
+
+var utf8BOM = []byte{0xEF, 0xBB, 0xBF}
+
+// lenientAutoClose is xml.HTMLAutoClose without "link", which is an empty
+// element in HTML but carries the item URL in RSS.
+var lenientAutoClose = []string{
+	"basefont", "br", "area", "img", "param", "hr", "input", "col",
+	"frame", "isindex", "base", "meta", "wbr", "embed", "source",
+}
+
+// parseFeed decodes an RSS document, first strictly and then, if that fails,
+// in a tolerant mode that accepts HTML entities such as &nbsp;, bare
+// ampersands, unclosed HTML tags and stray control characters. Salvaging a
+// feed that way is recorded in RSSFeed.Warnings; the original strict error
+// is returned only when even the tolerant pass can't make sense of it.
+func parseFeed(data []byte, contentType string) (RSSFeed, error) {
+	data = bytes.TrimPrefix(data, utf8BOM)
+
+	feed, strictErr := decodeFeed(data, contentType, false)
+	if strictErr == nil {
+		return feed, nil
+	}
+
+	feed, err := decodeFeed(sanitizeXML(data), contentType, true)
+	if err != nil {
+		return RSSFeed{}, strictErr
+	}
+	feed.Warnings = append(feed.Warnings, fmt.Sprintf("feed is not well-formed XML (%v), parsed in lenient mode", strictErr))
+	return feed, nil
+}
+
+func decodeFeed(data []byte, contentType string, lenient bool) (RSSFeed, error) {
+	var feed RSSFeed
+	decoder, err := newFeedDecoder(data, contentType)
+	if err != nil {
+		return RSSFeed{}, err
+	}
+	if lenient {
+		decoder.Strict = false
+		decoder.AutoClose = lenientAutoClose
+		decoder.Entity = xml.HTMLEntity
+	}
+	err = decoder.Decode(&feed)
+	if err != nil {
+		return RSSFeed{}, err
+	}
+	return feed, nil
+}
+
+// sanitizeXML drops control characters XML doesn't allow and escapes
+// ampersands that don't start an entity or character reference. It only
+// touches ASCII bytes, so it's safe to run before the document has been
+// transcoded from whatever charset it's in.
+func sanitizeXML(data []byte) []byte {
+	out := make([]byte, 0, len(data))
+	for i := 0; i < len(data); i++ {
+		b := data[i]
+		switch {
+		case b < 0x20 && b != '\t' && b != '\n' && b != '\r', b == 0x7F:
+			continue
+		case b == '&' && !startsReference(data[i+1:]):
+			out = append(out, "&amp;"...)
+		default:
+			out = append(out, b)
+		}
+	}
+	return out
+}
+
+// startsReference reports whether rest, the text following an '&', is the
+// remainder of a reference like "amp;", "#160;" or "#xA0;".
+func startsReference(rest []byte) bool {
+	end := bytes.IndexByte(rest, ';')
+	if end <= 0 || end > 32 {
+		return false
+	}
+	name := rest[:end]
+	if name[0] == '#' {
+		digits := name[1:]
+		isDigit := isDecimal
+		if len(digits) > 0 && (digits[0] == 'x' || digits[0] == 'X') {
+			digits = digits[1:]
+			isDigit = isHex
+		}
+		if len(digits) == 0 {
+			return false
+		}
+		for _, c := range digits {
+			if !isDigit(c) {
+				return false
+			}
+		}
+		return true
+	}
+	if !isLetter(name[0]) {
+		return false
+	}
+	for _, c := range name[1:] {
+		if !isLetter(c) && !isDecimal(c) {
+			return false
+		}
+	}
+	return true
+}
+
+func isLetter(c byte) bool {
+	return ('a' <= c && c <= 'z') || ('A' <= c && c <= 'Z')
+}
+
+func isDecimal(c byte) bool {
+	return '0' <= c && c <= '9'
+}
+
+func isHex(c byte) bool {
+	return isDecimal(c) || ('a' <= c && c <= 'f') || ('A' <= c && c <= 'F')
+}
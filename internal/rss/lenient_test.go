@@ -0,0 +1,54 @@
+package rss
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseFeedRecoversMalformedXML(t *testing.T) {
+	data := "\xEF\xBB\xBF<?xml version=\"1.0\"?>\n" +
+		"<rss><channel><title>Tom &amp; Jerry&nbsp;Weekly</title>\n" +
+		"<item><title>Fish & Chips\x0b</title><link>https://example.com/?a=1&b=2</link></item>\n" +
+		"<item><title>Caf&eacute; &#233;&#xE9;</title><description><p>line<br>break</p></description></item>\n" +
+		"</channel></rss>"
+
+	feed, err := parseFeed([]byte(data), "")
+	if err != nil {
+		t.Fatalf("error parsing feed: %v", err)
+	}
+	if len(feed.Warnings) != 1 {
+		t.Fatalf("expected 1 warning, got %v", feed.Warnings)
+	}
+	if feed.Channel.Title != "Tom & Jerry\u00a0Weekly" {
+		t.Errorf("unexpected channel title %q", feed.Channel.Title)
+	}
+	if len(feed.Channel.Item) != 2 {
+		t.Fatalf("expected 2 items, got %d", len(feed.Channel.Item))
+	}
+	if feed.Channel.Item[0].Title != "Fish & Chips" {
+		t.Errorf("unexpected title %q", feed.Channel.Item[0].Title)
+	}
+	if feed.Channel.Item[0].Link != "https://example.com/?a=1&b=2" {
+		t.Errorf("unexpected link %q", feed.Channel.Item[0].Link)
+	}
+	if feed.Channel.Item[1].Title != "Café éé" {
+		t.Errorf("unexpected title %q", feed.Channel.Item[1].Title)
+	}
+}
+
+func TestParseFeedWellFormedHasNoWarnings(t *testing.T) {
+	feed, err := parseFeed([]byte("\xEF\xBB\xBF<rss><channel><title>ok</title></channel></rss>"), "")
+	if err != nil {
+		t.Fatalf("error parsing feed: %v", err)
+	}
+	if len(feed.Warnings) != 0 {
+		t.Fatalf("expected no warnings, got %v", feed.Warnings)
+	}
+}
+
+func TestParseFeedGivesUpOnGarbage(t *testing.T) {
+	_, err := parseFeed([]byte("<html><body>not found</body>"), "")
+	if err == nil || !strings.Contains(err.Error(), "XML syntax error") {
+		t.Fatalf("expected the strict syntax error, got %v", err)
+	}
+}
@@ -0,0 +1,74 @@
+package rss
+
+import (
+	"regexp"
+	"strings"
+)
+
+// Media RSS (http://search.yahoo.com/mrss/) elements used by video and photo
+// feeds to attach media and preview images to items.
+
+type MediaContent struct {
+	URL        string           `xml:"url,attr"`
+	Type       string           `xml:"type,attr"`
+	Medium     string           `xml:"medium,attr"`
+	Thumbnails []MediaThumbnail `xml:"http://search.yahoo.com/mrss/ thumbnail"`
+}
+
+type MediaThumbnail struct {
+	URL string `xml:"url,attr"`
+}
+
+type MediaGroup struct {
+	Contents   []MediaContent   `xml:"http://search.yahoo.com/mrss/ content"`
+	Thumbnails []MediaThumbnail `xml:"http://search.yahoo.com/mrss/ thumbnail"`
+}
+
+var imgSrcRegex = regexp.MustCompile(`(?i)<img\s[^>]*?src\s*=\s*["']([^"']+)["']`)
+
+// ThumbnailURL picks a single image to represent the item. Explicit Media RSS
+// thumbnails win, then image media content, then the iTunes episode artwork,
+// and finally the first <img> in the description. It returns "" when the item
+// has no image at all.
+func (item RSSItem) ThumbnailURL() string {
+	for _, thumbnail := range item.MediaThumbnails {
+		if thumbnail.URL != "" {
+			return thumbnail.URL
+		}
+	}
+	contents := item.MediaContents
+	for _, group := range item.MediaGroups {
+		for _, thumbnail := range group.Thumbnails {
+			if thumbnail.URL != "" {
+				return thumbnail.URL
+			}
+		}
+		contents = append(contents, group.Contents...)
+	}
+	for _, content := range contents {
+		for _, thumbnail := range content.Thumbnails {
+			if thumbnail.URL != "" {
+				return thumbnail.URL
+			}
+		}
+	}
+	for _, content := range contents {
+		if content.URL != "" && content.isImage() {
+			return content.URL
+		}
+	}
+	if item.ITunesImage.Href != "" {
+		return item.ITunesImage.Href
+	}
+	if item.Description != nil {
+		match := imgSrcRegex.FindStringSubmatch(*item.Description)
+		if match != nil {
+			return match[1]
+		}
+	}
+	return ""
+}
+
+func (c MediaContent) isImage() bool {
+	return c.Medium == "image" || strings.HasPrefix(c.Type, "image/")
+}
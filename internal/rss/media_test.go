@@ -0,0 +1,89 @@
+package rss
+
+import (
+	"encoding/xml"
+	"testing"
+)
+
+const mediaFeed = `<?xml version="1.0" encoding="UTF-8"?>
+<rss version="2.0" xmlns:media="http://search.yahoo.com/mrss/" xmlns:itunes="http://www.itunes.com/dtds/podcast-1.0.dtd">
+<channel>
+	<title>Media</title>
+	<item>
+		<title>thumbnail</title>
+		<media:thumbnail url="https://example.com/thumb.jpg" />
+		<media:content url="https://example.com/photo.jpg" medium="image" />
+	</item>
+	<item>
+		<title>group</title>
+		<media:group>
+			<media:content url="https://example.com/video.mp4" type="video/mp4">
+				<media:thumbnail url="https://example.com/video.jpg" />
+			</media:content>
+		</media:group>
+	</item>
+	<item>
+		<title>content</title>
+		<media:content url="https://example.com/clip.mp4" type="video/mp4" />
+		<media:content url="https://example.com/still.png" type="image/png" />
+	</item>
+	<item>
+		<title>podcast</title>
+		<enclosure url="https://example.com/ep1.mp3" type="audio/mpeg" length="1234" />
+		<itunes:image href="https://example.com/ep1.jpg" />
+		<itunes:duration>01:02:03</itunes:duration>
+		<itunes:episode>1</itunes:episode>
+	</item>
+	<item>
+		<title>description</title>
+		<description>&lt;p&gt;Hi &lt;img alt="x" src="https://example.com/inline.gif"&gt;&lt;/p&gt;</description>
+	</item>
+	<item>
+		<title>none</title>
+		<description>no images here</description>
+	</item>
+</channel>
+</rss>`
+
+func TestThumbnailURL(t *testing.T) {
+	var feed RSSFeed
+	err := xml.Unmarshal([]byte(mediaFeed), &feed)
+	if err != nil {
+		t.Fatalf("error parsing feed: %v", err)
+	}
+
+	expected := map[string]string{
+		"thumbnail":   "https://example.com/thumb.jpg",
+		"group":       "https://example.com/video.jpg",
+		"content":     "https://example.com/still.png",
+		"podcast":     "https://example.com/ep1.jpg",
+		"description": "https://example.com/inline.gif",
+		"none":        "",
+	}
+	for _, item := range feed.Channel.Item {
+		actual := item.ThumbnailURL()
+		if actual != expected[item.Title] {
+			t.Errorf("%s: expected %q, got %q", item.Title, expected[item.Title], actual)
+		}
+	}
+}
+
+func TestEnclosureParsing(t *testing.T) {
+	var feed RSSFeed
+	err := xml.Unmarshal([]byte(mediaFeed), &feed)
+	if err != nil {
+		t.Fatalf("error parsing feed: %v", err)
+	}
+	item := feed.Channel.Item[3]
+	if len(item.Enclosures) != 1 {
+		t.Fatalf("expected 1 enclosure, got %d", len(item.Enclosures))
+	}
+	enclosure := item.Enclosures[0]
+	length, ok := enclosure.LengthBytes()
+	if enclosure.URL != "https://example.com/ep1.mp3" || enclosure.Type != "audio/mpeg" || !ok || length != 1234 {
+		t.Fatalf("unexpected enclosure: %+v", enclosure)
+	}
+	if item.ITunesDuration != "01:02:03" || item.ITunesEpisode != "1" {
+		t.Fatalf("unexpected itunes fields: %q %q", item.ITunesDuration, item.ITunesEpisode)
+	}
+}
@@ -0,0 +1,96 @@
+package rss
+
+import (
+	"net/url"
+	"strings"
+)
+
+// baseURLs works out what relative references in the feed are relative to.
+// xml:base on <rss>, <channel> or <item> wins, each resolved against the one
+// above it; without any xml:base the channel <link> (the site's address) is
+// used, and the feed's own URL is the last resort. It returns the channel's
+// base and one base per item.
+func (f *RSSFeed) baseURLs(feedURL *url.URL) (*url.URL, []*url.URL) {
+	channelBase := feedURL
+	hasXMLBase := false
+	for _, base := range []string{f.Base, f.Channel.Base} {
+		if resolved, ok := resolveAgainst(channelBase, base); ok {
+			channelBase = resolved
+			hasXMLBase = true
+		}
+	}
+	if !hasXMLBase {
+		if resolved, ok := resolveAgainst(feedURL, f.Channel.Link); ok && resolved.IsAbs() {
+			channelBase = resolved
+		}
+	}
+
+	itemBases := make([]*url.URL, len(f.Channel.Item))
+	for index, item := range f.Channel.Item {
+		itemBases[index] = channelBase
+		if resolved, ok := resolveAgainst(channelBase, item.Base); ok {
+			itemBases[index] = resolved
+		}
+	}
+	return channelBase, itemBases
+}
+
+// resolveURLs rewrites item links, enclosures and media references into
+// absolute URLs using the bases from baseURLs.
+func (f *RSSFeed) resolveURLs(feedURL, channelBase *url.URL, itemBases []*url.URL) {
+	f.Channel.Link = resolveString(feedURL, f.Channel.Link)
+	for index := range f.Channel.Item {
+		item := &f.Channel.Item[index]
+		base := itemBases[index]
+		item.Link = resolveString(base, item.Link)
+		item.ITunesImage.Href = resolveString(base, item.ITunesImage.Href)
+		for i := range item.Enclosures {
+			item.Enclosures[i].URL = resolveString(base, item.Enclosures[i].URL)
+		}
+		for i := range item.MediaThumbnails {
+			item.MediaThumbnails[i].URL = resolveString(base, item.MediaThumbnails[i].URL)
+		}
+		resolveMediaContents(base, item.MediaContents)
+		for i := range item.MediaGroups {
+			group := &item.MediaGroups[i]
+			for j := range group.Thumbnails {
+				group.Thumbnails[j].URL = resolveString(base, group.Thumbnails[j].URL)
+			}
+			resolveMediaContents(base, group.Contents)
+		}
+	}
+}
+
+func resolveMediaContents(base *url.URL, contents []MediaContent) {
+	for i := range contents {
+		contents[i].URL = resolveString(base, contents[i].URL)
+		for j := range contents[i].Thumbnails {
+			contents[i].Thumbnails[j].URL = resolveString(base, contents[i].Thumbnails[j].URL)
+		}
+	}
+}
+
+func resolveAgainst(base *url.URL, ref string) (*url.URL, bool) {
+	ref = strings.TrimSpace(ref)
+	if ref == "" {
+		return nil, false
+	}
+	u, err := url.Parse(ref)
+	if err != nil {
+		return nil, false
+	}
+	if base == nil {
+		return u, true
+	}
+	return base.ResolveReference(u), true
+}
+
+// resolveString returns ref resolved against base, or ref unchanged when it
+// is empty or can't be parsed.
+func resolveString(base *url.URL, ref string) string {
+	resolved, ok := resolveAgainst(base, ref)
+	if !ok {
+		return ref
+	}
+	return resolved.String()
+}
@@ -1,10 +1,19 @@
 package rss
 
+import (
+	"strconv"
+	"strings"
+)
+
 type RSSFeed struct {
+	Base    string  `xml:"http://www.w3.org/XML/1998/namespace base,attr"`
 	Channel Channel `xml:"channel"`
+	// Warnings lists problems that were worked around while parsing.
+	Warnings []string `xml:"-"`
 }
 
 type Channel struct {
+	Base        string    `xml:"http://www.w3.org/XML/1998/namespace base,attr"`
 	Title       string    `xml:"title"`
 	Link        string    `xml:"link"`
 	Description *string    `xml:"description"`
@@ -12,8 +21,37 @@ type Channel struct {
 }
 
 type RSSItem struct {
+	Base        string `xml:"http://www.w3.org/XML/1998/namespace base,attr"`
 	Title       string `xml:"title"`
 	Link        string `xml:"link"`
 	Description *string `xml:"description"`
 	PubDate     string `xml:"pubDate"`
+	Enclosures  []Enclosure `xml:"enclosure"`
+	ITunesDuration string `xml:"http://www.itunes.com/dtds/podcast-1.0.dtd duration"`
+	ITunesEpisode  string `xml:"http://www.itunes.com/dtds/podcast-1.0.dtd episode"`
+	ITunesImage    ITunesImage `xml:"http://www.itunes.com/dtds/podcast-1.0.dtd image"`
+	MediaContents   []MediaContent   `xml:"http://search.yahoo.com/mrss/ content"`
+	MediaThumbnails []MediaThumbnail `xml:"http://search.yahoo.com/mrss/ thumbnail"`
+	MediaGroups     []MediaGroup     `xml:"http://search.yahoo.com/mrss/ group"`
+}
+
+// Enclosure is a media file attached to an item, usually a podcast episode.
+type Enclosure struct {
+	URL    string `xml:"url,attr"`
+	Type   string `xml:"type,attr"`
+	Length string `xml:"length,attr"`
+}
+
+// LengthBytes returns the declared size of the enclosure, or false when the
+// feed left it out or filled it with something that isn't a number.
+func (e Enclosure) LengthBytes() (int64, bool) {
+	n, err := strconv.ParseInt(strings.TrimSpace(e.Length), 10, 64)
+	if err != nil || n <= 0 {
+		return 0, false
+	}
+	return n, true
+}
+
+type ITunesImage struct {
+	Href string `xml:"href,attr"`
 }
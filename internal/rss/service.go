@@ -2,10 +2,14 @@ package rss
 
 import (
 	"context"
-	"encoding/xml"
+	"fmt"
 	"html"
 	"io"
 	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/WagnerJust/go-gator/internal/sanitize"
 )
 func (f *RSSFeed) cleanupUnescapedEntities () {
 	f.Channel.Title = html.UnescapeString(f.Channel.Title)
@@ -22,32 +26,114 @@ func (f *RSSFeed) cleanupUnescapedEntities () {
 	}
 }
 
+// sanitizeDescriptions runs every description through the HTML allowlist so
+// scripts, frames, event handlers and tracking pixels never reach the
+// database, resolving relative references against the channel and item bases
+// on the way.
+func (f *RSSFeed) sanitizeDescriptions (channelBase *url.URL, itemBases []*url.URL) {
+	if f.Channel.Description != nil {
+		sanitized := sanitize.HTML(*f.Channel.Description, channelBase)
+		f.Channel.Description = &sanitized
+	}
+	for index, item := range f.Channel.Item {
+		if item.Description != nil {
+			sanitized := sanitize.HTML(*item.Description, itemBases[index])
+			f.Channel.Item[index].Description = &sanitized
+		}
+	}
+}
+
+// sanitizeTitles strips markup and control characters from the channel and
+// item titles, which are printed to the terminal as they are.
+func (f *RSSFeed) sanitizeTitles () {
+	f.Channel.Title = sanitize.Title(f.Channel.Title)
+	for index, item := range f.Channel.Item {
+		f.Channel.Item[index].Title = sanitize.Title(item.Title)
+	}
+}
+
+// DefaultUserAgent is sent when FetchOptions doesn't set a user agent.
+const DefaultUserAgent = "go-gator"
+
+// FetchOptions tunes how a Fetcher talks to feed servers. Zero values mean
+// no timeout, DefaultUserAgent, the proxy from the environment and no body
+// size limit.
+type FetchOptions struct {
+	Timeout      time.Duration
+	UserAgent    string
+	Proxy        string
+	MaxBodyBytes int64
+}
+
+// Fetcher downloads and parses feeds.
+type Fetcher struct {
+	client       *http.Client
+	userAgent    string
+	maxBodyBytes int64
+}
+
+func NewFetcher(opts FetchOptions) (*Fetcher, error) {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	if opts.Proxy != "" {
+		proxyURL, err := url.Parse(opts.Proxy)
+		if err != nil {
+			return nil, fmt.Errorf("invalid proxy %q: %w", opts.Proxy, err)
+		}
+		transport.Proxy = http.ProxyURL(proxyURL)
+	}
+	userAgent := opts.UserAgent
+	if userAgent == "" {
+		userAgent = DefaultUserAgent
+	}
+	return &Fetcher{
+		client:       &http.Client{Transport: transport, Timeout: opts.Timeout},
+		userAgent:    userAgent,
+		maxBodyBytes: opts.MaxBodyBytes,
+	}, nil
+}
+
+var defaultFetcher, _ = NewFetcher(FetchOptions{})
+
+// FetchFeed fetches a feed with the default options.
 func FetchFeed(ctx context.Context, feedURL string) (*RSSFeed, error) {
+	return defaultFetcher.Fetch(ctx, feedURL)
+}
+
+func (f *Fetcher) Fetch(ctx context.Context, feedURL string) (*RSSFeed, error) {
 	req, err := http.NewRequestWithContext(ctx, "GET", feedURL, nil)
 	if err != nil {
 		return &RSSFeed{}, err
 	}
-	req.Header.Set("user-agent", "go-gator")
+	req.Header.Set("user-agent", f.userAgent)
 
-	var client http.Client
-	res, err := client.Do(req)
+	res, err := f.client.Do(req)
 	if err != nil {
 		return &RSSFeed{}, err
 	}
 	defer res.Body.Close()
-	var feed RSSFeed
 
-	data, err := io.ReadAll(res.Body)
+	var body io.Reader = res.Body
+	if f.maxBodyBytes > 0 {
+		body = io.LimitReader(res.Body, f.maxBodyBytes+1)
+	}
+	data, err := io.ReadAll(body)
 	if err != nil {
 		return &RSSFeed{}, err
 	}
+	if f.maxBodyBytes > 0 && int64(len(data)) > f.maxBodyBytes {
+		return &RSSFeed{}, fmt.Errorf("feed is larger than %d bytes", f.maxBodyBytes)
+	}
 
-	err = xml.Unmarshal(data, &feed)
+	feed, err := parseFeed(data, res.Header.Get("content-type"))
 	if err != nil {
 		return &RSSFeed{}, err
 	}
 
 	feed.cleanupUnescapedEntities()
+	channelBase, itemBases := feed.baseURLs(res.Request.URL)
+	feed.resolveURLs(res.Request.URL, channelBase, itemBases)
+	feed.sanitizeDescriptions(channelBase, itemBases)
+	feed.sanitizeTitles()
 
 	return &feed, nil
 }
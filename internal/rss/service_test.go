@@ -0,0 +1,188 @@
+package rss
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"golang.org/x/text/encoding/japanese"
+)
+
+func feedServer(t *testing.T, contentType string, body []byte) *httptest.Server {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if contentType != "" {
+			w.Header().Set("content-type", contentType)
+		}
+		w.Write(body)
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestFetchFeedCharsets(t *testing.T) {
+	shiftJIS, err := japanese.ShiftJIS.NewEncoder().String("日本語のフィード")
+	if err != nil {
+		t.Fatalf("error encoding fixture: %v", err)
+	}
+
+	cases := []struct {
+		name        string
+		contentType string
+		body        string
+		expected    string
+	}{
+		{
+			name:     "prolog latin1",
+			body:     "<?xml version=\"1.0\" encoding=\"ISO-8859-1\"?><rss><channel><title>Caf\xe9</title></channel></rss>",
+			expected: "Café",
+		},
+		{
+			name:        "header windows-1252",
+			contentType: "application/rss+xml; charset=windows-1252",
+			body:        "<?xml version=\"1.0\"?><rss><channel><title>\x93quoted\x94</title></channel></rss>",
+			expected:    "“quoted”",
+		},
+		{
+			name:        "prolog wins over header",
+			contentType: "text/xml; charset=utf-8",
+			body:        "<?xml version=\"1.0\" encoding=\"Shift_JIS\"?><rss><channel><title>" + shiftJIS + "</title></channel></rss>",
+			expected:    "日本語のフィード",
+		},
+		{
+			name:        "utf-8",
+			contentType: "application/xml",
+			body:        "<rss><channel><title>Ünïcödé</title></channel></rss>",
+			expected:    "Ünïcödé",
+		},
+	}
+
+	for _, c := range cases {
+		server := feedServer(t, c.contentType, []byte(c.body))
+		feed, err := FetchFeed(context.Background(), server.URL)
+		if err != nil {
+			t.Errorf("%s: error fetching feed: %v", c.name, err)
+			continue
+		}
+		if feed.Channel.Title != c.expected {
+			t.Errorf("%s: expected %q, got %q", c.name, c.expected, feed.Channel.Title)
+		}
+	}
+}
+
+func TestFetchFeedResolvesRelativeURLs(t *testing.T) {
+	body := `<?xml version="1.0"?>
+<rss xmlns:media="http://search.yahoo.com/mrss/">
+<channel>
+	<title>Relative</title>
+	<link>https://blog.example.com/</link>
+	<item>
+		<title>channel link</title>
+		<link>/2024/05/post.html</link>
+		<description>&lt;a href="about"&gt;about&lt;/a&gt; &lt;img src="img/a.png"&gt;</description>
+		<enclosure url="media/ep.mp3" type="audio/mpeg" length="1" />
+	</item>
+	<item xml:base="https://cdn.example.net/posts/">
+		<title>item base</title>
+		<link>second.html</link>
+		<media:thumbnail url="thumb.jpg" />
+	</item>
+</channel>
+</rss>`
+	server := feedServer(t, "application/rss+xml", []byte(body))
+	feed, err := FetchFeed(context.Background(), server.URL+"/feeds/main.xml")
+	if err != nil {
+		t.Fatalf("error fetching feed: %v", err)
+	}
+
+	first := feed.Channel.Item[0]
+	if first.Link != "https://blog.example.com/2024/05/post.html" {
+		t.Errorf("unexpected link %q", first.Link)
+	}
+	if first.Enclosures[0].URL != "https://blog.example.com/media/ep.mp3" {
+		t.Errorf("unexpected enclosure %q", first.Enclosures[0].URL)
+	}
+	expectedDescription := `<a href="https://blog.example.com/about" rel="nofollow noopener noreferrer">about</a> <img src="https://blog.example.com/img/a.png">`
+	if *first.Description != expectedDescription {
+		t.Errorf("unexpected description %q", *first.Description)
+	}
+
+	second := feed.Channel.Item[1]
+	if second.Link != "https://cdn.example.net/posts/second.html" {
+		t.Errorf("unexpected link %q", second.Link)
+	}
+	if second.ThumbnailURL() != "https://cdn.example.net/posts/thumb.jpg" {
+		t.Errorf("unexpected thumbnail %q", second.ThumbnailURL())
+	}
+}
+
+func TestFetchFeedSanitizesTitles(t *testing.T) {
+	body := `<?xml version="1.0"?>
+<rss>
+<channel>
+	<title>&lt;b&gt;Loud&lt;/b&gt; channel</title>
+	<item>
+		<title>&amp;#27;[2JCleared</title>
+	</item>
+</channel>
+</rss>`
+	server := feedServer(t, "application/rss+xml", []byte(body))
+	feed, err := FetchFeed(context.Background(), server.URL)
+	if err != nil {
+		t.Fatalf("error fetching feed: %v", err)
+	}
+	if feed.Channel.Title != "Loud channel" {
+		t.Errorf("unexpected channel title %q", feed.Channel.Title)
+	}
+	if title := feed.Channel.Item[0].Title; title != "[2JCleared" {
+		t.Errorf("unexpected item title %q", title)
+	}
+}
+
+func TestFetchFeedFallsBackToFeedURL(t *testing.T) {
+	body := `<rss><channel><title>No link</title><item><title>x</title><link>post.html</link></item></channel></rss>`
+	server := feedServer(t, "", []byte(body))
+	feed, err := FetchFeed(context.Background(), server.URL+"/feeds/main.xml")
+	if err != nil {
+		t.Fatalf("error fetching feed: %v", err)
+	}
+	expected := server.URL + "/feeds/post.html"
+	if feed.Channel.Item[0].Link != expected {
+		t.Errorf("expected %q, got %q", expected, feed.Channel.Item[0].Link)
+	}
+}
+
+func TestFetcherOptions(t *testing.T) {
+	body := []byte(`<rss><channel><title>Sized</title></channel></rss>`)
+	var userAgent string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		userAgent = r.Header.Get("user-agent")
+		w.Write(body)
+	}))
+	t.Cleanup(server.Close)
+
+	fetcher, err := NewFetcher(FetchOptions{UserAgent: "gator-test/1.0", MaxBodyBytes: int64(len(body))})
+	if err != nil {
+		t.Fatalf("error creating fetcher: %v", err)
+	}
+	feed, err := fetcher.Fetch(context.Background(), server.URL)
+	if err != nil {
+		t.Fatalf("error fetching feed: %v", err)
+	}
+	if feed.Channel.Title != "Sized" || userAgent != "gator-test/1.0" {
+		t.Fatalf("unexpected title %q or user agent %q", feed.Channel.Title, userAgent)
+	}
+
+	fetcher, err = NewFetcher(FetchOptions{MaxBodyBytes: int64(len(body)) - 1})
+	if err != nil {
+		t.Fatalf("error creating fetcher: %v", err)
+	}
+	_, err = fetcher.Fetch(context.Background(), server.URL)
+	if err == nil {
+		t.Fatalf("expected an oversized feed to be rejected")
+	}
+	if userAgent != DefaultUserAgent {
+		t.Fatalf("expected default user agent, got %q", userAgent)
+	}
+}
@@ -0,0 +1,255 @@
+package sanitize
+
+import (
+	"html"
+	"net/url"
+	"slices"
+	"strings"
+
+	xhtml "golang.org/x/net/html"
+)
+
+// allowedTags maps each element that survives sanitization to the attributes
+// it may keep. Anything not listed here is unwrapped: the tag goes, its text
+// stays.
+var allowedTags = map[string][]string{
+	"a":          {"href", "title"},
+	"abbr":       {"title"},
+	"b":          nil,
+	"blockquote": {"cite"},
+	"br":         nil,
+	"caption":    nil,
+	"code":       nil,
+	"dd":         nil,
+	"del":        nil,
+	"div":        nil,
+	"dl":         nil,
+	"dt":         nil,
+	"em":         nil,
+	"figcaption": nil,
+	"figure":     nil,
+	"h1":         nil,
+	"h2":         nil,
+	"h3":         nil,
+	"h4":         nil,
+	"h5":         nil,
+	"h6":         nil,
+	"hr":         nil,
+	"i":          nil,
+	"img":        {"src", "alt", "title", "width", "height"},
+	"ins":        nil,
+	"li":         nil,
+	"ol":         {"start"},
+	"p":          nil,
+	"pre":        nil,
+	"q":          {"cite"},
+	"s":          nil,
+	"small":      nil,
+	"span":       nil,
+	"strong":     nil,
+	"sub":        nil,
+	"sup":        nil,
+	"table":      nil,
+	"tbody":      nil,
+	"td":         {"colspan", "rowspan"},
+	"tfoot":      nil,
+	"th":         {"colspan", "rowspan"},
+	"thead":      nil,
+	"tr":         nil,
+	"u":          nil,
+	"ul":         nil,
+}
+
+// droppedTags are removed together with everything inside them.
+var droppedTags = map[string]bool{
+	"applet":   true,
+	"embed":    true,
+	"form":     true,
+	"frame":    true,
+	"frameset": true,
+	"head":     true,
+	"iframe":   true,
+	"math":     true,
+	"noscript": true,
+	"object":   true,
+	"script":   true,
+	"select":   true,
+	"style":    true,
+	"svg":      true,
+	"template": true,
+	"textarea": true,
+	"title":    true,
+}
+
+// Dropped reports whether elements named tag are removed along with their
+// content, so renderers of sanitized HTML can skip the same ones.
+func Dropped(tag string) bool {
+	return droppedTags[tag]
+}
+
+var voidTags = map[string]bool{
+	"br":  true,
+	"hr":  true,
+	"img": true,
+}
+
+var urlAttributes = map[string]bool{
+	"cite": true,
+	"href": true,
+	"src":  true,
+}
+
+// HTML returns s with everything but a small allowlist of formatting tags and
+// attributes removed. Scripts, styles, frames and the like are dropped along
+// with their content, event handler and style attributes are stripped, URLs
+// that aren't http(s) or mailto are removed, relative URLs are resolved
+// against base (when it's non-nil) and 1x1 tracking pixels are discarded.
+func HTML(s string, base *url.URL) string {
+	tokenizer := xhtml.NewTokenizer(strings.NewReader(s))
+	var b strings.Builder
+	var open []string
+	skipTag := ""
+	skipDepth := 0
+
+	for {
+		tokenType := tokenizer.Next()
+		switch tokenType {
+		case xhtml.ErrorToken:
+			for i := len(open) - 1; i >= 0; i-- {
+				b.WriteString("</" + open[i] + ">")
+			}
+			return b.String()
+
+		case xhtml.TextToken:
+			if skipDepth == 0 {
+				b.WriteString(html.EscapeString(string(tokenizer.Text())))
+			}
+
+		case xhtml.StartTagToken, xhtml.SelfClosingTagToken:
+			token := tokenizer.Token()
+			selfClosing := tokenType == xhtml.SelfClosingTagToken || voidTags[token.Data]
+			if skipDepth > 0 {
+				if token.Data == skipTag && !selfClosing {
+					skipDepth++
+				}
+				continue
+			}
+			if droppedTags[token.Data] {
+				if !selfClosing {
+					skipTag = token.Data
+					skipDepth = 1
+				}
+				continue
+			}
+			allowed, ok := allowedTags[token.Data]
+			if !ok {
+				continue
+			}
+			attrs := cleanAttributes(token, allowed, base)
+			if token.Data == "img" && (attrs["src"] == "" || isTrackingPixel(attrs)) {
+				continue
+			}
+			if token.Data == "a" && attrs["href"] != "" {
+				attrs["rel"] = "nofollow noopener noreferrer"
+			}
+			writeStartTag(&b, token.Data, allowed, attrs)
+			if !selfClosing {
+				open = append(open, token.Data)
+			}
+
+		case xhtml.EndTagToken:
+			token := tokenizer.Token()
+			if skipDepth > 0 {
+				if token.Data == skipTag {
+					skipDepth--
+				}
+				continue
+			}
+			for i := len(open) - 1; i >= 0; i-- {
+				if open[i] != token.Data {
+					continue
+				}
+				for j := len(open) - 1; j >= i; j-- {
+					b.WriteString("</" + open[j] + ">")
+				}
+				open = open[:i]
+				break
+			}
+		}
+	}
+}
+
+func cleanAttributes(token xhtml.Token, allowed []string, base *url.URL) map[string]string {
+	attrs := make(map[string]string)
+	for _, attr := range token.Attr {
+		if attr.Namespace != "" || !slices.Contains(allowed, attr.Key) {
+			continue
+		}
+		value := strings.TrimSpace(attr.Val)
+		if urlAttributes[attr.Key] {
+			value = cleanURL(value, base, attr.Key == "href")
+			if value == "" {
+				continue
+			}
+		}
+		attrs[attr.Key] = value
+	}
+	return attrs
+}
+
+// cleanURL resolves raw against base and returns "" unless the result uses a
+// scheme that is safe to link to.
+func cleanURL(raw string, base *url.URL, allowMailto bool) string {
+	if raw == "" {
+		return ""
+	}
+	u, err := url.Parse(raw)
+	if err != nil {
+		return ""
+	}
+	if base != nil {
+		u = base.ResolveReference(u)
+	}
+	switch strings.ToLower(u.Scheme) {
+	case "http", "https":
+		return u.String()
+	case "mailto":
+		if allowMailto {
+			return u.String()
+		}
+	case "":
+		// Only reachable without a base: there's nothing to resolve
+		// against, so keep the relative reference as it is.
+		if u.Opaque == "" {
+			return u.String()
+		}
+	}
+	return ""
+}
+
+func isTrackingPixel(attrs map[string]string) bool {
+	return isTiny(attrs["width"]) && isTiny(attrs["height"])
+}
+
+func isTiny(dimension string) bool {
+	dimension = strings.TrimSuffix(strings.TrimSpace(dimension), "px")
+	return dimension == "0" || dimension == "1"
+}
+
+func writeStartTag(b *strings.Builder, tag string, allowed []string, attrs map[string]string) {
+	b.WriteString("<" + tag)
+	// Walk the allowlist rather than the map so attribute order is stable.
+	for _, key := range allowed {
+		writeAttribute(b, key, attrs)
+	}
+	writeAttribute(b, "rel", attrs)
+	b.WriteString(">")
+}
+
+func writeAttribute(b *strings.Builder, key string, attrs map[string]string) {
+	value, ok := attrs[key]
+	if !ok {
+		return
+	}
+	b.WriteString(" " + key + `="` + html.EscapeString(value) + `"`)
+}
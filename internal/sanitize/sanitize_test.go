@@ -0,0 +1,102 @@
+package sanitize
+
+import (
+	"net/url"
+	"testing"
+)
+
+func TestHTML(t *testing.T) {
+	base, err := url.Parse("https://example.com/blog/post.html")
+	if err != nil {
+		t.Fatalf("error parsing base: %v", err)
+	}
+
+	cases := []struct {
+		name     string
+		input    string
+		expected string
+	}{
+		{
+			name:     "script dropped with content",
+			input:    `<p>hi<script>alert("x")</script></p>`,
+			expected: `<p>hi</p>`,
+		},
+		{
+			name:     "iframe and style dropped",
+			input:    `<style>p{}</style><iframe src="https://evil.example"><p>fallback</p></iframe>ok`,
+			expected: `ok`,
+		},
+		{
+			name:     "event handlers and style stripped",
+			input:    `<p onclick="steal()" style="color:red">text</p>`,
+			expected: `<p>text</p>`,
+		},
+		{
+			name:     "javascript link removed",
+			input:    `<a href="javascript:alert(1)">click</a>`,
+			expected: `<a>click</a>`,
+		},
+		{
+			name:     "relative links resolved",
+			input:    `<a href="../about">about</a><img src="/img/a.png" alt="a">`,
+			expected: `<a href="https://example.com/about" rel="nofollow noopener noreferrer">about</a><img src="https://example.com/img/a.png" alt="a">`,
+		},
+		{
+			name:     "tracking pixel removed",
+			input:    `<p>x<img src="https://t.example/p.gif" width="1" height="1"></p>`,
+			expected: `<p>x</p>`,
+		},
+		{
+			name:     "unknown tags unwrapped",
+			input:    `<section><custom>kept</custom></section>`,
+			expected: `kept`,
+		},
+		{
+			name:     "unclosed tags closed",
+			input:    `<ul><li><b>one`,
+			expected: `<ul><li><b>one</b></li></ul>`,
+		},
+		{
+			name:     "text escaped",
+			input:    `1 &lt; 2 &amp; "3"`,
+			expected: `1 &lt; 2 &amp; &#34;3&#34;`,
+		},
+		{
+			name:     "data uri image removed",
+			input:    `<img src="data:image/png;base64,AAAA">`,
+			expected: ``,
+		},
+	}
+
+	for _, c := range cases {
+		actual := HTML(c.input, base)
+		if actual != c.expected {
+			t.Errorf("%s: expected %q, got %q", c.name, c.expected, actual)
+		}
+	}
+}
+
+func TestPlainText(t *testing.T) {
+	input := `<h1>Title</h1><p>Hello   <b>bo</b>ld&nbsp;world</p><table><tr><td>a</td><td>b</td></tr></table><script>evil()</script><ul><li>one</li><li>two</li></ul>`
+	expected := "Title\nHello bold world\na b\none\ntwo"
+	actual := PlainText(input)
+	if actual != expected {
+		t.Fatalf("expected %q, got %q", expected, actual)
+	}
+}
+
+func TestTitle(t *testing.T) {
+	cases := map[string]string{
+		"Plain title":                        "Plain title",
+		"\x1b[2JCleared":                     "[2JCleared",
+		"Bell\x07 and\u009b CSI":             "Bell and CSI",
+		"<b>Bold</b> <script>x()</script>it": "Bold it",
+		"Two\nlines &amp; more":              "Two lines & more",
+		"AT&T < Verizon":                     "AT&T < Verizon",
+	}
+	for input, expected := range cases {
+		if actual := Title(input); actual != expected {
+			t.Errorf("%q: expected %q, got %q", input, expected, actual)
+		}
+	}
+}
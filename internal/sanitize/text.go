@@ -0,0 +1,101 @@
+package sanitize
+
+import (
+	"strings"
+	"unicode"
+
+	xhtml "golang.org/x/net/html"
+)
+
+// blockTags start a new line when rendered as plain text.
+var blockTags = map[string]bool{
+	"blockquote": true,
+	"br":         true,
+	"div":        true,
+	"dd":         true,
+	"dl":         true,
+	"dt":         true,
+	"figcaption": true,
+	"figure":     true,
+	"h1":         true,
+	"h2":         true,
+	"h3":         true,
+	"h4":         true,
+	"h5":         true,
+	"h6":         true,
+	"hr":         true,
+	"li":         true,
+	"ol":         true,
+	"p":          true,
+	"pre":        true,
+	"table":      true,
+	"tr":         true,
+	"ul":         true,
+}
+
+// PlainText strips all markup from s, decodes entities and collapses
+// whitespace, keeping line breaks where block elements were. Content of
+// scripts, styles and other dropped elements is discarded.
+func PlainText(s string) string {
+	tokenizer := xhtml.NewTokenizer(strings.NewReader(s))
+	var lines []string
+	var line strings.Builder
+	skipDepth := 0
+	skipTag := ""
+
+	breakLine := func() {
+		text := strings.Join(strings.Fields(line.String()), " ")
+		line.Reset()
+		if text != "" {
+			lines = append(lines, text)
+		}
+	}
+
+	for {
+		tokenType := tokenizer.Next()
+		switch tokenType {
+		case xhtml.ErrorToken:
+			breakLine()
+			return strings.Join(lines, "\n")
+		case xhtml.TextToken:
+			if skipDepth == 0 {
+				line.Write(tokenizer.Text())
+			}
+		case xhtml.StartTagToken, xhtml.SelfClosingTagToken, xhtml.EndTagToken:
+			name, _ := tokenizer.TagName()
+			tag := string(name)
+			if droppedTags[tag] && tokenType == xhtml.StartTagToken {
+				if skipDepth == 0 || tag == skipTag {
+					skipTag = tag
+					skipDepth++
+				}
+				continue
+			}
+			if skipDepth > 0 {
+				if tag == skipTag && tokenType == xhtml.EndTagToken {
+					skipDepth--
+				}
+				continue
+			}
+			switch {
+			case blockTags[tag]:
+				breakLine()
+			case tag == "td" || tag == "th":
+				line.WriteString(" ")
+			}
+		}
+	}
+}
+
+// Title makes s safe to print as a one-line title: markup is stripped as by
+// PlainText, control characters such as the escape that starts a terminal
+// sequence are removed, and whitespace is collapsed to single spaces.
+func Title(s string) string {
+	s = strings.Map(func(r rune) rune {
+		if unicode.IsControl(r) {
+			return ' '
+		}
+		return r
+	}, PlainText(s))
+	return strings.Join(strings.Fields(s), " ")
+}
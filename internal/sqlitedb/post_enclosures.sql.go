@@ -0,0 +1,157 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.30.0
+// source: post_enclosures.sql
+
+package sqlitedb
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const createPostEnclosure = `-- name: CreatePostEnclosure :one
+INSERT INTO post_enclosures (id, created_at, updated_at, post_id, url, mime_type, length, duration, episode, image_url)
+VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+RETURNING id, created_at, updated_at, post_id, url, mime_type, length, duration, episode, image_url
+`
+
+type CreatePostEnclosureParams struct {
+	ID        uuid.UUID
+	CreatedAt time.Time
+	UpdatedAt time.Time
+	PostID    uuid.UUID
+	Url       string
+	MimeType  sql.NullString
+	Length    sql.NullInt64
+	Duration  sql.NullString
+	Episode   sql.NullString
+	ImageUrl  sql.NullString
+}
+
+func (q *Queries) CreatePostEnclosure(ctx context.Context, arg CreatePostEnclosureParams) (PostEnclosure, error) {
+	row := q.db.QueryRowContext(ctx, createPostEnclosure,
+		arg.ID,
+		arg.CreatedAt,
+		arg.UpdatedAt,
+		arg.PostID,
+		arg.Url,
+		arg.MimeType,
+		arg.Length,
+		arg.Duration,
+		arg.Episode,
+		arg.ImageUrl,
+	)
+	var i PostEnclosure
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.PostID,
+		&i.Url,
+		&i.MimeType,
+		&i.Length,
+		&i.Duration,
+		&i.Episode,
+		&i.ImageUrl,
+	)
+	return i, err
+}
+
+const getAllPostEnclosures = `-- name: GetAllPostEnclosures :many
+SELECT id, created_at, updated_at, post_id, url, mime_type, length, duration, episode, image_url FROM post_enclosures ORDER BY created_at, id
+`
+
+func (q *Queries) GetAllPostEnclosures(ctx context.Context) ([]PostEnclosure, error) {
+	rows, err := q.db.QueryContext(ctx, getAllPostEnclosures)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []PostEnclosure
+	for rows.Next() {
+		var i PostEnclosure
+		if err := rows.Scan(
+			&i.ID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.PostID,
+			&i.Url,
+			&i.MimeType,
+			&i.Length,
+			&i.Duration,
+			&i.Episode,
+			&i.ImageUrl,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getEnclosuresForPost = `-- name: GetEnclosuresForPost :many
+SELECT id, created_at, updated_at, post_id, url, mime_type, length, duration, episode, image_url FROM post_enclosures WHERE post_id = ? ORDER BY created_at
+`
+
+func (q *Queries) GetEnclosuresForPost(ctx context.Context, postID uuid.UUID) ([]PostEnclosure, error) {
+	rows, err := q.db.QueryContext(ctx, getEnclosuresForPost, postID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []PostEnclosure
+	for rows.Next() {
+		var i PostEnclosure
+		if err := rows.Scan(
+			&i.ID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.PostID,
+			&i.Url,
+			&i.MimeType,
+			&i.Length,
+			&i.Duration,
+			&i.Episode,
+			&i.ImageUrl,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const insertPostEnclosures = `-- name: InsertPostEnclosures :exec
+INSERT INTO post_enclosures (id, created_at, updated_at, post_id, url, mime_type, length, duration, episode, image_url)
+SELECT json_extract(value, '$.id'), json_extract(value, '$.created_at'), json_extract(value, '$.updated_at'),
+    json_extract(value, '$.post_id'), json_extract(value, '$.url'), json_extract(value, '$.mime_type'),
+    json_extract(value, '$.length'), json_extract(value, '$.duration'), json_extract(value, '$.episode'),
+    json_extract(value, '$.image_url')
+FROM (SELECT CAST(?1 AS TEXT) AS batch) AS b, json_each(b.batch)
+WHERE true
+ON CONFLICT DO NOTHING
+`
+
+// InsertPostEnclosures adds a batch of enclosures, given as a JSON array of
+// objects keyed by column name, skipping those already stored. WHERE true
+// stops SQLite reading ON CONFLICT as a join constraint.
+func (q *Queries) InsertPostEnclosures(ctx context.Context, enclosures string) error {
+	_, err := q.db.ExecContext(ctx, insertPostEnclosures, enclosures)
+	return err
+}
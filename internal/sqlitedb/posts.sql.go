@@ -0,0 +1,303 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.30.0
+// source: posts.sql
+
+package sqlitedb
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const createPost = `-- name: CreatePost :one
+INSERT INTO posts (id, created_at, updated_at, title, url, description, published_at, feed_id, thumbnail_url)
+VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)
+RETURNING id, created_at, updated_at, title, url, description, published_at, feed_id, thumbnail_url
+`
+
+type CreatePostParams struct {
+	ID           uuid.UUID
+	CreatedAt    time.Time
+	UpdatedAt    time.Time
+	Title        string
+	Url          string
+	Description  sql.NullString
+	PublishedAt  time.Time
+	FeedID       uuid.UUID
+	ThumbnailUrl sql.NullString
+}
+
+func (q *Queries) CreatePost(ctx context.Context, arg CreatePostParams) (Post, error) {
+	row := q.db.QueryRowContext(ctx, createPost,
+		arg.ID,
+		arg.CreatedAt,
+		arg.UpdatedAt,
+		arg.Title,
+		arg.Url,
+		arg.Description,
+		arg.PublishedAt,
+		arg.FeedID,
+		arg.ThumbnailUrl,
+	)
+	var i Post
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Title,
+		&i.Url,
+		&i.Description,
+		&i.PublishedAt,
+		&i.FeedID,
+		&i.ThumbnailUrl,
+	)
+	return i, err
+}
+
+const deleteAllPosts = `-- name: DeleteAllPosts :exec
+DELETE FROM posts
+`
+
+func (q *Queries) DeleteAllPosts(ctx context.Context) error {
+	_, err := q.db.ExecContext(ctx, deleteAllPosts)
+	return err
+}
+
+const deletePostsByID = `-- name: DeletePostsByID :exec
+DELETE FROM posts
+WHERE id IN (
+    SELECT j.value FROM (SELECT CAST(?1 AS TEXT) AS batch) AS b, json_each(b.batch) AS j
+)
+`
+
+// DeletePostsByID deletes the posts whose IDs are in a JSON array, along with
+// their enclosures and states.
+func (q *Queries) DeletePostsByID(ctx context.Context, ids string) error {
+	_, err := q.db.ExecContext(ctx, deletePostsByID, ids)
+	return err
+}
+
+const getAllPosts = `-- name: GetAllPosts :many
+SELECT id, created_at, updated_at, title, url, description, published_at, feed_id, thumbnail_url FROM posts ORDER BY created_at, id
+`
+
+func (q *Queries) GetAllPosts(ctx context.Context) ([]Post, error) {
+	rows, err := q.db.QueryContext(ctx, getAllPosts)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Post
+	for rows.Next() {
+		var i Post
+		if err := rows.Scan(
+			&i.ID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Title,
+			&i.Url,
+			&i.Description,
+			&i.PublishedAt,
+			&i.FeedID,
+			&i.ThumbnailUrl,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getPostByID = `-- name: GetPostByID :one
+SELECT id, created_at, updated_at, title, url, description, published_at, feed_id, thumbnail_url FROM posts WHERE id = ?
+`
+
+func (q *Queries) GetPostByID(ctx context.Context, id uuid.UUID) (Post, error) {
+	row := q.db.QueryRowContext(ctx, getPostByID, id)
+	var i Post
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Title,
+		&i.Url,
+		&i.Description,
+		&i.PublishedAt,
+		&i.FeedID,
+		&i.ThumbnailUrl,
+	)
+	return i, err
+}
+
+const getPostsForUser = `-- name: GetPostsForUser :many
+SELECT p.id, p.created_at, p.updated_at, p.title, p.url, p.description, p.published_at, p.feed_id, p.thumbnail_url FROM posts p
+JOIN feed_follows ff on ff.feed_id = p.feed_id
+WHERE ff.user_id = ?
+ORDER BY p.published_at DESC
+LIMIT ?
+`
+
+type GetPostsForUserParams struct {
+	UserID uuid.UUID
+	Limit  int64
+}
+
+func (q *Queries) GetPostsForUser(ctx context.Context, arg GetPostsForUserParams) ([]Post, error) {
+	rows, err := q.db.QueryContext(ctx, getPostsForUser, arg.UserID, arg.Limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Post
+	for rows.Next() {
+		var i Post
+		if err := rows.Scan(
+			&i.ID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Title,
+			&i.Url,
+			&i.Description,
+			&i.PublishedAt,
+			&i.FeedID,
+			&i.ThumbnailUrl,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getPrunablePosts = `-- name: GetPrunablePosts :many
+SELECT p.id, p.feed_id, p.title, p.url, p.published_at,
+    EXISTS (
+        SELECT 1 FROM feed_follows ff
+        LEFT JOIN post_states ps ON ps.post_id = p.id AND ps.user_id = ff.user_id
+        WHERE ff.feed_id = p.feed_id AND ps.read_at IS NULL
+    ) AS unread
+FROM posts p
+WHERE p.feed_id = ?1
+    AND (p.published_at < ?2
+        OR (CAST(?3 AS INTEGER) > 0 AND (
+            SELECT COUNT(*) FROM posts newer
+            WHERE newer.feed_id = p.feed_id
+                AND (newer.published_at > p.published_at
+                    OR (newer.published_at = p.published_at AND newer.id < p.id))
+        ) >= CAST(?3 AS INTEGER)))
+    AND NOT EXISTS (
+        SELECT 1 FROM post_states ps WHERE ps.post_id = p.id AND ps.starred
+    )
+ORDER BY p.published_at
+`
+
+type GetPrunablePostsParams struct {
+	FeedID          uuid.UUID
+	PublishedBefore time.Time
+	KeepLast        int64
+}
+
+type GetPrunablePostsRow struct {
+	ID          uuid.UUID
+	FeedID      uuid.UUID
+	Title       string
+	Url         string
+	PublishedAt time.Time
+	Unread      int64
+}
+
+// GetPrunablePosts lists a feed's posts that are older than published_before
+// or beyond its keep_last newest (zero keeps all), leaving out posts anyone
+// has starred. unread marks the posts a follower hasn't read, which are kept.
+func (q *Queries) GetPrunablePosts(ctx context.Context, arg GetPrunablePostsParams) ([]GetPrunablePostsRow, error) {
+	rows, err := q.db.QueryContext(ctx, getPrunablePosts, arg.FeedID, arg.PublishedBefore, arg.KeepLast)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetPrunablePostsRow
+	for rows.Next() {
+		var i GetPrunablePostsRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.FeedID,
+			&i.Title,
+			&i.Url,
+			&i.PublishedAt,
+			&i.Unread,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const insertPosts = `-- name: InsertPosts :many
+INSERT INTO posts (id, created_at, updated_at, title, url, description, published_at, feed_id, thumbnail_url)
+SELECT json_extract(value, '$.id'), json_extract(value, '$.created_at'), json_extract(value, '$.updated_at'),
+    json_extract(value, '$.title'), json_extract(value, '$.url'), json_extract(value, '$.description'),
+    json_extract(value, '$.published_at'), json_extract(value, '$.feed_id'), json_extract(value, '$.thumbnail_url')
+FROM (SELECT CAST(?1 AS TEXT) AS batch) AS b, json_each(b.batch)
+WHERE true
+ON CONFLICT DO NOTHING
+RETURNING id, created_at, updated_at, title, url, description, published_at, feed_id, thumbnail_url
+`
+
+// InsertPosts adds a batch of posts, given as a JSON array of objects keyed
+// by column name, skipping those already stored. WHERE true stops SQLite
+// reading ON CONFLICT as a join constraint.
+func (q *Queries) InsertPosts(ctx context.Context, posts string) ([]Post, error) {
+	rows, err := q.db.QueryContext(ctx, insertPosts, posts)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Post
+	for rows.Next() {
+		var i Post
+		if err := rows.Scan(
+			&i.ID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Title,
+			&i.Url,
+			&i.Description,
+			&i.PublishedAt,
+			&i.FeedID,
+			&i.ThumbnailUrl,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
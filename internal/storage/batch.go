@@ -0,0 +1,110 @@
+package storage
+
+import (
+	"database/sql"
+	"encoding/json"
+	"time"
+
+	"github.com/WagnerJust/go-gator/internal/database"
+	"github.com/google/uuid"
+)
+
+// The InsertPosts and InsertPostEnclosures queries take their batch as one
+// JSON array, which both databases can unpack into rows, so a feed's posts go
+// in with one statement however many there are.
+
+// batchTimeLayout is how times are written in a batch, in UTC. Postgres
+// parses it, and it's the layout the SQLite driver stores time.Time values
+// in, so batched rows sort alongside the rest.
+const batchTimeLayout = "2006-01-02 15:04:05.999999999-07:00"
+
+type postJSON struct {
+	ID           uuid.UUID `json:"id"`
+	CreatedAt    string    `json:"created_at"`
+	UpdatedAt    string    `json:"updated_at"`
+	Title        string    `json:"title"`
+	Url          string    `json:"url"`
+	Description  *string   `json:"description"`
+	PublishedAt  string    `json:"published_at"`
+	FeedID       uuid.UUID `json:"feed_id"`
+	ThumbnailUrl *string   `json:"thumbnail_url"`
+}
+
+type enclosureJSON struct {
+	ID        uuid.UUID `json:"id"`
+	CreatedAt string    `json:"created_at"`
+	UpdatedAt string    `json:"updated_at"`
+	PostID    uuid.UUID `json:"post_id"`
+	Url       string    `json:"url"`
+	MimeType  *string   `json:"mime_type"`
+	Length    *int64    `json:"length"`
+	Duration  *string   `json:"duration"`
+	Episode   *string   `json:"episode"`
+	ImageUrl  *string   `json:"image_url"`
+}
+
+func batchTime(t time.Time) string {
+	return t.UTC().Format(batchTimeLayout)
+}
+
+func batchString(s sql.NullString) *string {
+	if !s.Valid {
+		return nil
+	}
+	return &s.String
+}
+
+func marshalPosts(args []database.CreatePostParams) ([]byte, error) {
+	rows := make([]postJSON, len(args))
+	for i, arg := range args {
+		rows[i] = postJSON{
+			ID:           arg.ID,
+			CreatedAt:    batchTime(arg.CreatedAt),
+			UpdatedAt:    batchTime(arg.UpdatedAt),
+			Title:        arg.Title,
+			Url:          arg.Url,
+			Description:  batchString(arg.Description),
+			PublishedAt:  batchTime(arg.PublishedAt),
+			FeedID:       arg.FeedID,
+			ThumbnailUrl: batchString(arg.ThumbnailUrl),
+		}
+	}
+	return json.Marshal(rows)
+}
+
+func marshalEnclosures(args []database.CreatePostEnclosureParams) ([]byte, error) {
+	rows := make([]enclosureJSON, len(args))
+	for i, arg := range args {
+		rows[i] = enclosureJSON{
+			ID:        arg.ID,
+			CreatedAt: batchTime(arg.CreatedAt),
+			UpdatedAt: batchTime(arg.UpdatedAt),
+			PostID:    arg.PostID,
+			Url:       arg.Url,
+			MimeType:  batchString(arg.MimeType),
+			Duration:  batchString(arg.Duration),
+			Episode:   batchString(arg.Episode),
+			ImageUrl:  batchString(arg.ImageUrl),
+		}
+		if arg.Length.Valid {
+			rows[i].Length = &arg.Length.Int64
+		}
+	}
+	return json.Marshal(rows)
+}
+
+// inInputOrder returns the posts RETURNING gave back in the order they were
+// passed in, as databases don't promise one.
+func inInputOrder(args []database.CreatePostParams, posts []database.Post) []database.Post {
+	byID := make(map[uuid.UUID]database.Post, len(posts))
+	for _, post := range posts {
+		byID[post.ID] = post
+	}
+	ordered := make([]database.Post, 0, len(posts))
+	for _, arg := range args {
+		if post, ok := byID[arg.ID]; ok {
+			ordered = append(ordered, post)
+		}
+	}
+	return ordered
+}
@@ -0,0 +1,758 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/WagnerJust/go-gator/internal/database"
+	"github.com/google/uuid"
+)
+
+// errDuplicate is Memory's unique constraint violation; see IsDuplicate.
+var errDuplicate = errors.New("already exists")
+
+// Memory is a Store that keeps everything in memory, for tests. It enforces
+// the same unique keys and cascades as the schema, and returns sql.ErrNoRows
+// for lookups that find nothing. Transactions run one at a time and roll back
+// by restoring what was there before.
+type Memory struct {
+	txMu       sync.Mutex
+	mu         sync.Mutex
+	users      []database.User
+	feeds      []database.Feed
+	follows    []database.FeedFollow
+	posts      []database.Post
+	enclosures []database.PostEnclosure
+	states     []database.PostState
+}
+
+func NewMemory() *Memory {
+	return &Memory{}
+}
+
+// memoryTx is the Store inside a Memory transaction.
+type memoryTx struct {
+	*Memory
+}
+
+func (t memoryTx) InTx(ctx context.Context, fn func(Store) error) error {
+	return fn(t)
+}
+
+func (m *Memory) InTx(ctx context.Context, fn func(Store) error) error {
+	m.txMu.Lock()
+	defer m.txMu.Unlock()
+	m.mu.Lock()
+	saved := Memory{
+		users:      slices.Clone(m.users),
+		feeds:      slices.Clone(m.feeds),
+		follows:    slices.Clone(m.follows),
+		posts:      slices.Clone(m.posts),
+		enclosures: slices.Clone(m.enclosures),
+		states:     slices.Clone(m.states),
+	}
+	m.mu.Unlock()
+	err := fn(memoryTx{m})
+	if err != nil {
+		m.mu.Lock()
+		m.users, m.feeds, m.follows = saved.users, saved.feeds, saved.follows
+		m.posts, m.enclosures, m.states = saved.posts, saved.enclosures, saved.states
+		m.mu.Unlock()
+	}
+	return err
+}
+
+// InSnapshot calls fn with a copy of the store, so nothing written
+// meanwhile is seen.
+func (m *Memory) InSnapshot(ctx context.Context, fn func(Store) error) error {
+	m.mu.Lock()
+	snapshot := &Memory{
+		users:      slices.Clone(m.users),
+		feeds:      slices.Clone(m.feeds),
+		follows:    slices.Clone(m.follows),
+		posts:      slices.Clone(m.posts),
+		enclosures: slices.Clone(m.enclosures),
+		states:     slices.Clone(m.states),
+	}
+	m.mu.Unlock()
+	return fn(memoryTx{snapshot})
+}
+
+func (m *Memory) user(id uuid.UUID) (database.User, bool) {
+	i := slices.IndexFunc(m.users, func(u database.User) bool { return u.ID == id })
+	if i < 0 {
+		return database.User{}, false
+	}
+	return m.users[i], true
+}
+
+func (m *Memory) feed(id uuid.UUID) (database.Feed, bool) {
+	i := slices.IndexFunc(m.feeds, func(f database.Feed) bool { return f.ID == id })
+	if i < 0 {
+		return database.Feed{}, false
+	}
+	return m.feeds[i], true
+}
+
+func (m *Memory) isFollowing(userID, feedID uuid.UUID) bool {
+	return slices.ContainsFunc(m.follows, func(f database.FeedFollow) bool {
+		return f.UserID == userID && f.FeedID == feedID
+	})
+}
+
+func (m *Memory) state(userID, postID uuid.UUID) (database.PostState, bool) {
+	i := slices.IndexFunc(m.states, func(s database.PostState) bool {
+		return s.UserID == userID && s.PostID == postID
+	})
+	if i < 0 {
+		return database.PostState{}, false
+	}
+	return m.states[i], true
+}
+
+func (m *Memory) CreateUser(ctx context.Context, arg database.CreateUserParams) (database.User, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.nameTaken(arg.Name, arg.ID) {
+		return database.User{}, fmt.Errorf("user %q %w", arg.Name, errDuplicate)
+	}
+	user := database.User(arg)
+	m.users = append(m.users, user)
+	return user, nil
+}
+
+func (m *Memory) GetUserByName(ctx context.Context, name string) (database.User, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	i := slices.IndexFunc(m.users, func(u database.User) bool { return strings.EqualFold(u.Name, name) })
+	if i < 0 {
+		return database.User{}, sql.ErrNoRows
+	}
+	return m.users[i], nil
+}
+
+// nameTaken reports whether a user other than id has name, ignoring case.
+func (m *Memory) nameTaken(name string, id uuid.UUID) bool {
+	return slices.ContainsFunc(m.users, func(u database.User) bool {
+		return u.ID != id && strings.EqualFold(u.Name, name)
+	})
+}
+
+func (m *Memory) RenameUser(ctx context.Context, arg database.RenameUserParams) (database.User, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	i := slices.IndexFunc(m.users, func(u database.User) bool { return u.ID == arg.ID })
+	if i < 0 {
+		return database.User{}, sql.ErrNoRows
+	}
+	if m.nameTaken(arg.Name, arg.ID) {
+		return database.User{}, fmt.Errorf("user %q %w", arg.Name, errDuplicate)
+	}
+	m.users[i].Name = arg.Name
+	m.users[i].UpdatedAt = arg.UpdatedAt
+	return m.users[i], nil
+}
+
+func (m *Memory) GetUserStats(ctx context.Context, userID uuid.UUID) (database.GetUserStatsRow, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var stats database.GetUserStatsRow
+	for _, feed := range m.feeds {
+		if feed.UserID == userID {
+			stats.FeedsAdded++
+		}
+	}
+	for _, follow := range m.follows {
+		if follow.UserID == userID {
+			stats.Follows++
+		}
+	}
+	for _, state := range m.states {
+		if state.UserID != userID {
+			continue
+		}
+		if state.ReadAt.Valid {
+			stats.ReadPosts++
+		}
+		if state.Starred {
+			stats.StarredPosts++
+		}
+	}
+	return stats, nil
+}
+
+func (m *Memory) GetUserDeletionImpact(ctx context.Context, userID uuid.UUID) (database.GetUserDeletionImpactRow, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var impact database.GetUserDeletionImpactRow
+	added := map[uuid.UUID]bool{}
+	for _, feed := range m.feeds {
+		if feed.UserID == userID {
+			added[feed.ID] = true
+		}
+	}
+	for _, post := range m.posts {
+		if added[post.FeedID] {
+			impact.Posts++
+		}
+	}
+	followers := map[uuid.UUID]bool{}
+	for _, follow := range m.follows {
+		if added[follow.FeedID] && follow.UserID != userID {
+			impact.OtherFollows++
+			followers[follow.UserID] = true
+		}
+	}
+	impact.OtherFollowers = int64(len(followers))
+	return impact, nil
+}
+
+func (m *Memory) GetAllUsers(ctx context.Context) ([]database.User, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return slices.Clone(m.users), nil
+}
+
+// DeleteAllUsers empties the store, as every feed belongs to a user and
+// everything else to a feed.
+func (m *Memory) DeleteAllUsers(ctx context.Context) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.users, m.feeds, m.follows = nil, nil, nil
+	m.posts, m.enclosures, m.states = nil, nil, nil
+	return nil
+}
+
+func (m *Memory) DeleteUser(ctx context.Context, id uuid.UUID) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.users = slices.DeleteFunc(m.users, func(u database.User) bool { return u.ID == id })
+	m.follows = slices.DeleteFunc(m.follows, func(f database.FeedFollow) bool { return f.UserID == id })
+	m.states = slices.DeleteFunc(m.states, func(s database.PostState) bool { return s.UserID == id })
+	m.deleteFeeds(func(f database.Feed) bool { return f.UserID == id })
+	return nil
+}
+
+func (m *Memory) DeleteAllFeeds(ctx context.Context) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.deleteFeeds(func(database.Feed) bool { return true })
+	return nil
+}
+
+// deleteFeeds deletes the feeds drop returns true for, with their follows
+// and posts.
+func (m *Memory) deleteFeeds(drop func(database.Feed) bool) {
+	var dropped []uuid.UUID
+	m.feeds = slices.DeleteFunc(m.feeds, func(f database.Feed) bool {
+		if drop(f) {
+			dropped = append(dropped, f.ID)
+			return true
+		}
+		return false
+	})
+	m.follows = slices.DeleteFunc(m.follows, func(f database.FeedFollow) bool { return slices.Contains(dropped, f.FeedID) })
+	m.deletePosts(func(p database.Post) bool { return slices.Contains(dropped, p.FeedID) })
+}
+
+func (m *Memory) CreateFeed(ctx context.Context, arg database.CreateFeedParams) (database.Feed, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	feed := database.Feed{
+		ID:        arg.ID,
+		CreatedAt: arg.CreatedAt,
+		UpdatedAt: arg.UpdatedAt,
+		Name:      arg.Name,
+		Url:       arg.Url,
+		UserID:    arg.UserID,
+	}
+	return feed, m.addFeed(feed)
+}
+
+func (m *Memory) RestoreFeed(ctx context.Context, arg database.RestoreFeedParams) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.addFeed(database.Feed(arg))
+}
+
+func (m *Memory) addFeed(feed database.Feed) error {
+	if _, ok := m.user(feed.UserID); !ok {
+		return fmt.Errorf("no user %s", feed.UserID)
+	}
+	if slices.ContainsFunc(m.feeds, func(f database.Feed) bool { return f.Url == feed.Url }) {
+		return fmt.Errorf("feed %q %w", feed.Url, errDuplicate)
+	}
+	m.feeds = append(m.feeds, feed)
+	return nil
+}
+
+func (m *Memory) GetAllFeeds(ctx context.Context) ([]database.Feed, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return slices.Clone(m.feeds), nil
+}
+
+func (m *Memory) GetAllFeedsWithUsers(ctx context.Context) ([]database.GetAllFeedsWithUsersRow, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var rows []database.GetAllFeedsWithUsersRow
+	for _, feed := range m.feeds {
+		user, _ := m.user(feed.UserID)
+		rows = append(rows, database.GetAllFeedsWithUsersRow{
+			ID:               feed.ID,
+			CreatedAt:        feed.CreatedAt,
+			UpdatedAt:        feed.UpdatedAt,
+			LastFetchedAt:    feed.LastFetchedAt,
+			Name:             feed.Name,
+			Url:              feed.Url,
+			UserID:           feed.UserID,
+			LastFetchWarning: feed.LastFetchWarning,
+			UserName:         user.Name,
+		})
+	}
+	return rows, nil
+}
+
+func (m *Memory) GetFeedByID(ctx context.Context, id uuid.UUID) (database.Feed, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	feed, ok := m.feed(id)
+	if !ok {
+		return database.Feed{}, sql.ErrNoRows
+	}
+	return feed, nil
+}
+
+func (m *Memory) GetFeedByUrl(ctx context.Context, url string) (database.Feed, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	i := slices.IndexFunc(m.feeds, func(f database.Feed) bool { return f.Url == url })
+	if i < 0 {
+		return database.Feed{}, sql.ErrNoRows
+	}
+	return m.feeds[i], nil
+}
+
+func (m *Memory) GetNextFeedsToFetch(ctx context.Context, limit int32) ([]database.Feed, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	feeds := slices.Clone(m.feeds)
+	slices.SortStableFunc(feeds, func(a, b database.Feed) int {
+		switch {
+		case !a.LastFetchedAt.Valid || !b.LastFetchedAt.Valid:
+			return compareBool(b.LastFetchedAt.Valid, a.LastFetchedAt.Valid)
+		default:
+			return a.LastFetchedAt.Time.Compare(b.LastFetchedAt.Time)
+		}
+	})
+	return feeds[:min(int(limit), len(feeds))], nil
+}
+
+// compareBool orders true before false.
+func compareBool(a, b bool) int {
+	switch {
+	case a == b:
+		return 0
+	case a:
+		return -1
+	default:
+		return 1
+	}
+}
+
+func (m *Memory) MarkFeedFetched(ctx context.Context, id uuid.UUID) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for i := range m.feeds {
+		if m.feeds[i].ID == id {
+			now := time.Now().UTC()
+			m.feeds[i].LastFetchedAt = sql.NullTime{Time: now, Valid: true}
+			m.feeds[i].UpdatedAt = now
+		}
+	}
+	return nil
+}
+
+func (m *Memory) SetFeedFetchWarning(ctx context.Context, arg database.SetFeedFetchWarningParams) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for i := range m.feeds {
+		if m.feeds[i].ID == arg.ID {
+			m.feeds[i].LastFetchWarning = arg.LastFetchWarning
+		}
+	}
+	return nil
+}
+
+func (m *Memory) CreateFeedFollow(ctx context.Context, arg database.CreateFeedFollowParams) (database.CreateFeedFollowRow, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	user, ok := m.user(arg.UserID)
+	if !ok {
+		return database.CreateFeedFollowRow{}, fmt.Errorf("no user %s", arg.UserID)
+	}
+	feed, ok := m.feed(arg.FeedID)
+	if !ok {
+		return database.CreateFeedFollowRow{}, fmt.Errorf("no feed %s", arg.FeedID)
+	}
+	if m.isFollowing(arg.UserID, arg.FeedID) {
+		return database.CreateFeedFollowRow{}, fmt.Errorf("%s following %s %w", user.Name, feed.Name, errDuplicate)
+	}
+	follow := database.FeedFollow(arg)
+	m.follows = append(m.follows, follow)
+	return database.CreateFeedFollowRow{
+		ID:        follow.ID,
+		CreatedAt: follow.CreatedAt,
+		UpdatedAt: follow.UpdatedAt,
+		UserID:    follow.UserID,
+		FeedID:    follow.FeedID,
+		FeedName:  feed.Name,
+		UserName:  user.Name,
+	}, nil
+}
+
+func (m *Memory) GetFeedFollowsForUser(ctx context.Context, id uuid.UUID) ([]database.GetFeedFollowsForUserRow, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var rows []database.GetFeedFollowsForUserRow
+	for _, follow := range m.follows {
+		if follow.UserID != id {
+			continue
+		}
+		user, _ := m.user(follow.UserID)
+		feed, _ := m.feed(follow.FeedID)
+		rows = append(rows, database.GetFeedFollowsForUserRow{
+			ID:        follow.ID,
+			CreatedAt: follow.CreatedAt,
+			UpdatedAt: follow.UpdatedAt,
+			UserID:    follow.UserID,
+			FeedID:    follow.FeedID,
+			FeedName:  feed.Name,
+			UserName:  user.Name,
+			FeedUrl:   feed.Url,
+		})
+	}
+	return rows, nil
+}
+
+func (m *Memory) GetAllFeedFollows(ctx context.Context) ([]database.FeedFollow, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return slices.Clone(m.follows), nil
+}
+
+func (m *Memory) DeleteFeedFollowByUser(ctx context.Context, arg database.DeleteFeedFollowByUserParams) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.follows = slices.DeleteFunc(m.follows, func(f database.FeedFollow) bool {
+		return f.UserID == arg.UserID && f.FeedID == arg.FeedID
+	})
+	return nil
+}
+
+func (m *Memory) CreatePost(ctx context.Context, arg database.CreatePostParams) (database.Post, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if _, ok := m.feed(arg.FeedID); !ok {
+		return database.Post{}, fmt.Errorf("no feed %s", arg.FeedID)
+	}
+	if slices.ContainsFunc(m.posts, func(p database.Post) bool { return p.Url == arg.Url }) {
+		return database.Post{}, fmt.Errorf("post %q %w", arg.Url, errDuplicate)
+	}
+	post := database.Post(arg)
+	m.posts = append(m.posts, post)
+	return post, nil
+}
+
+func (m *Memory) CreatePosts(ctx context.Context, args []database.CreatePostParams) ([]database.Post, error) {
+	var created []database.Post
+	for _, arg := range args {
+		post, err := m.CreatePost(ctx, arg)
+		if IsDuplicate(err) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		created = append(created, post)
+	}
+	return created, nil
+}
+
+func (m *Memory) GetPostByID(ctx context.Context, id uuid.UUID) (database.Post, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	i := slices.IndexFunc(m.posts, func(p database.Post) bool { return p.ID == id })
+	if i < 0 {
+		return database.Post{}, sql.ErrNoRows
+	}
+	return m.posts[i], nil
+}
+
+// newestPosts returns the posts in the feeds userID follows that keep
+// returns true for, newest first.
+func (m *Memory) newestPosts(userID uuid.UUID, keep func(database.Post) bool) []database.Post {
+	var posts []database.Post
+	for _, post := range m.posts {
+		if m.isFollowing(userID, post.FeedID) && keep(post) {
+			posts = append(posts, post)
+		}
+	}
+	slices.SortStableFunc(posts, func(a, b database.Post) int {
+		return b.PublishedAt.Compare(a.PublishedAt)
+	})
+	return posts
+}
+
+func (m *Memory) GetPostsForUser(ctx context.Context, arg database.GetPostsForUserParams) ([]database.Post, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	posts := m.newestPosts(arg.UserID, func(database.Post) bool { return true })
+	return posts[:min(int(arg.Limit), len(posts))], nil
+}
+
+func (m *Memory) GetPrunablePosts(ctx context.Context, arg database.GetPrunablePostsParams) ([]database.GetPrunablePostsRow, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var posts []database.Post
+	for _, post := range m.posts {
+		if post.FeedID == arg.FeedID {
+			posts = append(posts, post)
+		}
+	}
+	slices.SortStableFunc(posts, func(a, b database.Post) int {
+		if c := b.PublishedAt.Compare(a.PublishedAt); c != 0 {
+			return c
+		}
+		return strings.Compare(a.ID.String(), b.ID.String())
+	})
+	var rows []database.GetPrunablePostsRow
+	for i, post := range posts {
+		expired := post.PublishedAt.Before(arg.PublishedBefore) || (arg.KeepLast > 0 && i >= int(arg.KeepLast))
+		if !expired || m.isStarred(post) {
+			continue
+		}
+		rows = append(rows, database.GetPrunablePostsRow{
+			ID:          post.ID,
+			FeedID:      post.FeedID,
+			Title:       post.Title,
+			Url:         post.Url,
+			PublishedAt: post.PublishedAt,
+			Unread:      m.isUnread(post),
+		})
+	}
+	slices.Reverse(rows)
+	return rows, nil
+}
+
+// isStarred reports whether anyone starred the post.
+func (m *Memory) isStarred(post database.Post) bool {
+	for _, state := range m.states {
+		if state.PostID == post.ID && state.Starred {
+			return true
+		}
+	}
+	return false
+}
+
+// isUnread reports whether a follower of the post's feed hasn't read it.
+func (m *Memory) isUnread(post database.Post) bool {
+	for _, follow := range m.follows {
+		if state, _ := m.state(follow.UserID, post.ID); follow.FeedID == post.FeedID && !state.ReadAt.Valid {
+			return true
+		}
+	}
+	return false
+}
+
+func (m *Memory) DeletePosts(ctx context.Context, ids []uuid.UUID) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.deletePosts(func(p database.Post) bool { return slices.Contains(ids, p.ID) })
+	return nil
+}
+
+func (m *Memory) DeleteAllPosts(ctx context.Context) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.deletePosts(func(database.Post) bool { return true })
+	return nil
+}
+
+// deletePosts deletes the posts drop returns true for, with their
+// enclosures and states.
+func (m *Memory) deletePosts(drop func(database.Post) bool) {
+	var dropped []uuid.UUID
+	m.posts = slices.DeleteFunc(m.posts, func(p database.Post) bool {
+		if drop(p) {
+			dropped = append(dropped, p.ID)
+			return true
+		}
+		return false
+	})
+	m.enclosures = slices.DeleteFunc(m.enclosures, func(e database.PostEnclosure) bool { return slices.Contains(dropped, e.PostID) })
+	m.states = slices.DeleteFunc(m.states, func(s database.PostState) bool { return slices.Contains(dropped, s.PostID) })
+}
+
+func (m *Memory) GetAllPosts(ctx context.Context) ([]database.Post, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return slices.Clone(m.posts), nil
+}
+
+func (m *Memory) GetAllPostEnclosures(ctx context.Context) ([]database.PostEnclosure, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return slices.Clone(m.enclosures), nil
+}
+
+func (m *Memory) GetAllPostStates(ctx context.Context) ([]database.PostState, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return slices.Clone(m.states), nil
+}
+
+func (m *Memory) CreatePostEnclosure(ctx context.Context, arg database.CreatePostEnclosureParams) (database.PostEnclosure, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if !slices.ContainsFunc(m.posts, func(p database.Post) bool { return p.ID == arg.PostID }) {
+		return database.PostEnclosure{}, fmt.Errorf("no post %s", arg.PostID)
+	}
+	if slices.ContainsFunc(m.enclosures, func(e database.PostEnclosure) bool { return e.PostID == arg.PostID && e.Url == arg.Url }) {
+		return database.PostEnclosure{}, fmt.Errorf("enclosure %q %w", arg.Url, errDuplicate)
+	}
+	enclosure := database.PostEnclosure(arg)
+	m.enclosures = append(m.enclosures, enclosure)
+	return enclosure, nil
+}
+
+func (m *Memory) CreatePostEnclosures(ctx context.Context, args []database.CreatePostEnclosureParams) error {
+	for _, arg := range args {
+		_, err := m.CreatePostEnclosure(ctx, arg)
+		if err != nil && !IsDuplicate(err) {
+			return err
+		}
+	}
+	return nil
+}
+
+func (m *Memory) GetEnclosuresForPost(ctx context.Context, postID uuid.UUID) ([]database.PostEnclosure, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var enclosures []database.PostEnclosure
+	for _, enclosure := range m.enclosures {
+		if enclosure.PostID == postID {
+			enclosures = append(enclosures, enclosure)
+		}
+	}
+	slices.SortStableFunc(enclosures, func(a, b database.PostEnclosure) int {
+		return a.CreatedAt.Compare(b.CreatedAt)
+	})
+	return enclosures, nil
+}
+
+// setState applies change to the user's state for the post, creating it
+// from the other fields when there is none.
+func (m *Memory) setState(created database.PostState, change func(*database.PostState)) {
+	for i := range m.states {
+		if m.states[i].UserID == created.UserID && m.states[i].PostID == created.PostID {
+			change(&m.states[i])
+			m.states[i].UpdatedAt = created.UpdatedAt
+			return
+		}
+	}
+	change(&created)
+	m.states = append(m.states, created)
+}
+
+func (m *Memory) SetPostRead(ctx context.Context, arg database.SetPostReadParams) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	created := database.PostState{ID: arg.ID, CreatedAt: arg.CreatedAt, UpdatedAt: arg.UpdatedAt, UserID: arg.UserID, PostID: arg.PostID}
+	m.setState(created, func(s *database.PostState) { s.ReadAt = arg.ReadAt })
+	return nil
+}
+
+func (m *Memory) SetPostStarred(ctx context.Context, arg database.SetPostStarredParams) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	created := database.PostState{ID: arg.ID, CreatedAt: arg.CreatedAt, UpdatedAt: arg.UpdatedAt, UserID: arg.UserID, PostID: arg.PostID}
+	m.setState(created, func(s *database.PostState) { s.Starred = arg.Starred })
+	return nil
+}
+
+func (m *Memory) RestorePostState(ctx context.Context, arg database.RestorePostStateParams) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if _, ok := m.user(arg.UserID); !ok {
+		return fmt.Errorf("no user %s", arg.UserID)
+	}
+	if !slices.ContainsFunc(m.posts, func(p database.Post) bool { return p.ID == arg.PostID }) {
+		return fmt.Errorf("no post %s", arg.PostID)
+	}
+	if _, ok := m.state(arg.UserID, arg.PostID); ok {
+		return fmt.Errorf("state for post %s %w", arg.PostID, errDuplicate)
+	}
+	m.states = append(m.states, database.PostState(arg))
+	return nil
+}
+
+func (m *Memory) GetPostsWithStateForUser(ctx context.Context, arg database.GetPostsWithStateForUserParams) ([]database.GetPostsWithStateForUserRow, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	posts := m.newestPosts(arg.UserID, func(post database.Post) bool {
+		state, _ := m.state(arg.UserID, post.ID)
+		return (!arg.FeedID.Valid || post.FeedID == arg.FeedID.UUID) &&
+			(!arg.UnreadOnly || !state.ReadAt.Valid) &&
+			(!arg.StarredOnly || state.Starred)
+	})
+	var rows []database.GetPostsWithStateForUserRow
+	for _, post := range posts[:min(int(arg.Limit), len(posts))] {
+		state, _ := m.state(arg.UserID, post.ID)
+		feed, _ := m.feed(post.FeedID)
+		rows = append(rows, database.GetPostsWithStateForUserRow{
+			ID:           post.ID,
+			CreatedAt:    post.CreatedAt,
+			UpdatedAt:    post.UpdatedAt,
+			Title:        post.Title,
+			Url:          post.Url,
+			Description:  post.Description,
+			PublishedAt:  post.PublishedAt,
+			FeedID:       post.FeedID,
+			ThumbnailUrl: post.ThumbnailUrl,
+			Read:         state.ReadAt.Valid,
+			Starred:      state.Starred,
+			FeedName:     feed.Name,
+		})
+	}
+	return rows, nil
+}
+
+func (m *Memory) GetUnreadCountsForUser(ctx context.Context, userID uuid.UUID) ([]database.GetUnreadCountsForUserRow, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var rows []database.GetUnreadCountsForUserRow
+	for _, follow := range m.follows {
+		if follow.UserID != userID {
+			continue
+		}
+		feed, _ := m.feed(follow.FeedID)
+		row := database.GetUnreadCountsForUserRow{ID: feed.ID, Name: feed.Name, Url: feed.Url}
+		for _, post := range m.posts {
+			if state, _ := m.state(userID, post.ID); post.FeedID == feed.ID && !state.ReadAt.Valid {
+				row.Unread++
+			}
+		}
+		rows = append(rows, row)
+	}
+	slices.SortStableFunc(rows, func(a, b database.GetUnreadCountsForUserRow) int {
+		return strings.Compare(a.Name, b.Name)
+	})
+	return rows, nil
+}
@@ -0,0 +1,89 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+
+	"github.com/WagnerJust/go-gator/internal/database"
+	"github.com/google/uuid"
+)
+
+// postgresStore is the Store for Postgres: the generated queries, plus the
+// batches and transactions they can't express alone. db is nil inside a
+// transaction.
+type postgresStore struct {
+	*database.Queries
+	db *sql.DB
+}
+
+func (p *postgresStore) InTx(ctx context.Context, fn func(Store) error) error {
+	if p.db == nil {
+		return fn(p)
+	}
+	tx, err := p.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	err = fn(&postgresStore{Queries: p.Queries.WithTx(tx)})
+	if err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// InSnapshot runs fn in a read-only repeatable read transaction, which
+// Postgres serves from one snapshot taken at its first query.
+func (p *postgresStore) InSnapshot(ctx context.Context, fn func(Store) error) error {
+	if p.db == nil {
+		return fn(p)
+	}
+	tx, err := p.db.BeginTx(ctx, &sql.TxOptions{Isolation: sql.LevelRepeatableRead, ReadOnly: true})
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	err = fn(&postgresStore{Queries: p.Queries.WithTx(tx)})
+	if err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func (p *postgresStore) CreatePosts(ctx context.Context, args []database.CreatePostParams) ([]database.Post, error) {
+	if len(args) == 0 {
+		return nil, nil
+	}
+	batch, err := marshalPosts(args)
+	if err != nil {
+		return nil, err
+	}
+	posts, err := p.InsertPosts(ctx, batch)
+	if err != nil {
+		return nil, err
+	}
+	return inInputOrder(args, posts), nil
+}
+
+func (p *postgresStore) CreatePostEnclosures(ctx context.Context, args []database.CreatePostEnclosureParams) error {
+	if len(args) == 0 {
+		return nil
+	}
+	batch, err := marshalEnclosures(args)
+	if err != nil {
+		return err
+	}
+	return p.InsertPostEnclosures(ctx, batch)
+}
+
+func (p *postgresStore) DeletePosts(ctx context.Context, ids []uuid.UUID) error {
+	if len(ids) == 0 {
+		return nil
+	}
+	batch, err := json.Marshal(ids)
+	if err != nil {
+		return err
+	}
+	return p.DeletePostsByID(ctx, batch)
+}
@@ -0,0 +1,329 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"time"
+
+	"github.com/WagnerJust/go-gator/internal/database"
+	"github.com/WagnerJust/go-gator/internal/sqlitedb"
+	"github.com/google/uuid"
+)
+
+// sqliteQueries is the Store for SQLite, using the queries generated from
+// sql/sqlite. The generated rows and params have the same fields as the
+// Postgres ones, so most methods only convert between the two. db is nil
+// inside a transaction.
+type sqliteQueries struct {
+	db *sql.DB
+	q  *sqlitedb.Queries
+}
+
+func (s *sqliteQueries) InTx(ctx context.Context, fn func(Store) error) error {
+	if s.db == nil {
+		return fn(s)
+	}
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	err = fn(&sqliteQueries{q: s.q.WithTx(tx)})
+	if err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// InSnapshot runs fn in a read-only transaction. With the WAL journal, a
+// SQLite transaction reads from the snapshot taken at its first query.
+func (s *sqliteQueries) InSnapshot(ctx context.Context, fn func(Store) error) error {
+	if s.db == nil {
+		return fn(s)
+	}
+	tx, err := s.db.BeginTx(ctx, &sql.TxOptions{ReadOnly: true})
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	err = fn(&sqliteQueries{q: s.q.WithTx(tx)})
+	if err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func convertAll[T, U any](rows []T, convert func(T) U) []U {
+	if rows == nil {
+		return nil
+	}
+	converted := make([]U, len(rows))
+	for i, row := range rows {
+		converted[i] = convert(row)
+	}
+	return converted
+}
+
+func (s *sqliteQueries) CreateFeed(ctx context.Context, arg database.CreateFeedParams) (database.Feed, error) {
+	feed, err := s.q.CreateFeed(ctx, sqlitedb.CreateFeedParams(arg))
+	return database.Feed(feed), err
+}
+
+// CreateFeedFollow inserts the follow and reads it back with the feed and
+// user names in one transaction, as SQLite can't do both in one statement.
+func (s *sqliteQueries) CreateFeedFollow(ctx context.Context, arg database.CreateFeedFollowParams) (database.CreateFeedFollowRow, error) {
+	var row database.CreateFeedFollowRow
+	err := s.InTx(ctx, func(tx Store) error {
+		q := tx.(*sqliteQueries).q
+		follow, err := q.InsertFeedFollow(ctx, sqlitedb.InsertFeedFollowParams(arg))
+		if err != nil {
+			return err
+		}
+		created, err := q.GetFeedFollow(ctx, follow.ID)
+		row = database.CreateFeedFollowRow(created)
+		return err
+	})
+	return row, err
+}
+
+func (s *sqliteQueries) CreatePost(ctx context.Context, arg database.CreatePostParams) (database.Post, error) {
+	post, err := s.q.CreatePost(ctx, sqlitedb.CreatePostParams(arg))
+	return database.Post(post), err
+}
+
+func (s *sqliteQueries) CreatePosts(ctx context.Context, args []database.CreatePostParams) ([]database.Post, error) {
+	if len(args) == 0 {
+		return nil, nil
+	}
+	batch, err := marshalPosts(args)
+	if err != nil {
+		return nil, err
+	}
+	posts, err := s.q.InsertPosts(ctx, string(batch))
+	if err != nil {
+		return nil, err
+	}
+	return inInputOrder(args, convertAll(posts, func(p sqlitedb.Post) database.Post { return database.Post(p) })), nil
+}
+
+func (s *sqliteQueries) CreatePostEnclosures(ctx context.Context, args []database.CreatePostEnclosureParams) error {
+	if len(args) == 0 {
+		return nil
+	}
+	batch, err := marshalEnclosures(args)
+	if err != nil {
+		return err
+	}
+	return s.q.InsertPostEnclosures(ctx, string(batch))
+}
+
+func (s *sqliteQueries) DeletePosts(ctx context.Context, ids []uuid.UUID) error {
+	if len(ids) == 0 {
+		return nil
+	}
+	batch, err := json.Marshal(ids)
+	if err != nil {
+		return err
+	}
+	return s.q.DeletePostsByID(ctx, string(batch))
+}
+
+func (s *sqliteQueries) CreatePostEnclosure(ctx context.Context, arg database.CreatePostEnclosureParams) (database.PostEnclosure, error) {
+	enclosure, err := s.q.CreatePostEnclosure(ctx, sqlitedb.CreatePostEnclosureParams(arg))
+	return database.PostEnclosure(enclosure), err
+}
+
+func (s *sqliteQueries) CreateUser(ctx context.Context, arg database.CreateUserParams) (database.User, error) {
+	user, err := s.q.CreateUser(ctx, sqlitedb.CreateUserParams(arg))
+	return database.User(user), err
+}
+
+func (s *sqliteQueries) DeleteAllUsers(ctx context.Context) error {
+	return s.q.DeleteAllUsers(ctx)
+}
+
+func (s *sqliteQueries) DeleteUser(ctx context.Context, id uuid.UUID) error {
+	return s.q.DeleteUser(ctx, id)
+}
+
+func (s *sqliteQueries) DeleteAllFeeds(ctx context.Context) error {
+	return s.q.DeleteAllFeeds(ctx)
+}
+
+func (s *sqliteQueries) RestoreFeed(ctx context.Context, arg database.RestoreFeedParams) error {
+	return s.q.RestoreFeed(ctx, sqlitedb.RestoreFeedParams(arg))
+}
+
+func (s *sqliteQueries) RestorePostState(ctx context.Context, arg database.RestorePostStateParams) error {
+	return s.q.RestorePostState(ctx, sqlitedb.RestorePostStateParams(arg))
+}
+
+func (s *sqliteQueries) DeleteAllPosts(ctx context.Context) error {
+	return s.q.DeleteAllPosts(ctx)
+}
+
+func (s *sqliteQueries) DeleteFeedFollowByUser(ctx context.Context, arg database.DeleteFeedFollowByUserParams) error {
+	return s.q.DeleteFeedFollowByUser(ctx, sqlitedb.DeleteFeedFollowByUserParams(arg))
+}
+
+func (s *sqliteQueries) GetAllFeeds(ctx context.Context) ([]database.Feed, error) {
+	feeds, err := s.q.GetAllFeeds(ctx)
+	return convertAll(feeds, func(f sqlitedb.Feed) database.Feed { return database.Feed(f) }), err
+}
+
+func (s *sqliteQueries) GetAllFeedsWithUsers(ctx context.Context) ([]database.GetAllFeedsWithUsersRow, error) {
+	rows, err := s.q.GetAllFeedsWithUsers(ctx)
+	return convertAll(rows, func(r sqlitedb.GetAllFeedsWithUsersRow) database.GetAllFeedsWithUsersRow {
+		return database.GetAllFeedsWithUsersRow(r)
+	}), err
+}
+
+func (s *sqliteQueries) GetAllFeedFollows(ctx context.Context) ([]database.FeedFollow, error) {
+	follows, err := s.q.GetAllFeedFollows(ctx)
+	return convertAll(follows, func(f sqlitedb.FeedFollow) database.FeedFollow { return database.FeedFollow(f) }), err
+}
+
+func (s *sqliteQueries) GetAllPosts(ctx context.Context) ([]database.Post, error) {
+	posts, err := s.q.GetAllPosts(ctx)
+	return convertAll(posts, func(p sqlitedb.Post) database.Post { return database.Post(p) }), err
+}
+
+func (s *sqliteQueries) GetAllPostEnclosures(ctx context.Context) ([]database.PostEnclosure, error) {
+	enclosures, err := s.q.GetAllPostEnclosures(ctx)
+	return convertAll(enclosures, func(e sqlitedb.PostEnclosure) database.PostEnclosure { return database.PostEnclosure(e) }), err
+}
+
+func (s *sqliteQueries) GetAllPostStates(ctx context.Context) ([]database.PostState, error) {
+	states, err := s.q.GetAllPostStates(ctx)
+	return convertAll(states, func(p sqlitedb.PostState) database.PostState { return database.PostState(p) }), err
+}
+
+func (s *sqliteQueries) GetAllUsers(ctx context.Context) ([]database.User, error) {
+	users, err := s.q.GetAllUsers(ctx)
+	return convertAll(users, func(u sqlitedb.User) database.User { return database.User(u) }), err
+}
+
+func (s *sqliteQueries) GetEnclosuresForPost(ctx context.Context, postID uuid.UUID) ([]database.PostEnclosure, error) {
+	enclosures, err := s.q.GetEnclosuresForPost(ctx, postID)
+	return convertAll(enclosures, func(e sqlitedb.PostEnclosure) database.PostEnclosure { return database.PostEnclosure(e) }), err
+}
+
+func (s *sqliteQueries) GetFeedByID(ctx context.Context, id uuid.UUID) (database.Feed, error) {
+	feed, err := s.q.GetFeedByID(ctx, id)
+	return database.Feed(feed), err
+}
+
+func (s *sqliteQueries) GetFeedByUrl(ctx context.Context, url string) (database.Feed, error) {
+	feed, err := s.q.GetFeedByUrl(ctx, url)
+	return database.Feed(feed), err
+}
+
+func (s *sqliteQueries) GetFeedFollowsForUser(ctx context.Context, id uuid.UUID) ([]database.GetFeedFollowsForUserRow, error) {
+	rows, err := s.q.GetFeedFollowsForUser(ctx, id)
+	return convertAll(rows, func(r sqlitedb.GetFeedFollowsForUserRow) database.GetFeedFollowsForUserRow {
+		return database.GetFeedFollowsForUserRow(r)
+	}), err
+}
+
+func (s *sqliteQueries) GetNextFeedToFetch(ctx context.Context) (database.Feed, error) {
+	feed, err := s.q.GetNextFeedToFetch(ctx)
+	return database.Feed(feed), err
+}
+
+func (s *sqliteQueries) GetNextFeedsToFetch(ctx context.Context, limit int32) ([]database.Feed, error) {
+	feeds, err := s.q.GetNextFeedsToFetch(ctx, int64(limit))
+	return convertAll(feeds, func(f sqlitedb.Feed) database.Feed { return database.Feed(f) }), err
+}
+
+func (s *sqliteQueries) GetPostByID(ctx context.Context, id uuid.UUID) (database.Post, error) {
+	post, err := s.q.GetPostByID(ctx, id)
+	return database.Post(post), err
+}
+
+func (s *sqliteQueries) GetPrunablePosts(ctx context.Context, arg database.GetPrunablePostsParams) ([]database.GetPrunablePostsRow, error) {
+	rows, err := s.q.GetPrunablePosts(ctx, sqlitedb.GetPrunablePostsParams{
+		FeedID:          arg.FeedID,
+		PublishedBefore: arg.PublishedBefore,
+		KeepLast:        int64(arg.KeepLast),
+	})
+	return convertAll(rows, func(r sqlitedb.GetPrunablePostsRow) database.GetPrunablePostsRow {
+		return database.GetPrunablePostsRow{
+			ID:          r.ID,
+			FeedID:      r.FeedID,
+			Title:       r.Title,
+			Url:         r.Url,
+			PublishedAt: r.PublishedAt,
+			Unread:      r.Unread != 0,
+		}
+	}), err
+}
+
+func (s *sqliteQueries) GetPostsForUser(ctx context.Context, arg database.GetPostsForUserParams) ([]database.Post, error) {
+	posts, err := s.q.GetPostsForUser(ctx, sqlitedb.GetPostsForUserParams{UserID: arg.UserID, Limit: int64(arg.Limit)})
+	return convertAll(posts, func(p sqlitedb.Post) database.Post { return database.Post(p) }), err
+}
+
+func (s *sqliteQueries) GetPostsWithStateForUser(ctx context.Context, arg database.GetPostsWithStateForUserParams) ([]database.GetPostsWithStateForUserRow, error) {
+	rows, err := s.q.GetPostsWithStateForUser(ctx, sqlitedb.GetPostsWithStateForUserParams{
+		UserID:      arg.UserID,
+		FeedID:      arg.FeedID,
+		UnreadOnly:  arg.UnreadOnly,
+		StarredOnly: arg.StarredOnly,
+		Limit:       int64(arg.Limit),
+	})
+	return convertAll(rows, func(r sqlitedb.GetPostsWithStateForUserRow) database.GetPostsWithStateForUserRow {
+		return database.GetPostsWithStateForUserRow(r)
+	}), err
+}
+
+func (s *sqliteQueries) GetUnreadCountsForUser(ctx context.Context, userID uuid.UUID) ([]database.GetUnreadCountsForUserRow, error) {
+	rows, err := s.q.GetUnreadCountsForUser(ctx, userID)
+	return convertAll(rows, func(r sqlitedb.GetUnreadCountsForUserRow) database.GetUnreadCountsForUserRow {
+		return database.GetUnreadCountsForUserRow(r)
+	}), err
+}
+
+func (s *sqliteQueries) GetUserByName(ctx context.Context, name string) (database.User, error) {
+	user, err := s.q.GetUserByName(ctx, name)
+	return database.User(user), err
+}
+
+func (s *sqliteQueries) RenameUser(ctx context.Context, arg database.RenameUserParams) (database.User, error) {
+	user, err := s.q.RenameUser(ctx, sqlitedb.RenameUserParams(arg))
+	return database.User(user), err
+}
+
+func (s *sqliteQueries) GetUserStats(ctx context.Context, userID uuid.UUID) (database.GetUserStatsRow, error) {
+	stats, err := s.q.GetUserStats(ctx, userID)
+	return database.GetUserStatsRow(stats), err
+}
+
+func (s *sqliteQueries) GetUserDeletionImpact(ctx context.Context, userID uuid.UUID) (database.GetUserDeletionImpactRow, error) {
+	impact, err := s.q.GetUserDeletionImpact(ctx, userID)
+	return database.GetUserDeletionImpactRow(impact), err
+}
+
+// MarkFeedFetched passes the time in rather than using CURRENT_TIMESTAMP,
+// whose text has no fraction or offset and so wouldn't sort among the
+// times written from Go.
+func (s *sqliteQueries) MarkFeedFetched(ctx context.Context, id uuid.UUID) error {
+	now := time.Now().UTC()
+	return s.q.MarkFeedFetched(ctx, sqlitedb.MarkFeedFetchedParams{
+		LastFetchedAt: sql.NullTime{Time: now, Valid: true},
+		UpdatedAt:     now,
+		ID:            id,
+	})
+}
+
+func (s *sqliteQueries) SetFeedFetchWarning(ctx context.Context, arg database.SetFeedFetchWarningParams) error {
+	return s.q.SetFeedFetchWarning(ctx, sqlitedb.SetFeedFetchWarningParams{ID: arg.ID, LastFetchWarning: arg.LastFetchWarning})
+}
+
+func (s *sqliteQueries) SetPostRead(ctx context.Context, arg database.SetPostReadParams) error {
+	return s.q.SetPostRead(ctx, sqlitedb.SetPostReadParams(arg))
+}
+
+func (s *sqliteQueries) SetPostStarred(ctx context.Context, arg database.SetPostStarredParams) error {
+	return s.q.SetPostStarred(ctx, sqlitedb.SetPostStarredParams(arg))
+}
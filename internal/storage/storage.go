@@ -0,0 +1,222 @@
+// Package storage opens gator's database, Postgres or an embedded SQLite
+// file, behind the Store interfaces the commands use.
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/WagnerJust/go-gator/internal/database"
+	"github.com/WagnerJust/go-gator/internal/sqlitedb"
+	"github.com/lib/pq"
+	"modernc.org/sqlite"
+	sqlite3 "modernc.org/sqlite/lib"
+)
+
+// Driver names, as registered with database/sql.
+const (
+	Postgres = "postgres"
+	SQLite   = "sqlite"
+)
+
+// sqlitePragmas are applied to every SQLite connection: wait for other
+// writers such as a running agg instead of failing, let readers work
+// alongside a writer, and enforce the ON DELETE CASCADEs.
+const sqlitePragmas = "_pragma=busy_timeout(5000)&_pragma=journal_mode(WAL)&_pragma=foreign_keys(1)&_time_format=sqlite"
+
+// ErrNoDbUrl is returned for an empty db_url.
+var ErrNoDbUrl = errors.New("db_url is not set; add it to the config, set GATOR_DB_URL or pass --db-url")
+
+// Driver returns the driver for dbUrl, or "" when it isn't a database URL
+// gator recognises. sqlite: and file: URLs and SQLite file paths are SQLite;
+// postgres:// URLs and libpq "host=... dbname=..." strings are Postgres.
+// A file path is SQLite only when it looks like one, starting with a
+// directory such as "/", "./" or "~/" or ending in .db, .sqlite or
+// .sqlite3, so a mistyped URL isn't taken for a file name.
+func Driver(dbUrl string) string {
+	switch {
+	case strings.HasPrefix(dbUrl, "sqlite:") || strings.HasPrefix(dbUrl, "file:"):
+		return SQLite
+	case strings.HasPrefix(dbUrl, "postgres://") || strings.HasPrefix(dbUrl, "postgresql://"):
+		return Postgres
+	case strings.Contains(dbUrl, "://"):
+		return ""
+	case isKeyValueDSN(dbUrl):
+		return Postgres
+	case isSQLitePath(dbUrl):
+		return SQLite
+	}
+	return ""
+}
+
+// CheckDbUrl returns why dbUrl can't be opened, or nil if it can.
+func CheckDbUrl(dbUrl string) error {
+	if dbUrl == "" {
+		return ErrNoDbUrl
+	}
+	switch Driver(dbUrl) {
+	case "":
+		return fmt.Errorf("%q is not a database URL gator recognises; use a postgres:// URL, a libpq \"host=... dbname=...\" string, or a SQLite file such as ~/gator.db or sqlite:///var/lib/gator.db", dbUrl)
+	case Postgres:
+		if !strings.Contains(dbUrl, "://") {
+			return nil
+		}
+		u, err := url.Parse(dbUrl)
+		if err != nil {
+			return fmt.Errorf("%q is not a database URL: %w", dbUrl, err)
+		}
+		if u.Host == "" && u.Query().Get("host") == "" {
+			return fmt.Errorf("%q has no host", dbUrl)
+		}
+	}
+	return nil
+}
+
+func isSQLitePath(dbUrl string) bool {
+	path, _, _ := strings.Cut(dbUrl, "?")
+	for _, ext := range []string{".db", ".sqlite", ".sqlite3"} {
+		if strings.HasSuffix(path, ext) {
+			return true
+		}
+	}
+	for _, prefix := range []string{"/", "./", "../", "~/"} {
+		if strings.HasPrefix(path, prefix) {
+			return true
+		}
+	}
+	return filepath.IsAbs(path)
+}
+
+// libpqKeys are the connection parameters that mark a libpq key=value
+// string, so that file paths which merely contain "=" stay SQLite.
+var libpqKeys = map[string]bool{
+	"host": true, "hostaddr": true, "port": true, "dbname": true,
+	"user": true, "password": true, "sslmode": true, "connect_timeout": true,
+	"application_name": true, "service": true,
+}
+
+func isKeyValueDSN(dbUrl string) bool {
+	for _, field := range strings.Fields(dbUrl) {
+		key, _, ok := strings.Cut(field, "=")
+		if ok && libpqKeys[key] {
+			return true
+		}
+	}
+	return false
+}
+
+// SQLitePath returns the database file a SQLite dbUrl points at, with a
+// leading ~ expanded to the home directory.
+func SQLitePath(dbUrl string) string {
+	path := dbUrl
+	for _, prefix := range []string{"sqlite://", "sqlite:", "file:"} {
+		if rest, ok := strings.CutPrefix(path, prefix); ok {
+			path = rest
+			break
+		}
+	}
+	path, _, _ = strings.Cut(path, "?")
+	if rest, ok := strings.CutPrefix(path, "~/"); ok {
+		if home, err := os.UserHomeDir(); err == nil {
+			path = filepath.Join(home, rest)
+		}
+	}
+	return path
+}
+
+// Open opens dbUrl with its driver, or returns CheckDbUrl's error. Like
+// sql.Open it doesn't connect; for SQLite the file's directory is created
+// on first connecting, so that the first query can create the file.
+func Open(dbUrl string) (*sql.DB, error) {
+	err := CheckDbUrl(dbUrl)
+	if err != nil {
+		return nil, err
+	}
+	if Driver(dbUrl) == Postgres {
+		return sql.Open(Postgres, dbUrl)
+	}
+	path := SQLitePath(dbUrl)
+	dsn := "file:" + path + "?" + sqlitePragmas
+	if _, query, ok := strings.Cut(dbUrl, "?"); ok && query != "" {
+		dsn += "&" + query
+	}
+	return sql.OpenDB(sqliteConnector{dir: filepath.Dir(path), dsn: dsn}), nil
+}
+
+// sqliteConnector opens SQLite connections, creating the database's
+// directory first.
+type sqliteConnector struct {
+	dir string
+	dsn string
+}
+
+func (c sqliteConnector) Connect(ctx context.Context) (driver.Conn, error) {
+	err := os.MkdirAll(c.dir, 0700)
+	if err != nil {
+		return nil, err
+	}
+	return c.Driver().Open(c.dsn)
+}
+
+func (c sqliteConnector) Driver() driver.Driver {
+	return &sqlite.Driver{}
+}
+
+// Unavailable returns a database every connection to which fails with err,
+// standing in for one whose db_url can't be used so that only commands
+// that query it fail.
+func Unavailable(err error) *sql.DB {
+	return sql.OpenDB(unavailableConnector{err})
+}
+
+type unavailableConnector struct {
+	err error
+}
+
+func (c unavailableConnector) Connect(ctx context.Context) (driver.Conn, error) {
+	return nil, c.err
+}
+
+func (c unavailableConnector) Driver() driver.Driver {
+	return unavailableDriver(c)
+}
+
+type unavailableDriver struct {
+	err error
+}
+
+func (d unavailableDriver) Open(name string) (driver.Conn, error) {
+	return nil, d.err
+}
+
+// New returns the Store for db, opened with driver.
+func New(db *sql.DB, driver string) Store {
+	if driver == SQLite {
+		return &sqliteQueries{db: db, q: sqlitedb.New(db)}
+	}
+	return &postgresStore{Queries: database.New(db), db: db}
+}
+
+// IsDuplicate reports whether err is a unique constraint violation from
+// any Store, such as a post whose URL is already stored.
+func IsDuplicate(err error) bool {
+	if errors.Is(err, errDuplicate) {
+		return true
+	}
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) {
+		return pqErr.Code == "23505"
+	}
+	var sqliteErr *sqlite.Error
+	if errors.As(err, &sqliteErr) {
+		return sqliteErr.Code() == sqlite3.SQLITE_CONSTRAINT_UNIQUE || sqliteErr.Code() == sqlite3.SQLITE_CONSTRAINT_PRIMARYKEY
+	}
+	return false
+}
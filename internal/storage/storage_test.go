@@ -0,0 +1,611 @@
+package storage_test
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/WagnerJust/go-gator/internal/database"
+	"github.com/WagnerJust/go-gator/internal/migrate"
+	"github.com/WagnerJust/go-gator/internal/storage"
+	"github.com/google/uuid"
+)
+
+func TestDriver(t *testing.T) {
+	cases := map[string]string{
+		"postgres://justin@localhost:5432/gogator?sslmode=disable": storage.Postgres,
+		"postgresql://localhost/gator":                             storage.Postgres,
+		"host=localhost dbname=gator":                              storage.Postgres,
+		"sqlite:///home/justin/gator.db":                           storage.SQLite,
+		"sqlite://~/gator.db":                                      storage.SQLite,
+		"/home/justin/gator.db":                                    storage.SQLite,
+		"gator.db":                                                 storage.SQLite,
+		"dbname=gator":                                             storage.Postgres,
+		"user=gator password=secret host=/run/postgresql":          storage.Postgres,
+		"/data/run=1/gator.db":                                     storage.SQLite,
+		"backups/a=b.db":                                           storage.SQLite,
+		"./gator":                                                  storage.SQLite,
+		"":                                                         "",
+		"postgres//user@host/db":                                   "",
+		"localhost:5432/gator":                                     "",
+		"mysql://localhost/gator":                                  "",
+	}
+	for dbUrl, expected := range cases {
+		if driver := storage.Driver(dbUrl); driver != expected {
+			t.Errorf("expected %s for %q, got %s", expected, dbUrl, driver)
+		}
+	}
+	if path := storage.SQLitePath("sqlite:///var/lib/gator.db?cache=shared"); path != "/var/lib/gator.db" {
+		t.Errorf("unexpected path %q", path)
+	}
+}
+
+func TestOpenRejectsUnusableDbUrls(t *testing.T) {
+	_, err := storage.Open("")
+	if !errors.Is(err, storage.ErrNoDbUrl) {
+		t.Fatalf("expected ErrNoDbUrl for an empty db_url, got %v", err)
+	}
+	for _, dbUrl := range []string{"postgres//user@host/db", "localhost:5432/gator", "postgres:///gator"} {
+		_, err := storage.Open(dbUrl)
+		if err == nil {
+			t.Errorf("expected %q to be refused", dbUrl)
+		}
+	}
+}
+
+func TestOpenCreatesTheSQLiteDirectoryOnFirstQuery(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "data")
+	db, err := storage.Open("sqlite://" + filepath.Join(dir, "gator.db"))
+	if err != nil {
+		t.Fatalf("error opening database: %v", err)
+	}
+	defer db.Close()
+	if _, err := os.Stat(dir); !errors.Is(err, os.ErrNotExist) {
+		t.Fatalf("expected opening to leave the filesystem alone, got %v", err)
+	}
+	err = db.Ping()
+	if err != nil {
+		t.Fatalf("error connecting: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "gator.db")); err != nil {
+		t.Fatalf("expected the first connection to create the database: %v", err)
+	}
+}
+
+func openSQLite(t *testing.T) storage.Store {
+	t.Helper()
+	return storage.New(openSQLiteDB(t), storage.SQLite)
+}
+
+// openSQLiteDB returns a migrated SQLite database in a temporary directory.
+func openSQLiteDB(t *testing.T) *sql.DB {
+	t.Helper()
+	dbUrl := "sqlite://" + filepath.Join(t.TempDir(), "data", "gator.db")
+	db, err := storage.Open(dbUrl)
+	if err != nil {
+		t.Fatalf("error opening database: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+	migrator, err := migrate.New(db, storage.Driver(dbUrl))
+	if err != nil {
+		t.Fatalf("error loading migrations: %v", err)
+	}
+	_, err = migrator.Up(context.Background())
+	if err != nil {
+		t.Fatalf("error migrating: %v", err)
+	}
+	return db
+}
+
+func TestSQLiteFetchTimesMatchOtherTimes(t *testing.T) {
+	db := openSQLiteDB(t)
+	q := storage.New(db, storage.SQLite)
+	ctx := context.Background()
+	now := time.Now().UTC()
+	user, err := q.CreateUser(ctx, database.CreateUserParams{ID: uuid.New(), CreatedAt: now, UpdatedAt: now, Name: "shawn"})
+	if err != nil {
+		t.Fatalf("error creating user: %v", err)
+	}
+	feed, err := q.CreateFeed(ctx, database.CreateFeedParams{ID: uuid.New(), CreatedAt: now, UpdatedAt: now, Name: "Psych", Url: "https://example.com/feed", UserID: user.ID})
+	if err != nil {
+		t.Fatalf("error creating feed: %v", err)
+	}
+	err = q.MarkFeedFetched(ctx, feed.ID)
+	if err != nil {
+		t.Fatalf("error marking feed fetched: %v", err)
+	}
+
+	fetched, err := q.GetFeedByUrl(ctx, feed.Url)
+	if err != nil || !fetched.LastFetchedAt.Valid || fetched.LastFetchedAt.Time.Before(now) || time.Since(fetched.LastFetchedAt.Time) > time.Minute {
+		t.Fatalf("expected the feed to be fetched just now, got %+v (%v)", fetched, err)
+	}
+	var created, lastFetched string
+	err = db.QueryRow("SELECT created_at || '', last_fetched_at || '' FROM feeds WHERE id = ?", feed.ID).Scan(&created, &lastFetched)
+	if err != nil {
+		t.Fatalf("error reading times: %v", err)
+	}
+	layout := regexp.MustCompile(`^\d{4}-\d\d-\d\d \d\d:\d\d:\d\d(\.\d+)?\+00:00$`)
+	for _, value := range []string{created, lastFetched} {
+		if !layout.MatchString(value) {
+			t.Errorf("expected a UTC time with an offset, got %q", value)
+		}
+	}
+}
+
+func TestSQLiteStore(t *testing.T) {
+	testStore(t, openSQLite(t))
+}
+
+func TestMemoryStore(t *testing.T) {
+	testStore(t, storage.NewMemory())
+}
+
+func TestUserNamesIgnoreCase(t *testing.T) {
+	for name, q := range map[string]storage.Store{"sqlite": openSQLite(t), "memory": storage.NewMemory()} {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			now := time.Now().UTC()
+			shawn, err := q.CreateUser(ctx, database.CreateUserParams{ID: uuid.New(), CreatedAt: now, UpdatedAt: now, Name: "Shawn"})
+			if err != nil {
+				t.Fatalf("error creating user: %v", err)
+			}
+			_, err = q.CreateUser(ctx, database.CreateUserParams{ID: uuid.New(), CreatedAt: now, UpdatedAt: now, Name: "shawn"})
+			if !storage.IsDuplicate(err) {
+				t.Fatalf("expected a duplicate error for a name differing in case, got %v", err)
+			}
+			found, err := q.GetUserByName(ctx, "SHAWN")
+			if err != nil || found.ID != shawn.ID {
+				t.Fatalf("expected to find Shawn ignoring case, got %+v (%v)", found, err)
+			}
+
+			gus, err := q.CreateUser(ctx, database.CreateUserParams{ID: uuid.New(), CreatedAt: now, UpdatedAt: now, Name: "gus"})
+			if err != nil {
+				t.Fatalf("error creating user: %v", err)
+			}
+			_, err = q.RenameUser(ctx, database.RenameUserParams{ID: gus.ID, Name: "SHAWN", UpdatedAt: now})
+			if !storage.IsDuplicate(err) {
+				t.Fatalf("expected renaming onto a taken name to fail, got %v", err)
+			}
+			renamed, err := q.RenameUser(ctx, database.RenameUserParams{ID: shawn.ID, Name: "shawn", UpdatedAt: now})
+			if err != nil || renamed.Name != "shawn" {
+				t.Fatalf("expected a user to change their name's case, got %+v (%v)", renamed, err)
+			}
+			_, err = q.RenameUser(ctx, database.RenameUserParams{ID: uuid.New(), Name: "lassie", UpdatedAt: now})
+			if !errors.Is(err, sql.ErrNoRows) {
+				t.Fatalf("expected renaming an unknown user to find nothing, got %v", err)
+			}
+		})
+	}
+}
+
+func TestUserDeletionImpact(t *testing.T) {
+	for name, q := range map[string]storage.Store{"sqlite": openSQLite(t), "memory": storage.NewMemory()} {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			now := time.Now().UTC()
+			var users []database.User
+			for _, name := range []string{"gus", "shawn", "lassie"} {
+				user, err := q.CreateUser(ctx, database.CreateUserParams{ID: uuid.New(), CreatedAt: now, UpdatedAt: now, Name: name})
+				if err != nil {
+					t.Fatalf("error creating user: %v", err)
+				}
+				users = append(users, user)
+			}
+			gus := users[0]
+			var feeds []database.Feed
+			for _, url := range []string{"https://example.com/gus", "https://example.com/psych"} {
+				feed, err := q.CreateFeed(ctx, database.CreateFeedParams{ID: uuid.New(), CreatedAt: now, UpdatedAt: now, Name: url, Url: url, UserID: gus.ID})
+				if err != nil {
+					t.Fatalf("error creating feed: %v", err)
+				}
+				feeds = append(feeds, feed)
+			}
+			for _, follow := range []struct{ user, feed int }{{0, 0}, {1, 0}, {1, 1}, {2, 1}} {
+				_, err := q.CreateFeedFollow(ctx, database.CreateFeedFollowParams{ID: uuid.New(), CreatedAt: now, UpdatedAt: now, UserID: users[follow.user].ID, FeedID: feeds[follow.feed].ID})
+				if err != nil {
+					t.Fatalf("error following feed: %v", err)
+				}
+			}
+			_, err := q.CreatePost(ctx, database.CreatePostParams{ID: uuid.New(), CreatedAt: now, UpdatedAt: now, Title: "Episode", Url: "https://example.com/1", PublishedAt: now, FeedID: feeds[1].ID})
+			if err != nil {
+				t.Fatalf("error creating post: %v", err)
+			}
+
+			impact, err := q.GetUserDeletionImpact(ctx, gus.ID)
+			want := database.GetUserDeletionImpactRow{Posts: 1, OtherFollows: 3, OtherFollowers: 2}
+			if err != nil || impact != want {
+				t.Fatalf("expected %+v, got %+v (%v)", want, impact, err)
+			}
+			impact, err = q.GetUserDeletionImpact(ctx, users[1].ID)
+			if err != nil || impact != (database.GetUserDeletionImpactRow{}) {
+				t.Fatalf("expected nothing for a user who added no feeds, got %+v (%v)", impact, err)
+			}
+		})
+	}
+}
+
+func TestSnapshotIgnoresLaterWrites(t *testing.T) {
+	for name, q := range map[string]storage.Store{"sqlite": openSQLite(t), "memory": storage.NewMemory()} {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			now := time.Now().UTC()
+			_, err := q.CreateUser(ctx, database.CreateUserParams{ID: uuid.New(), CreatedAt: now, UpdatedAt: now, Name: "shawn"})
+			if err != nil {
+				t.Fatalf("error creating user: %v", err)
+			}
+			err = q.InSnapshot(ctx, func(snapshot storage.Store) error {
+				users, err := snapshot.GetAllUsers(ctx)
+				if err != nil || len(users) != 1 {
+					t.Fatalf("expected one user, got %+v (%v)", users, err)
+				}
+				_, err = q.CreateUser(ctx, database.CreateUserParams{ID: uuid.New(), CreatedAt: now, UpdatedAt: now, Name: "gus"})
+				if err != nil {
+					t.Fatalf("error creating user during the snapshot: %v", err)
+				}
+				users, err = snapshot.GetAllUsers(ctx)
+				if err != nil || len(users) != 1 {
+					t.Fatalf("expected the snapshot to still hold one user, got %+v (%v)", users, err)
+				}
+				return nil
+			})
+			if err != nil {
+				t.Fatalf("error reading the snapshot: %v", err)
+			}
+			users, err := q.GetAllUsers(ctx)
+			if err != nil || len(users) != 2 {
+				t.Fatalf("expected the write to be kept, got %+v (%v)", users, err)
+			}
+		})
+	}
+}
+
+func TestPostsSortAcrossZones(t *testing.T) {
+	for name, q := range map[string]storage.Store{"sqlite": openSQLite(t), "memory": storage.NewMemory()} {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			now := time.Now().UTC()
+			user, err := q.CreateUser(ctx, database.CreateUserParams{ID: uuid.New(), CreatedAt: now, UpdatedAt: now, Name: "shawn"})
+			if err != nil {
+				t.Fatalf("error creating user: %v", err)
+			}
+			feed, err := q.CreateFeed(ctx, database.CreateFeedParams{ID: uuid.New(), CreatedAt: now, UpdatedAt: now, Name: "Psych", Url: "https://example.com/feed", UserID: user.ID})
+			if err != nil {
+				t.Fatalf("error creating feed: %v", err)
+			}
+			_, err = q.CreateFeedFollow(ctx, database.CreateFeedFollowParams{ID: uuid.New(), CreatedAt: now, UpdatedAt: now, UserID: user.ID, FeedID: feed.ID})
+			if err != nil {
+				t.Fatalf("error following feed: %v", err)
+			}
+			// 22:00 in New York is 11:00 the next day in Tokyo, so the
+			// Tokyo post at 10:00 is older though it reads later.
+			newYork := time.FixedZone("EDT", -4*60*60)
+			tokyo := time.FixedZone("JST", 9*60*60)
+			_, err = q.CreatePosts(ctx, []database.CreatePostParams{
+				{ID: uuid.New(), CreatedAt: now, UpdatedAt: now, Title: "New York", Url: "https://example.com/ny", PublishedAt: time.Date(2006, 7, 7, 22, 0, 0, 0, newYork), FeedID: feed.ID},
+				{ID: uuid.New(), CreatedAt: now, UpdatedAt: now, Title: "Tokyo", Url: "https://example.com/tokyo", PublishedAt: time.Date(2006, 7, 8, 10, 0, 0, 0, tokyo), FeedID: feed.ID},
+			})
+			if err != nil {
+				t.Fatalf("error creating posts: %v", err)
+			}
+			posts, err := q.GetPostsForUser(ctx, database.GetPostsForUserParams{UserID: user.ID, Limit: 10})
+			if err != nil || len(posts) != 2 || posts[0].Title != "New York" {
+				t.Fatalf("expected the New York post first, got %+v (%v)", posts, err)
+			}
+			if !posts[0].PublishedAt.Equal(time.Date(2006, 7, 8, 2, 0, 0, 0, time.UTC)) {
+				t.Fatalf("expected the publish time to be kept, got %v", posts[0].PublishedAt)
+			}
+		})
+	}
+}
+
+// testStore checks the behavior the commands rely on, which every Store
+// must share.
+func testStore(t *testing.T, q storage.Store) {
+	ctx := context.Background()
+	now := time.Now().UTC().Truncate(time.Second)
+
+	user, err := q.CreateUser(ctx, database.CreateUserParams{ID: uuid.New(), CreatedAt: now, UpdatedAt: now, Name: "shawn"})
+	if err != nil {
+		t.Fatalf("error creating user: %v", err)
+	}
+	feed, err := q.CreateFeed(ctx, database.CreateFeedParams{ID: uuid.New(), CreatedAt: now, UpdatedAt: now, Name: "Psych", Url: "https://example.com/feed", UserID: user.ID})
+	if err != nil {
+		t.Fatalf("error creating feed: %v", err)
+	}
+	follow, err := q.CreateFeedFollow(ctx, database.CreateFeedFollowParams{ID: uuid.New(), CreatedAt: now, UpdatedAt: now, UserID: user.ID, FeedID: feed.ID})
+	if err != nil {
+		t.Fatalf("error following feed: %v", err)
+	}
+	if follow.FeedName != "Psych" || follow.UserName != "shawn" {
+		t.Fatalf("unexpected follow %+v", follow)
+	}
+
+	for i, published := range []time.Time{now.Add(-time.Hour), now} {
+		_, err = q.CreatePost(ctx, database.CreatePostParams{
+			ID: uuid.New(), CreatedAt: now, UpdatedAt: now,
+			Title:       "Episode",
+			Url:         "https://example.com/" + string(rune('a'+i)),
+			Description: sql.NullString{String: "Pineapple", Valid: true},
+			PublishedAt: published,
+			FeedID:      feed.ID,
+		})
+		if err != nil {
+			t.Fatalf("error creating post: %v", err)
+		}
+	}
+	posts, err := q.GetPostsForUser(ctx, database.GetPostsForUserParams{UserID: user.ID, Limit: 10})
+	if err != nil {
+		t.Fatalf("error getting posts: %v", err)
+	}
+	if len(posts) != 2 || !posts[0].PublishedAt.Equal(now) || posts[0].Description.String != "Pineapple" {
+		t.Fatalf("expected the newest post first, got %+v", posts)
+	}
+
+	enclosure := database.CreatePostEnclosureParams{ID: uuid.New(), CreatedAt: now, UpdatedAt: now, PostID: posts[0].ID, Url: "https://example.com/a.mp3"}
+	_, err = q.CreatePostEnclosure(ctx, enclosure)
+	if err != nil {
+		t.Fatalf("error creating enclosure: %v", err)
+	}
+	enclosure.ID = uuid.New()
+	_, err = q.CreatePostEnclosure(ctx, enclosure)
+	if !storage.IsDuplicate(err) {
+		t.Fatalf("expected a duplicate error for the same enclosure, got %v", err)
+	}
+	enclosures, err := q.GetEnclosuresForPost(ctx, posts[0].ID)
+	if err != nil || len(enclosures) != 1 {
+		t.Fatalf("expected one enclosure, got %+v (%v)", enclosures, err)
+	}
+
+	err = q.SetPostStarred(ctx, database.SetPostStarredParams{ID: uuid.New(), CreatedAt: now, UpdatedAt: now, UserID: user.ID, PostID: posts[0].ID, Starred: true})
+	if err != nil {
+		t.Fatalf("error starring post: %v", err)
+	}
+	err = q.SetPostRead(ctx, database.SetPostReadParams{ID: uuid.New(), CreatedAt: now, UpdatedAt: now, UserID: user.ID, PostID: posts[0].ID, ReadAt: sql.NullTime{Time: now, Valid: true}})
+	if err != nil {
+		t.Fatalf("error marking post read: %v", err)
+	}
+	starred, err := q.GetPostsWithStateForUser(ctx, database.GetPostsWithStateForUserParams{UserID: user.ID, StarredOnly: true, Limit: 10})
+	if err != nil {
+		t.Fatalf("error getting starred posts: %v", err)
+	}
+	if len(starred) != 1 || !starred[0].Starred || !starred[0].Read || starred[0].FeedName != "Psych" {
+		t.Fatalf("expected one starred, read post, got %+v", starred)
+	}
+	unread, err := q.GetPostsWithStateForUser(ctx, database.GetPostsWithStateForUserParams{UserID: user.ID, FeedID: uuid.NullUUID{UUID: feed.ID, Valid: true}, UnreadOnly: true, Limit: 10})
+	if err != nil || len(unread) != 1 || unread[0].Read {
+		t.Fatalf("expected one unread post, got %+v (%v)", unread, err)
+	}
+	counts, err := q.GetUnreadCountsForUser(ctx, user.ID)
+	if err != nil || len(counts) != 1 || counts[0].Unread != 1 {
+		t.Fatalf("expected one unread post in the feed, got %+v (%v)", counts, err)
+	}
+	stats, err := q.GetUserStats(ctx, user.ID)
+	want := database.GetUserStatsRow{FeedsAdded: 1, Follows: 1, ReadPosts: 1, StarredPosts: 1}
+	if err != nil || stats != want {
+		t.Fatalf("expected stats %+v, got %+v (%v)", want, stats, err)
+	}
+
+	_, err = q.CreateUser(ctx, database.CreateUserParams{ID: uuid.New(), CreatedAt: now, UpdatedAt: now, Name: "shawn"})
+	if !storage.IsDuplicate(err) {
+		t.Fatalf("expected a duplicate error for a taken name, got %v", err)
+	}
+
+	_, err = q.GetUserByName(ctx, "gus")
+	if !errors.Is(err, sql.ErrNoRows) {
+		t.Fatalf("expected sql.ErrNoRows for a missing user, got %v", err)
+	}
+
+	other, err := q.CreateFeed(ctx, database.CreateFeedParams{ID: uuid.New(), CreatedAt: now, UpdatedAt: now, Name: "Blog", Url: "https://example.com/blog", UserID: user.ID})
+	if err != nil {
+		t.Fatalf("error creating feed: %v", err)
+	}
+	err = q.MarkFeedFetched(ctx, feed.ID)
+	if err != nil {
+		t.Fatalf("error marking feed fetched: %v", err)
+	}
+	next, err := q.GetNextFeedsToFetch(ctx, 2)
+	if err != nil || len(next) != 2 || next[0].ID != other.ID || !next[1].LastFetchedAt.Valid {
+		t.Fatalf("expected the unfetched feed first, then the fetched one, got %+v (%v)", next, err)
+	}
+
+	err = q.DeleteFeedFollowByUser(ctx, database.DeleteFeedFollowByUserParams{UserID: user.ID, FeedID: feed.ID})
+	if err != nil {
+		t.Fatalf("error unfollowing feed: %v", err)
+	}
+	posts, err = q.GetPostsForUser(ctx, database.GetPostsForUserParams{UserID: user.ID, Limit: 10})
+	if err != nil || len(posts) != 0 {
+		t.Fatalf("expected no posts after unfollowing, got %+v (%v)", posts, err)
+	}
+
+	batch := []database.CreatePostParams{
+		{ID: uuid.New(), CreatedAt: now, UpdatedAt: now, Title: "Old", Url: "https://example.com/a", PublishedAt: now, FeedID: other.ID},
+		{ID: uuid.New(), CreatedAt: now, UpdatedAt: now, Title: "New", Url: "https://example.com/c", PublishedAt: now.Add(time.Minute), FeedID: other.ID, ThumbnailUrl: sql.NullString{String: "https://example.com/c.png", Valid: true}},
+		{ID: uuid.New(), CreatedAt: now, UpdatedAt: now, Title: "Newer", Url: "https://example.com/d", Description: sql.NullString{String: "", Valid: true}, PublishedAt: now.Add(2 * time.Minute), FeedID: other.ID},
+		{ID: uuid.New(), CreatedAt: now, UpdatedAt: now, Title: "Again", Url: "https://example.com/d", PublishedAt: now, FeedID: other.ID},
+	}
+	created, err := q.CreatePosts(ctx, batch)
+	if err != nil {
+		t.Fatalf("error creating posts: %v", err)
+	}
+	if len(created) != 2 || created[0].ID != batch[1].ID || created[1].ID != batch[2].ID {
+		t.Fatalf("expected only the two new posts, in order, got %+v", created)
+	}
+	if !created[0].PublishedAt.Equal(batch[1].PublishedAt) || created[0].ThumbnailUrl != batch[1].ThumbnailUrl || created[0].Description.Valid || created[1].Description != batch[2].Description {
+		t.Fatalf("expected the posts' fields to round trip, got %+v", created[0])
+	}
+	length := sql.NullInt64{Int64: 1 << 20, Valid: true}
+	err = q.CreatePostEnclosures(ctx, []database.CreatePostEnclosureParams{
+		{ID: uuid.New(), CreatedAt: now, UpdatedAt: now, PostID: created[0].ID, Url: "https://example.com/c.mp3", Length: length},
+		enclosure,
+	})
+	if err != nil {
+		t.Fatalf("error creating enclosures: %v", err)
+	}
+	enclosures, err = q.GetEnclosuresForPost(ctx, created[0].ID)
+	if err != nil || len(enclosures) != 1 || enclosures[0].Length != length || enclosures[0].MimeType.Valid {
+		t.Fatalf("expected the batched enclosure, got %+v (%v)", enclosures, err)
+	}
+
+	failed := errors.New("rolled back")
+	err = q.InTx(ctx, func(tx storage.Store) error {
+		_, err := tx.CreatePosts(ctx, []database.CreatePostParams{
+			{ID: uuid.New(), CreatedAt: now, UpdatedAt: now, Title: "Lost", Url: "https://example.com/lost", PublishedAt: now, FeedID: other.ID},
+		})
+		if err != nil {
+			return err
+		}
+		err = tx.InTx(ctx, func(tx storage.Store) error {
+			return tx.MarkFeedFetched(ctx, other.ID)
+		})
+		if err != nil {
+			return err
+		}
+		return failed
+	})
+	if !errors.Is(err, failed) {
+		t.Fatalf("expected the transaction's error, got %v", err)
+	}
+	unfetched, err := q.GetFeedByUrl(ctx, other.Url)
+	if err != nil || unfetched.LastFetchedAt.Valid {
+		t.Fatalf("expected the rolled back fetch mark to be undone, got %+v (%v)", unfetched, err)
+	}
+	_, err = q.CreatePosts(ctx, []database.CreatePostParams{
+		{ID: uuid.New(), CreatedAt: now, UpdatedAt: now, Title: "Lost", Url: "https://example.com/lost", PublishedAt: now, FeedID: other.ID},
+	})
+	if err != nil {
+		t.Fatalf("expected the rolled back post to be gone, got %v", err)
+	}
+
+	prunable := func(arg database.GetPrunablePostsParams, want ...string) {
+		t.Helper()
+		rows, err := q.GetPrunablePosts(ctx, arg)
+		if err != nil {
+			t.Fatalf("error getting prunable posts: %v", err)
+		}
+		var titles []string
+		for _, row := range rows {
+			if row.Unread {
+				row.Title += " (unread)"
+			}
+			titles = append(titles, row.Title)
+		}
+		if !slices.Equal(titles, want) {
+			t.Fatalf("expected %v to be prunable, got %v", want, titles)
+		}
+	}
+	keepOne := database.GetPrunablePostsParams{FeedID: other.ID, KeepLast: 1}
+	prunable(keepOne, "Lost", "New")
+	prunable(database.GetPrunablePostsParams{FeedID: other.ID, PublishedBefore: now.Add(90 * time.Second)}, "Lost", "New")
+	prunable(database.GetPrunablePostsParams{FeedID: other.ID})
+	err = q.SetPostStarred(ctx, database.SetPostStarredParams{ID: uuid.New(), CreatedAt: now, UpdatedAt: now, UserID: user.ID, PostID: created[0].ID, Starred: true})
+	if err != nil {
+		t.Fatalf("error starring post: %v", err)
+	}
+	prunable(keepOne, "Lost")
+	_, err = q.CreateFeedFollow(ctx, database.CreateFeedFollowParams{ID: uuid.New(), CreatedAt: now, UpdatedAt: now, UserID: user.ID, FeedID: other.ID})
+	if err != nil {
+		t.Fatalf("error following feed: %v", err)
+	}
+	prunable(keepOne, "Lost (unread)")
+	posts, err = q.GetPostsForUser(ctx, database.GetPostsForUserParams{UserID: user.ID, Limit: 10})
+	if err != nil || len(posts) != 3 || posts[2].Title != "Lost" {
+		t.Fatalf("expected the followed feed's posts, got %+v (%v)", posts, err)
+	}
+	err = q.SetPostRead(ctx, database.SetPostReadParams{ID: uuid.New(), CreatedAt: now, UpdatedAt: now, UserID: user.ID, PostID: posts[2].ID, ReadAt: sql.NullTime{Time: now, Valid: true}})
+	if err != nil {
+		t.Fatalf("error marking post read: %v", err)
+	}
+	prunable(keepOne, "Lost")
+	err = q.DeletePosts(ctx, []uuid.UUID{posts[2].ID})
+	if err != nil {
+		t.Fatalf("error deleting posts: %v", err)
+	}
+	_, err = q.GetPostByID(ctx, posts[2].ID)
+	if !errors.Is(err, sql.ErrNoRows) {
+		t.Fatalf("expected the deleted post to be gone, got %v", err)
+	}
+	prunable(keepOne)
+
+	gus, err := q.CreateUser(ctx, database.CreateUserParams{ID: uuid.New(), CreatedAt: now, UpdatedAt: now, Name: "gus"})
+	if err != nil {
+		t.Fatalf("error creating user: %v", err)
+	}
+	gusFeed, err := q.CreateFeed(ctx, database.CreateFeedParams{ID: uuid.New(), CreatedAt: now, UpdatedAt: now, Name: "Gus", Url: "https://example.com/gus", UserID: gus.ID})
+	if err != nil {
+		t.Fatalf("error creating feed: %v", err)
+	}
+	for _, feedID := range []uuid.UUID{gusFeed.ID, other.ID} {
+		_, err = q.CreateFeedFollow(ctx, database.CreateFeedFollowParams{ID: uuid.New(), CreatedAt: now, UpdatedAt: now, UserID: gus.ID, FeedID: feedID})
+		if err != nil {
+			t.Fatalf("error following feed: %v", err)
+		}
+	}
+	err = q.SetPostStarred(ctx, database.SetPostStarredParams{ID: uuid.New(), CreatedAt: now, UpdatedAt: now, UserID: gus.ID, PostID: created[1].ID, Starred: true})
+	if err != nil {
+		t.Fatalf("error starring post: %v", err)
+	}
+	allFollows, err := q.GetAllFeedFollows(ctx)
+	if err != nil || len(allFollows) != 3 {
+		t.Fatalf("expected three follows, got %+v (%v)", allFollows, err)
+	}
+	err = q.DeleteUser(ctx, gus.ID)
+	if err != nil {
+		t.Fatalf("error deleting user: %v", err)
+	}
+	_, err = q.GetFeedByID(ctx, gusFeed.ID)
+	if !errors.Is(err, sql.ErrNoRows) {
+		t.Fatalf("expected the user's feed to be deleted with them, got %v", err)
+	}
+	allFollows, err = q.GetAllFeedFollows(ctx)
+	if err != nil || len(allFollows) != 1 || allFollows[0].UserID != user.ID {
+		t.Fatalf("expected only the other user's follow to be left, got %+v (%v)", allFollows, err)
+	}
+	states, err := q.GetAllPostStates(ctx)
+	if err != nil || len(states) == 0 || slices.ContainsFunc(states, func(s database.PostState) bool { return s.UserID == gus.ID }) {
+		t.Fatalf("expected the user's marks to be deleted with them, got %+v (%v)", states, err)
+	}
+
+	err = q.DeleteAllPosts(ctx)
+	if err != nil {
+		t.Fatalf("error deleting posts: %v", err)
+	}
+	allPosts, err := q.GetAllPosts(ctx)
+	if err != nil || len(allPosts) != 0 {
+		t.Fatalf("expected no posts, got %+v (%v)", allPosts, err)
+	}
+	allEnclosures, err := q.GetAllPostEnclosures(ctx)
+	if err != nil || len(allEnclosures) != 0 {
+		t.Fatalf("expected deleting posts to cascade to enclosures, got %+v (%v)", allEnclosures, err)
+	}
+	states, err = q.GetAllPostStates(ctx)
+	if err != nil || len(states) != 0 {
+		t.Fatalf("expected deleting posts to cascade to states, got %+v (%v)", states, err)
+	}
+	err = q.DeleteAllFeeds(ctx)
+	if err != nil {
+		t.Fatalf("error deleting feeds: %v", err)
+	}
+	allFollows, err = q.GetAllFeedFollows(ctx)
+	if err != nil || len(allFollows) != 0 {
+		t.Fatalf("expected deleting feeds to cascade to follows, got %+v (%v)", allFollows, err)
+	}
+	users, err := q.GetAllUsers(ctx)
+	if err != nil || len(users) != 1 {
+		t.Fatalf("expected deleting feeds to leave users, got %+v (%v)", users, err)
+	}
+
+	err = q.DeleteAllUsers(ctx)
+	if err != nil {
+		t.Fatalf("error deleting users: %v", err)
+	}
+	feeds, err := q.GetAllFeeds(ctx)
+	if err != nil || len(feeds) != 0 {
+		t.Fatalf("expected deleting users to cascade to feeds, got %+v (%v)", feeds, err)
+	}
+}
@@ -0,0 +1,116 @@
+package storage
+
+import (
+	"context"
+
+	"github.com/WagnerJust/go-gator/internal/database"
+	"github.com/google/uuid"
+)
+
+// Users stores user accounts.
+type Users interface {
+	CreateUser(ctx context.Context, arg database.CreateUserParams) (database.User, error)
+	// GetUserByName finds the user whose name matches name ignoring case, as
+	// names are unique.
+	GetUserByName(ctx context.Context, name string) (database.User, error)
+	RenameUser(ctx context.Context, arg database.RenameUserParams) (database.User, error)
+	// GetUserStats counts the feeds the user added and follows and the posts
+	// they've read and starred.
+	GetUserStats(ctx context.Context, userID uuid.UUID) (database.GetUserStatsRow, error)
+	// GetUserDeletionImpact counts what deleting the user takes from others:
+	// the posts of the feeds they added, and other users' follows of those
+	// feeds and how many users those are.
+	GetUserDeletionImpact(ctx context.Context, userID uuid.UUID) (database.GetUserDeletionImpactRow, error)
+	GetAllUsers(ctx context.Context) ([]database.User, error)
+	// DeleteAllUsers deletes every user along with their feeds, follows and
+	// posts.
+	DeleteAllUsers(ctx context.Context) error
+	// DeleteUser deletes the user along with the feeds they added, their
+	// follows and their read and starred marks.
+	DeleteUser(ctx context.Context, id uuid.UUID) error
+}
+
+// Feeds stores the feeds users have added and when they were last fetched.
+type Feeds interface {
+	CreateFeed(ctx context.Context, arg database.CreateFeedParams) (database.Feed, error)
+	GetAllFeeds(ctx context.Context) ([]database.Feed, error)
+	GetAllFeedsWithUsers(ctx context.Context) ([]database.GetAllFeedsWithUsersRow, error)
+	GetFeedByID(ctx context.Context, id uuid.UUID) (database.Feed, error)
+	GetFeedByUrl(ctx context.Context, url string) (database.Feed, error)
+	// GetNextFeedsToFetch returns up to limit feeds, those never fetched
+	// first and then the longest since their last fetch.
+	GetNextFeedsToFetch(ctx context.Context, limit int32) ([]database.Feed, error)
+	MarkFeedFetched(ctx context.Context, id uuid.UUID) error
+	SetFeedFetchWarning(ctx context.Context, arg database.SetFeedFetchWarningParams) error
+	// DeleteAllFeeds deletes every feed along with its follows and posts.
+	DeleteAllFeeds(ctx context.Context) error
+	// RestoreFeed stores a feed with every column given, for restoring a
+	// backup.
+	RestoreFeed(ctx context.Context, arg database.RestoreFeedParams) error
+}
+
+// Follows stores which users follow which feeds.
+type Follows interface {
+	CreateFeedFollow(ctx context.Context, arg database.CreateFeedFollowParams) (database.CreateFeedFollowRow, error)
+	GetFeedFollowsForUser(ctx context.Context, id uuid.UUID) ([]database.GetFeedFollowsForUserRow, error)
+	DeleteFeedFollowByUser(ctx context.Context, arg database.DeleteFeedFollowByUserParams) error
+	GetAllFeedFollows(ctx context.Context) ([]database.FeedFollow, error)
+}
+
+// Posts stores the posts fetched from feeds, their enclosures, and each
+// user's read and starred marks.
+type Posts interface {
+	CreatePost(ctx context.Context, arg database.CreatePostParams) (database.Post, error)
+	// CreatePosts stores a batch of posts in one statement, skipping those
+	// whose URL is already stored, and returns the ones it added.
+	CreatePosts(ctx context.Context, args []database.CreatePostParams) ([]database.Post, error)
+	GetPostByID(ctx context.Context, id uuid.UUID) (database.Post, error)
+	// GetPostsForUser returns the newest posts from the user's followed
+	// feeds.
+	GetPostsForUser(ctx context.Context, arg database.GetPostsForUserParams) ([]database.Post, error)
+	CreatePostEnclosure(ctx context.Context, arg database.CreatePostEnclosureParams) (database.PostEnclosure, error)
+	// CreatePostEnclosures stores a batch of enclosures in one statement,
+	// skipping those already stored.
+	CreatePostEnclosures(ctx context.Context, args []database.CreatePostEnclosureParams) error
+	GetEnclosuresForPost(ctx context.Context, postID uuid.UUID) ([]database.PostEnclosure, error)
+	SetPostRead(ctx context.Context, arg database.SetPostReadParams) error
+	SetPostStarred(ctx context.Context, arg database.SetPostStarredParams) error
+	GetPostsWithStateForUser(ctx context.Context, arg database.GetPostsWithStateForUserParams) ([]database.GetPostsWithStateForUserRow, error)
+	GetUnreadCountsForUser(ctx context.Context, userID uuid.UUID) ([]database.GetUnreadCountsForUserRow, error)
+	// GetPrunablePosts returns the feed's posts that retention no longer
+	// keeps, oldest first. Starred posts are never included, and posts a
+	// follower hasn't read are marked Unread so they can be kept.
+	GetPrunablePosts(ctx context.Context, arg database.GetPrunablePostsParams) ([]database.GetPrunablePostsRow, error)
+	// DeletePosts deletes the posts with their enclosures and states.
+	DeletePosts(ctx context.Context, ids []uuid.UUID) error
+	DeleteAllPosts(ctx context.Context) error
+	GetAllPosts(ctx context.Context) ([]database.Post, error)
+	GetAllPostEnclosures(ctx context.Context) ([]database.PostEnclosure, error)
+	GetAllPostStates(ctx context.Context) ([]database.PostState, error)
+	// RestorePostState stores a user's marks on a post with every column
+	// given, for restoring a backup.
+	RestorePostState(ctx context.Context, arg database.RestorePostStateParams) error
+}
+
+// Store is everything the commands read and write. There is one for each
+// database, built on its sqlc queries, and Memory.
+type Store interface {
+	Users
+	Feeds
+	Follows
+	Posts
+	// InTx calls fn with a Store whose changes are committed together when
+	// fn returns nil and rolled back otherwise. Calling InTx on that Store
+	// runs within the same transaction.
+	InTx(ctx context.Context, fn func(Store) error) error
+	// InSnapshot calls fn with a Store that reads the database as it was at
+	// one moment, unaffected by writes committed while fn runs. fn must only
+	// read; within InTx it runs in that transaction.
+	InSnapshot(ctx context.Context, fn func(Store) error) error
+}
+
+var (
+	_ Store = (*postgresStore)(nil)
+	_ Store = (*sqliteQueries)(nil)
+	_ Store = (*Memory)(nil)
+)
@@ -0,0 +1,623 @@
+package tui
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"time"
+
+	"github.com/WagnerJust/go-gator/internal/render"
+	"github.com/google/uuid"
+	"golang.org/x/term"
+)
+
+// Feed is a followed feed as shown in the left pane.
+type Feed struct {
+	ID     uuid.UUID
+	Name   string
+	URL    string
+	Unread int
+}
+
+// Post is an entry in the post list. Body holds the sanitized HTML
+// description.
+type Post struct {
+	ID        uuid.UUID
+	FeedID    uuid.UUID
+	FeedName  string
+	Title     string
+	URL       string
+	Body      string
+	Published time.Time
+	Read      bool
+	Starred   bool
+}
+
+// Filter selects the posts for whatever is highlighted in the left pane.
+type Filter struct {
+	FeedID      uuid.NullUUID
+	UnreadOnly  bool
+	StarredOnly bool
+}
+
+// Source is everything the reader needs from the rest of gator.
+type Source interface {
+	Feeds() ([]Feed, error)
+	Posts(filter Filter) ([]Post, error)
+	SetRead(postID uuid.UUID, read bool) error
+	SetStarred(postID uuid.UUID, starred bool) error
+	// Refresh fetches the feed and returns a short summary of what changed.
+	Refresh(feedID uuid.UUID) (string, error)
+}
+
+const (
+	paneFeeds = iota
+	panePosts
+	paneBody
+)
+
+const helpText = "j/k move  tab/enter open  h back  m read  s star  o browser  r refresh  R refresh all  q quit"
+
+type folder struct {
+	label  string
+	filter Filter
+}
+
+var folders = []folder{
+	{label: "All posts"},
+	{label: "Unread", filter: Filter{UnreadOnly: true}},
+	{label: "Starred", filter: Filter{StarredOnly: true}},
+}
+
+// Run takes over the terminal until the user quits.
+func Run(source Source, title string) error {
+	stdin := int(os.Stdin.Fd())
+	if !term.IsTerminal(stdin) || !term.IsTerminal(int(os.Stdout.Fd())) {
+		return errors.New("tui needs an interactive terminal")
+	}
+	previous, err := term.MakeRaw(stdin)
+	if err != nil {
+		return err
+	}
+	defer term.Restore(stdin, previous)
+
+	fmt.Print("\x1b[?1049h\x1b[?25l")
+	defer fmt.Print("\x1b[?25h\x1b[?1049l")
+
+	a := newApp(source, title)
+	a.open = func(url string) error {
+		return openBrowser(url, func(cmd *exec.Cmd) error {
+			// Give the terminal back as it was for the browser, then
+			// take it over again.
+			fmt.Print("\x1b[?25h\x1b[?1049l")
+			term.Restore(stdin, previous)
+			err := runAttached(cmd)
+			_, rawErr := term.MakeRaw(stdin)
+			fmt.Print("\x1b[?1049h\x1b[?25l")
+			if err != nil {
+				return err
+			}
+			return rawErr
+		})
+	}
+	err = a.reload()
+	if err != nil {
+		return err
+	}
+	input := bufio.NewReader(os.Stdin)
+	for {
+		width, height, err := term.GetSize(int(os.Stdout.Fd()))
+		if err == nil {
+			a.width, a.height = width, height
+		}
+		fmt.Print(a.view())
+		key, err := readKey(input)
+		if err != nil {
+			return err
+		}
+		if a.handle(key) {
+			return nil
+		}
+	}
+}
+
+type app struct {
+	source Source
+	title  string
+	width  int
+	height int
+
+	feeds []Feed
+	posts []Post
+	focus int
+	// entry indexes the left pane: folders first, then feeds.
+	entry      int
+	entryTop   int
+	post       int
+	postTop    int
+	bodyScroll int
+	status     string
+
+	// redraw is called before slow operations so the status line shows up
+	// while they run.
+	redraw func()
+	// open shows a post's link in a browser.
+	open func(url string) error
+}
+
+func newApp(source Source, title string) *app {
+	a := &app{source: source, title: title, width: 100, height: 30}
+	a.redraw = func() { fmt.Print(a.view()) }
+	a.open = func(url string) error { return openBrowser(url, runAttached) }
+	return a
+}
+
+func (a *app) filter() Filter {
+	if a.entry < len(folders) {
+		return folders[a.entry].filter
+	}
+	feed := a.feeds[a.entry-len(folders)]
+	return Filter{FeedID: uuid.NullUUID{UUID: feed.ID, Valid: true}}
+}
+
+func (a *app) entryCount() int {
+	return len(folders) + len(a.feeds)
+}
+
+// reload refreshes feeds and posts from the source, keeping the current
+// selection where possible.
+func (a *app) reload() error {
+	feeds, err := a.source.Feeds()
+	if err != nil {
+		return err
+	}
+	a.feeds = feeds
+	if a.entry >= a.entryCount() {
+		a.entry = a.entryCount() - 1
+	}
+	return a.reloadPosts()
+}
+
+func (a *app) reloadPosts() error {
+	var selected uuid.UUID
+	if a.post < len(a.posts) {
+		selected = a.posts[a.post].ID
+	}
+	posts, err := a.source.Posts(a.filter())
+	if err != nil {
+		return err
+	}
+	a.posts = posts
+	a.post = 0
+	for i, post := range posts {
+		if post.ID == selected {
+			a.post = i
+		}
+	}
+	a.bodyScroll = 0
+	return nil
+}
+
+func (a *app) selectedPost() *Post {
+	if a.post < 0 || a.post >= len(a.posts) {
+		return nil
+	}
+	return &a.posts[a.post]
+}
+
+// handle applies one key press and reports whether the user asked to quit.
+func (a *app) handle(key string) bool {
+	a.status = ""
+	switch key {
+	case "q", "ctrl+c":
+		return true
+	case "?":
+		a.status = helpText
+	case "j", "down":
+		a.move(1)
+	case "k", "up":
+		a.move(-1)
+	case "pgdown", " ":
+		a.move(a.paneHeight() - 1)
+	case "pgup":
+		a.move(-(a.paneHeight() - 1))
+	case "g", "home":
+		a.move(-1 << 30)
+	case "G", "end":
+		a.move(1 << 30)
+	case "tab", "l", "right", "enter":
+		a.forward()
+	case "h", "left", "esc", "backspace", "backtab":
+		if a.focus > paneFeeds {
+			a.focus--
+		}
+	case "m":
+		if post := a.selectedPost(); post != nil {
+			a.setRead(post, !post.Read)
+		}
+	case "s":
+		if post := a.selectedPost(); post != nil {
+			a.setStarred(post, !post.Starred)
+		}
+	case "o":
+		if post := a.selectedPost(); post != nil {
+			err := a.open(post.URL)
+			if err != nil {
+				a.setStatusErr(err)
+			} else {
+				a.setRead(post, true)
+			}
+		}
+	case "r":
+		a.refresh(false)
+	case "R":
+		a.refresh(true)
+	}
+	return false
+}
+
+func (a *app) forward() {
+	switch a.focus {
+	case paneFeeds:
+		a.focus = panePosts
+	case panePosts:
+		if post := a.selectedPost(); post != nil {
+			a.focus = paneBody
+			a.bodyScroll = 0
+			if !post.Read {
+				a.setRead(post, true)
+			}
+		}
+	}
+}
+
+func (a *app) move(delta int) {
+	switch a.focus {
+	case paneFeeds:
+		previous := a.entry
+		a.entry = clamp(a.entry+delta, 0, a.entryCount()-1)
+		if a.entry != previous {
+			a.setStatusErr(a.reloadPosts())
+		}
+	case panePosts:
+		previous := a.post
+		a.post = clamp(a.post+delta, 0, len(a.posts)-1)
+		if a.post != previous {
+			a.bodyScroll = 0
+		}
+	case paneBody:
+		a.bodyScroll = clamp(a.bodyScroll+delta, 0, max(0, len(a.bodyLines())-a.paneHeight()))
+	}
+}
+
+func (a *app) setRead(post *Post, read bool) {
+	if post.Read == read {
+		return
+	}
+	err := a.source.SetRead(post.ID, read)
+	if err != nil {
+		a.setStatusErr(err)
+		return
+	}
+	post.Read = read
+	for i := range a.feeds {
+		if a.feeds[i].ID != post.FeedID {
+			continue
+		}
+		if read {
+			a.feeds[i].Unread--
+		} else {
+			a.feeds[i].Unread++
+		}
+	}
+}
+
+func (a *app) setStarred(post *Post, starred bool) {
+	err := a.source.SetStarred(post.ID, starred)
+	if err != nil {
+		a.setStatusErr(err)
+		return
+	}
+	post.Starred = starred
+}
+
+func (a *app) refresh(all bool) {
+	var feeds []Feed
+	switch {
+	case all:
+		feeds = a.feeds
+	case a.focus == paneFeeds && a.entry >= len(folders):
+		feeds = []Feed{a.feeds[a.entry-len(folders)]}
+	case a.selectedPost() != nil:
+		for _, feed := range a.feeds {
+			if feed.ID == a.selectedPost().FeedID {
+				feeds = []Feed{feed}
+			}
+		}
+	}
+	if len(feeds) == 0 {
+		a.status = "Select a feed or post to refresh"
+		return
+	}
+
+	var summaries []string
+	for _, feed := range feeds {
+		a.status = fmt.Sprintf("Refreshing %s…", feed.Name)
+		a.redraw()
+		summary, err := a.source.Refresh(feed.ID)
+		if err != nil {
+			summary = fmt.Sprintf("%s: %v", feed.Name, err)
+		}
+		summaries = append(summaries, summary)
+	}
+	a.status = strings.Join(summaries, "; ")
+	a.setStatusErr(a.reload())
+}
+
+func (a *app) setStatusErr(err error) {
+	if err != nil {
+		a.status = "Error: " + err.Error()
+	}
+}
+
+func (a *app) paneHeight() int {
+	// One row for the title bar, one for the status line.
+	return max(1, a.height-2)
+}
+
+func (a *app) paneWidths() (int, int, int) {
+	left := clamp(a.width/4, 16, 30)
+	middle := (a.width - left) * 2 / 5
+	right := a.width - left - middle - 2
+	return left, middle, max(right, 0)
+}
+
+func (a *app) bodyLines() []string {
+	post := a.selectedPost()
+	if post == nil {
+		return nil
+	}
+	_, _, width := a.paneWidths()
+	opts := render.Options{Width: width - 2, Color: true}
+	lines := []string{
+		opts.Title(post.Title),
+		opts.Dim(post.FeedName + " · " + post.Published.Format("2006-01-02 15:04")),
+		opts.Dim(post.URL),
+		"",
+	}
+	body := render.HTML(post.Body, opts)
+	if body != "" {
+		lines = append(lines, strings.Split(body, "\n")...)
+	}
+	return lines
+}
+
+func (a *app) feedLines(height int) []string {
+	unread := 0
+	for _, feed := range a.feeds {
+		unread += feed.Unread
+	}
+	var lines []string
+	for _, f := range folders {
+		label := f.label
+		if !f.filter.StarredOnly {
+			label = fmt.Sprintf("%s (%d)", label, unread)
+		}
+		lines = append(lines, label)
+	}
+	for _, feed := range a.feeds {
+		label := "  " + feed.Name
+		if feed.Unread > 0 {
+			label = fmt.Sprintf("%s (%d)", label, feed.Unread)
+		}
+		lines = append(lines, label)
+	}
+	a.entryTop = scrollTo(a.entry, a.entryTop, height)
+	return window(lines, a.entryTop, height)
+}
+
+func (a *app) postLines(height int) []string {
+	var lines []string
+	for _, post := range a.posts {
+		marker := " "
+		if !post.Read {
+			marker = "●"
+		}
+		star := " "
+		if post.Starred {
+			star = "★"
+		}
+		lines = append(lines, fmt.Sprintf("%s%s %s %s", marker, star, post.Published.Format("01-02"), post.Title))
+	}
+	if len(lines) == 0 {
+		lines = append(lines, " No posts")
+	}
+	a.postTop = scrollTo(a.post, a.postTop, height)
+	return window(lines, a.postTop, height)
+}
+
+// view draws the whole screen.
+func (a *app) view() string {
+	left, middle, right := a.paneWidths()
+	height := a.paneHeight()
+	feeds := a.feedLines(height)
+	posts := a.postLines(height)
+	body := window(a.bodyLines(), a.bodyScroll, height)
+
+	var b strings.Builder
+	b.WriteString("\x1b[H")
+	b.WriteString("\x1b[7m" + render.Truncate(" gator · "+a.title, a.width) + "\x1b[0m\x1b[K\r\n")
+	for row := 0; row < height; row++ {
+		b.WriteString(a.cell(feeds, row, left, paneFeeds, a.entry-a.entryTop))
+		b.WriteString("│")
+		b.WriteString(a.cell(posts, row, middle, panePosts, a.post-a.postTop))
+		b.WriteString("│")
+		line := ""
+		if row < len(body) {
+			line = " " + body[row]
+		}
+		b.WriteString(render.Truncate(line, right))
+		b.WriteString("\x1b[K\r\n")
+	}
+	status := a.status
+	if status == "" {
+		status = "? for help"
+	}
+	b.WriteString(render.Truncate(status, a.width) + "\x1b[K")
+	return b.String()
+}
+
+func (a *app) cell(lines []string, row, width, pane, selected int) string {
+	line := ""
+	if row < len(lines) {
+		line = lines[row]
+	}
+	text := render.Truncate(line, width)
+	if row != selected {
+		return text
+	}
+	if a.focus == pane {
+		return "\x1b[7m" + text + "\x1b[0m"
+	}
+	return "\x1b[1m" + text + "\x1b[0m"
+}
+
+// scrollTo returns the first visible row so that selected stays on screen.
+func scrollTo(selected, top, height int) int {
+	if selected < top {
+		return selected
+	}
+	if selected >= top+height {
+		return selected - height + 1
+	}
+	return top
+}
+
+func window(lines []string, top, height int) []string {
+	if top >= len(lines) {
+		return nil
+	}
+	return lines[top:min(len(lines), top+height)]
+}
+
+func clamp(value, low, high int) int {
+	if high < low {
+		return low
+	}
+	return min(max(value, low), high)
+}
+
+var escapeSequences = map[string]string{
+	"\x1b[A":  "up",
+	"\x1b[B":  "down",
+	"\x1b[C":  "right",
+	"\x1b[D":  "left",
+	"\x1bOA":  "up",
+	"\x1bOB":  "down",
+	"\x1bOC":  "right",
+	"\x1bOD":  "left",
+	"\x1b[5~": "pgup",
+	"\x1b[6~": "pgdown",
+	"\x1b[H":  "home",
+	"\x1b[F":  "end",
+	"\x1b[1~": "home",
+	"\x1b[4~": "end",
+	"\x1b[Z":  "backtab",
+}
+
+// readKey reads one key press from a terminal in raw mode and names it.
+func readKey(r *bufio.Reader) (string, error) {
+	b, err := r.ReadByte()
+	if err != nil {
+		return "", err
+	}
+	switch b {
+	case '\r', '\n':
+		return "enter", nil
+	case '\t':
+		return "tab", nil
+	case 3:
+		return "ctrl+c", nil
+	case 8, 127:
+		return "backspace", nil
+	case 0x1b:
+		sequence := []byte{b}
+		for r.Buffered() > 0 && len(sequence) < 6 {
+			c, err := r.ReadByte()
+			if err != nil {
+				return "", err
+			}
+			sequence = append(sequence, c)
+			if len(sequence) > 2 && (c == '~' || ('A' <= c && c <= 'Z')) {
+				break
+			}
+		}
+		if name, ok := escapeSequences[string(sequence)]; ok {
+			return name, nil
+		}
+		return "esc", nil
+	}
+	return string(rune(b)), nil
+}
+
+// terminalBrowsers draw in the terminal, so the reader hands it over to them
+// until they exit instead of starting them in the background.
+var terminalBrowsers = map[string]bool{
+	"browsh":      true,
+	"carbonyl":    true,
+	"elinks":      true,
+	"links":       true,
+	"lynx":        true,
+	"w3m":         true,
+	"www-browser": true,
+}
+
+// browserCommand returns the command that opens url with $BROWSER, falling
+// back to the platform's default handler, and whether it runs in the
+// terminal.
+func browserCommand(url string) (*exec.Cmd, bool) {
+	if browser := strings.Split(os.Getenv("BROWSER"), ":")[0]; browser != "" {
+		args := strings.Fields(browser)
+		return exec.Command(args[0], append(args[1:], url)...), terminalBrowsers[filepath.Base(args[0])]
+	}
+	switch runtime.GOOS {
+	case "darwin":
+		return exec.Command("open", url), false
+	case "windows":
+		return exec.Command("rundll32", "url.dll,FileProtocolHandler", url), false
+	}
+	return exec.Command("xdg-open", url), false
+}
+
+// openBrowser opens url. A terminal browser is run through handOver, which
+// gives it the terminal until it exits; any other is started in the
+// background and reaped when it exits.
+func openBrowser(url string, handOver func(*exec.Cmd) error) error {
+	if url == "" {
+		return errors.New("post has no link")
+	}
+	cmd, inTerminal := browserCommand(url)
+	if inTerminal {
+		return handOver(cmd)
+	}
+	cmd.Stdout = io.Discard
+	cmd.Stderr = io.Discard
+	err := cmd.Start()
+	if err != nil {
+		return err
+	}
+	go cmd.Wait()
+	return nil
+}
+
+// runAttached runs cmd on the process's own stdin, stdout and stderr.
+func runAttached(cmd *exec.Cmd) error {
+	cmd.Stdin, cmd.Stdout, cmd.Stderr = os.Stdin, os.Stdout, os.Stderr
+	return cmd.Run()
+}
@@ -0,0 +1,196 @@
+package tui
+
+import (
+	"bufio"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type fakeSource struct {
+	feeds     []Feed
+	posts     []Post
+	refreshed []uuid.UUID
+}
+
+func (f *fakeSource) Feeds() ([]Feed, error) {
+	return append([]Feed(nil), f.feeds...), nil
+}
+
+func (f *fakeSource) Posts(filter Filter) ([]Post, error) {
+	var posts []Post
+	for _, post := range f.posts {
+		if filter.FeedID.Valid && post.FeedID != filter.FeedID.UUID {
+			continue
+		}
+		if filter.UnreadOnly && post.Read {
+			continue
+		}
+		if filter.StarredOnly && !post.Starred {
+			continue
+		}
+		posts = append(posts, post)
+	}
+	return posts, nil
+}
+
+func (f *fakeSource) SetRead(postID uuid.UUID, read bool) error {
+	for i := range f.posts {
+		if f.posts[i].ID == postID {
+			f.posts[i].Read = read
+		}
+	}
+	return nil
+}
+
+func (f *fakeSource) SetStarred(postID uuid.UUID, starred bool) error {
+	for i := range f.posts {
+		if f.posts[i].ID == postID {
+			f.posts[i].Starred = starred
+		}
+	}
+	return nil
+}
+
+func (f *fakeSource) Refresh(feedID uuid.UUID) (string, error) {
+	f.refreshed = append(f.refreshed, feedID)
+	return "refreshed", nil
+}
+
+func newTestApp(t *testing.T) (*app, *fakeSource) {
+	t.Helper()
+	goBlog := Feed{ID: uuid.New(), Name: "Go Blog", Unread: 2}
+	rust := Feed{ID: uuid.New(), Name: "Rust Blog", Unread: 1}
+	source := &fakeSource{
+		feeds: []Feed{goBlog, rust},
+		posts: []Post{
+			{ID: uuid.New(), FeedID: goBlog.ID, FeedName: goBlog.Name, Title: "Go 1.99", Body: "<p>hello</p>", Published: time.Now()},
+			{ID: uuid.New(), FeedID: rust.ID, FeedName: rust.Name, Title: "Rust 2.0", Published: time.Now()},
+			{ID: uuid.New(), FeedID: goBlog.ID, FeedName: goBlog.Name, Title: "Generics", Published: time.Now()},
+		},
+	}
+	a := newApp(source, "tester")
+	a.redraw = func() {}
+	err := a.reload()
+	if err != nil {
+		t.Fatalf("error loading: %v", err)
+	}
+	return a, source
+}
+
+func press(a *app, keys ...string) {
+	for _, key := range keys {
+		a.handle(key)
+	}
+}
+
+func TestOpeningPostMarksItRead(t *testing.T) {
+	a, source := newTestApp(t)
+	press(a, "tab", "tab")
+	if a.focus != paneBody {
+		t.Fatalf("expected body focus, got %d", a.focus)
+	}
+	if !source.posts[0].Read {
+		t.Fatalf("expected first post to be marked read")
+	}
+	if a.feeds[0].Unread != 1 {
+		t.Fatalf("expected unread counter to drop to 1, got %d", a.feeds[0].Unread)
+	}
+
+	press(a, "m")
+	if source.posts[0].Read || a.feeds[0].Unread != 2 {
+		t.Fatalf("expected m to mark the post unread again")
+	}
+}
+
+func TestOpeningInBrowserMarksReadOnlyOnSuccess(t *testing.T) {
+	a, source := newTestApp(t)
+	var opened []string
+	a.open = func(url string) error {
+		opened = append(opened, url)
+		return errors.New("no browser")
+	}
+	source.posts[0].URL = "https://go.dev/blog"
+	a.posts[0].URL = source.posts[0].URL
+	press(a, "tab", "o")
+	if len(opened) != 1 || source.posts[0].Read || !strings.Contains(a.status, "no browser") {
+		t.Fatalf("expected a failed open to leave the post unread, got opened %v, status %q", opened, a.status)
+	}
+
+	a.open = func(url string) error { return nil }
+	press(a, "o")
+	if !source.posts[0].Read {
+		t.Fatalf("expected opening the post to mark it read")
+	}
+}
+
+func TestBrowserCommand(t *testing.T) {
+	t.Setenv("BROWSER", "/usr/bin/lynx -accept_all_cookies:firefox")
+	cmd, inTerminal := browserCommand("https://go.dev")
+	if !inTerminal || cmd.Args[len(cmd.Args)-1] != "https://go.dev" || cmd.Args[1] != "-accept_all_cookies" {
+		t.Fatalf("expected lynx to run in the terminal, got %v (%v)", cmd.Args, inTerminal)
+	}
+	t.Setenv("BROWSER", "firefox")
+	if _, inTerminal := browserCommand("https://go.dev"); inTerminal {
+		t.Fatalf("expected firefox to run in the background")
+	}
+}
+
+func TestStarAndStarredFolder(t *testing.T) {
+	a, source := newTestApp(t)
+	press(a, "tab", "j", "s")
+	if !source.posts[1].Starred {
+		t.Fatalf("expected second post to be starred")
+	}
+	press(a, "h", "j", "j")
+	if len(a.posts) != 1 || a.posts[0].Title != "Rust 2.0" {
+		t.Fatalf("expected starred folder to hold Rust 2.0, got %+v", a.posts)
+	}
+}
+
+func TestFeedSelectionAndRefresh(t *testing.T) {
+	a, source := newTestApp(t)
+	press(a, "j", "j", "j")
+	if len(a.posts) != 2 {
+		t.Fatalf("expected 2 Go Blog posts, got %d", len(a.posts))
+	}
+	press(a, "r")
+	if len(source.refreshed) != 1 || source.refreshed[0] != source.feeds[0].ID {
+		t.Fatalf("expected Go Blog to be refreshed, got %v", source.refreshed)
+	}
+	press(a, "R")
+	if len(source.refreshed) != 3 {
+		t.Fatalf("expected every feed to be refreshed, got %d refreshes", len(source.refreshed))
+	}
+}
+
+func TestViewFitsScreen(t *testing.T) {
+	a, _ := newTestApp(t)
+	a.width, a.height = 60, 10
+	press(a, "tab", "tab")
+	view := a.view()
+	rows := strings.Split(view, "\r\n")
+	if len(rows) != a.height {
+		t.Fatalf("expected %d rows, got %d", a.height, len(rows))
+	}
+	if !strings.Contains(view, "Go 1.99") || !strings.Contains(view, "hello") {
+		t.Fatalf("expected post title and body in view:\n%s", view)
+	}
+}
+
+func TestReadKey(t *testing.T) {
+	input := bufio.NewReader(strings.NewReader("j\x1b[A\x1b[6~\r\t"))
+	expected := []string{"j", "up", "pgdown", "enter", "tab"}
+	for _, want := range expected {
+		got, err := readKey(input)
+		if err != nil {
+			t.Fatalf("error reading key: %v", err)
+		}
+		if got != want {
+			t.Fatalf("expected %q, got %q", want, got)
+		}
+	}
+}
@@ -1,5 +1,9 @@
 package main
 
+// Embed the time zone database so output.timezone works on systems without
+// one, such as Windows.
+import _ "time/tzdata"
+
 func main () {
 	CliLoop()
 }
@@ -0,0 +1,120 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/WagnerJust/go-gator/internal/migrate"
+	"github.com/WagnerJust/go-gator/internal/output"
+)
+
+type migrationRecord struct {
+	Version   int64      `json:"version"`
+	Name      string     `json:"name"`
+	AppliedAt *time.Time `json:"applied_at"`
+}
+
+func handlerMigrate(s *state, cmd Command) error {
+	if s.dbErr != nil {
+		return s.dbErr
+	}
+	migrator, err := migrate.New(s.db, s.driver)
+	if err != nil {
+		return err
+	}
+	ctx := context.Background()
+	switch cmd.Args[0] {
+	case "up":
+		applied, err := migrator.Up(ctx)
+		printApplied(applied)
+		if err != nil {
+			return err
+		}
+		if len(applied) == 0 {
+			fmt.Println("The database schema is up to date")
+		}
+	case "down":
+		applied, err := migrator.Down(ctx)
+		if err != nil {
+			return err
+		}
+		printApplied([]migrate.Applied{applied})
+	case "redo":
+		applied, err := migrator.Redo(ctx)
+		printApplied(applied)
+		if err != nil {
+			return err
+		}
+	case "status":
+		statuses, err := migrator.Status(ctx)
+		if err != nil {
+			return err
+		}
+		records := make([]migrationRecord, 0, len(statuses))
+		for _, status := range statuses {
+			record := migrationRecord{Version: status.Version, Name: status.Name}
+			if !status.AppliedAt.IsZero() {
+				appliedAt := status.AppliedAt
+				record.AppliedAt = &appliedAt
+			}
+			records = append(records, record)
+		}
+		if s.Output != output.Text {
+			return s.print(records)
+		}
+		for _, record := range records {
+			applied := "pending"
+			if record.AppliedAt != nil {
+				applied = "applied " + record.AppliedAt.Format("2006-01-02 15:04")
+			}
+			fmt.Printf("%5d  %-32s %s\n", record.Version, record.Name, applied)
+		}
+	default:
+		return fmt.Errorf("usage: %s migrate up|down|status|redo", programName)
+	}
+	s.schemaChecked = false
+	return nil
+}
+
+func printApplied(applied []migrate.Applied) {
+	for _, migration := range applied {
+		fmt.Printf("Migrated %s %s (%s)\n", migration.Direction, migration.Name, migration.Duration.Round(time.Millisecond))
+	}
+}
+
+// ensureSchema makes sure the database schema matches this build before a
+// command uses it, applying pending migrations when autoMigrate is set. It
+// checks once per connection.
+func (s *state) ensureSchema(autoMigrate bool) error {
+	if s.schemaChecked {
+		return nil
+	}
+	if s.dbErr != nil {
+		return s.dbErr
+	}
+	migrator, err := migrate.New(s.db, s.driver)
+	if err != nil {
+		return err
+	}
+	err = migrator.Check(context.Background())
+	var behind *migrate.BehindError
+	var ahead *migrate.AheadError
+	switch {
+	case errors.As(err, &behind) && autoMigrate:
+		applied, err := migrator.Up(context.Background())
+		printApplied(applied)
+		if err != nil {
+			return err
+		}
+	case errors.As(err, &behind):
+		return fmt.Errorf("%w; run '%s migrate up' or pass --auto-migrate", err, programName)
+	case errors.As(err, &ahead):
+		return fmt.Errorf("%w; upgrade %s", err, programName)
+	case err != nil:
+		return err
+	}
+	s.schemaChecked = true
+	return nil
+}
@@ -0,0 +1,98 @@
+package main
+
+import (
+	"fmt"
+	"net/url"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/WagnerJust/go-gator/internal/output"
+)
+
+type profileRecord struct {
+	Name   string `json:"name"`
+	DbUrl  string `json:"db_url"`
+	User   string `json:"user"`
+	Active bool   `json:"active"`
+}
+
+func handlerProfile(s *state, cmd Command) error {
+	usage := fmt.Errorf("usage: %s profile list | use <name> | add <name> <db_url> | remove <name>", programName)
+	action, args := cmd.Args[0], cmd.Args[1:]
+	switch {
+	case action == "list" && len(args) == 0:
+		return listProfiles(s)
+	case action == "use" && len(args) == 1:
+		err := s.Config.UseProfile(args[0])
+		if err != nil {
+			return err
+		}
+		fmt.Printf("Now using profile %s\n", args[0])
+		return nil
+	case action == "add" && len(args) == 2:
+		err := s.Config.AddProfile(args[0], args[1])
+		if err != nil {
+			return err
+		}
+		fmt.Printf("Added profile %s; switch to it with '%s profile use %s'\n", args[0], programName, args[0])
+		return nil
+	case action == "remove" && len(args) == 1:
+		err := s.Config.RemoveProfile(args[0])
+		if err != nil {
+			return err
+		}
+		fmt.Printf("Removed profile %s\n", args[0])
+		return nil
+	}
+	return usage
+}
+
+func listProfiles(s *state) error {
+	records := make([]profileRecord, 0, len(s.Config.Profiles)+1)
+	for _, name := range s.Config.ProfileNames() {
+		profile, err := s.Config.GetProfile(name)
+		if err != nil {
+			return err
+		}
+		records = append(records, profileRecord{
+			Name:   name,
+			DbUrl:  redactDbUrl(profile.DbUrl),
+			User:   profile.CurrentUserName,
+			Active: name == s.Config.ProfileName(),
+		})
+	}
+	if s.Output != output.Text {
+		return s.print(records)
+	}
+	writer := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	for _, record := range records {
+		marker := " "
+		if record.Active {
+			marker = "*"
+		}
+		fmt.Fprintf(writer, "%s %s\t%s\t%s\n", marker, record.Name, record.DbUrl, record.User)
+	}
+	return writer.Flush()
+}
+
+// redactDbUrl hides the password in a database URL or libpq key=value DSN.
+func redactDbUrl(dbUrl string) string {
+	if !strings.Contains(dbUrl, "://") {
+		if !strings.Contains(dbUrl, "password=") {
+			return dbUrl
+		}
+		fields := strings.Fields(dbUrl)
+		for i, field := range fields {
+			if strings.HasPrefix(field, "password=") {
+				fields[i] = "password=xxxxx"
+			}
+		}
+		return strings.Join(fields, " ")
+	}
+	u, err := url.Parse(dbUrl)
+	if err != nil {
+		return dbUrl
+	}
+	return u.Redacted()
+}
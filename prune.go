@@ -0,0 +1,126 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/WagnerJust/go-gator/internal/config"
+	"github.com/WagnerJust/go-gator/internal/database"
+	"github.com/WagnerJust/go-gator/internal/output"
+	"github.com/WagnerJust/go-gator/internal/storage"
+	"github.com/google/uuid"
+)
+
+// prunedFeed is what pruning deleted from a feed, or would have, and how many
+// posts it kept only because a follower hasn't read them.
+type prunedFeed struct {
+	Feed   database.Feed
+	Posts  []database.GetPrunablePostsRow
+	Unread int
+}
+
+// prunePosts applies each feed's retention policy as of now, deleting the
+// posts it no longer keeps unless dryRun is set. Posts a follower hasn't read
+// are kept and counted instead. Feeds with nothing to prune or keep are left
+// out of the result.
+func prunePosts(ctx context.Context, store storage.Store, aggregator config.AggregatorConfig, now time.Time, dryRun bool) ([]prunedFeed, error) {
+	feeds, err := store.GetAllFeeds(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var pruned []prunedFeed
+	for _, feed := range feeds {
+		policy := aggregator.RetentionFor(feed.Url)
+		if policy.KeepsAll() {
+			continue
+		}
+		params := database.GetPrunablePostsParams{FeedID: feed.ID, KeepLast: int32(policy.KeepPosts)}
+		if maxAge := policy.MaxAge(); maxAge > 0 {
+			params.PublishedBefore = now.Add(-maxAge).UTC()
+		}
+		result := prunedFeed{Feed: feed}
+		err := store.InTx(ctx, func(tx storage.Store) error {
+			posts, err := tx.GetPrunablePosts(ctx, params)
+			if err != nil {
+				return err
+			}
+			var ids []uuid.UUID
+			for _, post := range posts {
+				if post.Unread {
+					result.Unread++
+					continue
+				}
+				result.Posts = append(result.Posts, post)
+				ids = append(ids, post.ID)
+			}
+			if dryRun || len(ids) == 0 {
+				return nil
+			}
+			return tx.DeletePosts(ctx, ids)
+		})
+		if err != nil {
+			return nil, err
+		}
+		if len(result.Posts) > 0 || result.Unread > 0 {
+			pruned = append(pruned, result)
+		}
+	}
+	return pruned, nil
+}
+
+func countPruned(pruned []prunedFeed) int {
+	count := 0
+	for _, feed := range pruned {
+		count += len(feed.Posts)
+	}
+	return count
+}
+
+func countUnread(pruned []prunedFeed) int {
+	count := 0
+	for _, feed := range pruned {
+		count += feed.Unread
+	}
+	return count
+}
+
+func handlerPrune(s *state, cmd Command) error {
+	dryRun := cmd.boolFlag("dry-run")
+	pruned, err := prunePosts(context.Background(), s.Db, s.Config.Aggregator, time.Now(), dryRun)
+	if err != nil {
+		return err
+	}
+	if s.Output != output.Text {
+		records := []prunedPostRecord{}
+		for _, feed := range pruned {
+			for _, post := range feed.Posts {
+				records = append(records, newPrunedPostRecord(feed.Feed, post))
+			}
+		}
+		return s.print(records)
+	}
+	if countPruned(pruned) == 0 {
+		fmt.Println("Nothing to prune")
+	}
+	verb := "Deleted"
+	if dryRun {
+		verb = "Would delete"
+	}
+	location := s.Config.Output.Location()
+	for _, feed := range pruned {
+		if len(feed.Posts) == 0 {
+			continue
+		}
+		fmt.Printf("%s %d posts from %s\n", verb, len(feed.Posts), feed.Feed.Name)
+		if dryRun {
+			for _, post := range feed.Posts {
+				fmt.Printf("\t- %s %s\n", post.PublishedAt.In(location).Format("2006-01-02"), post.Title)
+			}
+		}
+	}
+	if unread := countUnread(pruned); unread > 0 {
+		fmt.Printf("%d posts kept because they are unread\n", unread)
+	}
+	return nil
+}
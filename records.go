@@ -0,0 +1,141 @@
+package main
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/WagnerJust/go-gator/internal/database"
+	"github.com/google/uuid"
+)
+
+// The record types below are what listing commands emit with --output. Their
+// json tags are the field names for every structured format, so treat them
+// as a stable interface: add fields, don't rename them. Times are in UTC.
+
+type userRecord struct {
+	ID        uuid.UUID `json:"id"`
+	Name      string    `json:"name"`
+	CreatedAt time.Time `json:"created_at"`
+	Current   bool      `json:"current"`
+}
+
+type userDetailRecord struct {
+	ID           uuid.UUID `json:"id"`
+	Name         string    `json:"name"`
+	CreatedAt    time.Time `json:"created_at"`
+	UpdatedAt    time.Time `json:"updated_at"`
+	Current      bool      `json:"current"`
+	FeedsAdded   int64     `json:"feeds_added"`
+	Following    int64     `json:"following"`
+	ReadPosts    int64     `json:"read_posts"`
+	StarredPosts int64     `json:"starred_posts"`
+}
+
+type feedRecord struct {
+	ID               uuid.UUID  `json:"id"`
+	Name             string     `json:"name"`
+	URL              string     `json:"url"`
+	User             string     `json:"user"`
+	CreatedAt        time.Time  `json:"created_at"`
+	LastFetchedAt    *time.Time `json:"last_fetched_at"`
+	LastFetchWarning *string    `json:"last_fetch_warning"`
+}
+
+type followRecord struct {
+	ID        uuid.UUID `json:"id"`
+	FeedID    uuid.UUID `json:"feed_id"`
+	FeedName  string    `json:"feed_name"`
+	User      string    `json:"user"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type postRecord struct {
+	ID           uuid.UUID     `json:"id"`
+	FeedID       uuid.UUID     `json:"feed_id"`
+	Title        string        `json:"title"`
+	URL          string        `json:"url"`
+	PublishedAt  time.Time     `json:"published_at"`
+	Description  *string       `json:"description"`
+	ThumbnailURL *string       `json:"thumbnail_url"`
+	Media        []mediaRecord `json:"media"`
+}
+
+type prunedPostRecord struct {
+	ID          uuid.UUID `json:"id"`
+	FeedID      uuid.UUID `json:"feed_id"`
+	FeedName    string    `json:"feed_name"`
+	Title       string    `json:"title"`
+	URL         string    `json:"url"`
+	PublishedAt time.Time `json:"published_at"`
+}
+
+type mediaRecord struct {
+	URL      string  `json:"url"`
+	MimeType *string `json:"mime_type"`
+	Length   *int64  `json:"length"`
+	Duration *string `json:"duration"`
+	Episode  *string `json:"episode"`
+	ImageURL *string `json:"image_url"`
+}
+
+// String is what CSV and table output show for a post's media column.
+func (m mediaRecord) String() string {
+	return m.URL
+}
+
+func nullStringPtr(s sql.NullString) *string {
+	if !s.Valid {
+		return nil
+	}
+	return &s.String
+}
+
+func nullTimePtr(t sql.NullTime) *time.Time {
+	if !t.Valid {
+		return nil
+	}
+	utc := t.Time.UTC()
+	return &utc
+}
+
+func nullInt64Ptr(i sql.NullInt64) *int64 {
+	if !i.Valid {
+		return nil
+	}
+	return &i.Int64
+}
+
+func newPostRecord(post database.Post, enclosures []database.PostEnclosure) postRecord {
+	media := make([]mediaRecord, 0, len(enclosures))
+	for _, enclosure := range enclosures {
+		media = append(media, mediaRecord{
+			URL:      enclosure.Url,
+			MimeType: nullStringPtr(enclosure.MimeType),
+			Length:   nullInt64Ptr(enclosure.Length),
+			Duration: nullStringPtr(enclosure.Duration),
+			Episode:  nullStringPtr(enclosure.Episode),
+			ImageURL: nullStringPtr(enclosure.ImageUrl),
+		})
+	}
+	return postRecord{
+		ID:           post.ID,
+		FeedID:       post.FeedID,
+		Title:        post.Title,
+		URL:          post.Url,
+		PublishedAt:  post.PublishedAt.UTC(),
+		Description:  nullStringPtr(post.Description),
+		ThumbnailURL: nullStringPtr(post.ThumbnailUrl),
+		Media:        media,
+	}
+}
+
+func newPrunedPostRecord(feed database.Feed, post database.GetPrunablePostsRow) prunedPostRecord {
+	return prunedPostRecord{
+		ID:          post.ID,
+		FeedID:      feed.ID,
+		FeedName:    feed.Name,
+		Title:       post.Title,
+		URL:         post.Url,
+		PublishedAt: post.PublishedAt.UTC(),
+	}
+}
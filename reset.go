@@ -0,0 +1,137 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/WagnerJust/go-gator/internal/backup"
+	"golang.org/x/term"
+)
+
+// handlerResetDatabase deletes everything, or just the posts, the feeds or
+// one user, after confirming and saving a snapshot to restore from.
+func handlerResetDatabase(s *state, cmd Command) error {
+	ctx := context.Background()
+	scope := "all"
+	if len(cmd.Args) > 0 {
+		scope = cmd.Args[0]
+	}
+	var what string
+	var reset func() error
+	switch {
+	case scope == "all" && len(cmd.Args) <= 1:
+		what = "every user, with their feeds, follows and posts"
+		reset = func() error { return s.Db.DeleteAllUsers(ctx) }
+	case scope == "posts" && len(cmd.Args) == 1:
+		what = "every post"
+		reset = func() error { return s.Db.DeleteAllPosts(ctx) }
+	case scope == "feeds" && len(cmd.Args) == 1:
+		what = "every feed, with its follows and posts"
+		reset = func() error { return s.Db.DeleteAllFeeds(ctx) }
+	case scope == "user" && len(cmd.Args) == 2:
+		user, err := s.Db.GetUserByName(ctx, cmd.Args[1])
+		if err != nil {
+			return err
+		}
+		what, err = describeUserDeletion(ctx, s.Db, user)
+		if err != nil {
+			return err
+		}
+		reset = func() error { return s.Db.DeleteUser(ctx, user.ID) }
+	default:
+		return fmt.Errorf("usage: %s reset [all | posts | feeds | user <name>] [--yes]", programName)
+	}
+
+	snapshot, err := confirmAndSnapshot(s, cmd, "reset-"+scope, what)
+	if err != nil {
+		return err
+	}
+	err = reset()
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Deleted %s\n", what)
+	printUndo(snapshot, scope == "all")
+	return nil
+}
+
+// confirmAndSnapshot asks before deleting what, unless cmd has --yes, then
+// saves a snapshot named for reason to undo it with and returns its path.
+func confirmAndSnapshot(s *state, cmd Command, reason, what string) (string, error) {
+	if !cmd.boolFlag("yes") {
+		err := confirm(os.Stdin, fmt.Sprintf("This deletes %s.", what))
+		if err != nil {
+			return "", err
+		}
+	}
+	path, err := saveSnapshot(s, reason)
+	if err != nil {
+		return "", fmt.Errorf("error saving a snapshot, nothing was deleted: %w", err)
+	}
+	fmt.Printf("Saved a snapshot to %s\n", path)
+	return path, nil
+}
+
+// printUndo says how to undo a deletion with the snapshot at path. restore
+// only loads into an empty database, so undoing anything short of deleting
+// everything means emptying the database first, losing what was added since.
+func printUndo(path string, everything bool) {
+	if everything {
+		fmt.Printf("To undo, run: %s restore %s\n", programName, path)
+		return
+	}
+	fmt.Printf("restore only loads into an empty database, so to undo, run: %s reset --yes && %s restore %s\n", programName, programName, path)
+	fmt.Println("That also drops anything added since the snapshot.")
+}
+
+// confirm asks for "yes" on in before something destructive, refusing
+// outright when in isn't a terminal so scripts must pass --yes.
+func confirm(in *os.File, prompt string) error {
+	if !term.IsTerminal(int(in.Fd())) {
+		return errors.New("stdin isn't a terminal to confirm on; pass --yes to go ahead")
+	}
+	fmt.Printf("%s Type yes to continue: ", prompt)
+	answer, err := bufio.NewReader(in).ReadString('\n')
+	if err != nil && !errors.Is(err, io.EOF) {
+		return err
+	}
+	if strings.TrimSpace(answer) != "yes" {
+		return errors.New("cancelled")
+	}
+	return nil
+}
+
+// saveSnapshot writes the whole database to a new file in the snapshot
+// directory, named for why it was taken, and returns its path.
+func saveSnapshot(s *state, reason string) (string, error) {
+	dir, err := s.Config.GetSnapshotDir()
+	if err != nil {
+		return "", err
+	}
+	err = os.MkdirAll(dir, 0700)
+	if err != nil {
+		return "", err
+	}
+	name := fmt.Sprintf("gator-%s-%s.tar.gz", reason, time.Now().UTC().Format("20060102T150405.000Z"))
+	path := filepath.Join(dir, name)
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0600)
+	if err != nil {
+		return "", err
+	}
+	_, err = backup.Write(context.Background(), s.Db, file)
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(path)
+		return "", err
+	}
+	return path, nil
+}
@@ -0,0 +1,132 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/WagnerJust/go-gator/internal/database"
+	"github.com/WagnerJust/go-gator/internal/rss"
+	"github.com/WagnerJust/go-gator/internal/storage"
+	"github.com/google/uuid"
+)
+
+var pubDateLayouts = []string{
+	time.RFC1123Z,
+	time.RFC1123,
+	time.RFC3339,
+}
+
+type scrapeResult struct {
+	Fetched     *rss.RSSFeed
+	Created     []database.Post
+	Duplicates  int
+	ParseErrors int
+	// Problems describes items that were skipped or only partly saved.
+	Problems []string
+}
+
+func parsePubDate(value string) (time.Time, error) {
+	var err error
+	for _, layout := range pubDateLayouts {
+		var pubDate time.Time
+		pubDate, err = time.Parse(layout, strings.TrimSpace(value))
+		if err == nil {
+			return pubDate, nil
+		}
+	}
+	return time.Time{}, err
+}
+
+// scrapeFeed fetches a feed, stores the posts it hasn't seen before along
+// with their enclosures, and records any parser warnings on the feed. It is
+// the one fetch path shared by agg, aggone and the tui, and prints nothing so
+// each caller can report on the result in its own way.
+//
+// Everything is stored in one transaction that also marks the feed fetched,
+// so a feed that fails part way is left as it was, to be fetched again. A
+// feed that can't be fetched at all is marked fetched with the error as its
+// warning, so that it waits its turn rather than being retried ahead of
+// every other feed.
+func scrapeFeed(s *state, feed database.Feed) (scrapeResult, error) {
+	var result scrapeResult
+	ctx := s.context()
+	fetchedFeed, err := s.Fetcher.Fetch(ctx, feed.Url)
+	if err != nil {
+		if ctx.Err() != nil {
+			return result, err
+		}
+		return result, errors.Join(err, recordFetchFailure(s, feed, err))
+	}
+	result.Fetched = fetchedFeed
+
+	var postParams []database.CreatePostParams
+	items := make(map[uuid.UUID]rss.RSSItem)
+	for i, item := range fetchedFeed.Channel.Item {
+		pubDate, err := parsePubDate(item.PubDate)
+		if err != nil {
+			result.ParseErrors++
+			result.Problems = append(result.Problems, fmt.Sprintf("[%d] Could not parse date '%s' for: %s", i+1, item.PubDate, item.Title))
+			continue
+		}
+
+		params := database.CreatePostParams{
+			ID:           uuid.New(),
+			CreatedAt:    time.Now().UTC(),
+			UpdatedAt:    time.Now().UTC(),
+			PublishedAt:  pubDate.UTC(),
+			Title:        item.Title,
+			Url:          item.Link,
+			Description:  stringPtrToNullString(item.Description),
+			FeedID:       feed.ID,
+			ThumbnailUrl: stringToNullString(item.ThumbnailURL()),
+		}
+		postParams = append(postParams, params)
+		items[params.ID] = item
+	}
+
+	var created []database.Post
+	err = s.Db.InTx(ctx, func(tx storage.Store) error {
+		err := recordFetchWarnings(tx, feed, fetchedFeed)
+		if err != nil {
+			return err
+		}
+		created, err = tx.CreatePosts(ctx, postParams)
+		if err != nil {
+			return fmt.Errorf("error creating posts: %w", err)
+		}
+		var enclosureParams []database.CreatePostEnclosureParams
+		for _, post := range created {
+			enclosureParams = append(enclosureParams, postEnclosures(post, items[post.ID])...)
+		}
+		err = tx.CreatePostEnclosures(ctx, enclosureParams)
+		if err != nil {
+			return fmt.Errorf("error saving enclosures: %w", err)
+		}
+		return tx.MarkFeedFetched(ctx, feed.ID)
+	})
+	if err != nil {
+		return result, err
+	}
+	result.Created = created
+	result.Duplicates = len(postParams) - len(created)
+	return result, nil
+}
+
+// recordFetchFailure marks feed fetched with fetchErr as its warning.
+func recordFetchFailure(s *state, feed database.Feed, fetchErr error) error {
+	ctx := s.context()
+	err := s.Db.SetFeedFetchWarning(ctx, database.SetFeedFetchWarningParams{
+		ID:               feed.ID,
+		LastFetchWarning: stringToNullString("fetch failed: " + fetchErr.Error()),
+	})
+	if err != nil {
+		return fmt.Errorf("error recording the failed fetch: %w", err)
+	}
+	err = s.Db.MarkFeedFetched(ctx, feed.ID)
+	if err != nil {
+		return fmt.Errorf("error recording the failed fetch: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,212 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+
+	"golang.org/x/term"
+)
+
+const (
+	shellPrompt      = "gator> "
+	shellHistoryFile = ".gator_history"
+	shellHistorySize = 500
+)
+
+// handlerShell reads commands from stdin and runs them through the registry
+// against one long-lived state, so the config and database connection are
+// set up once. On a terminal it offers line editing and history.
+func (c *commands) handlerShell(s *state, cmd Command) error {
+	// Global flags given to one command shouldn't stick to the next.
+	baseGlobals := *c.globals
+	execute := func(line string) {
+		words, err := splitWords(line)
+		if err != nil {
+			fmt.Println("Error:", err)
+			return
+		}
+		if len(words) == 0 {
+			return
+		}
+		if words[0] == "shell" {
+			fmt.Println("Error: already in the shell")
+			return
+		}
+		*c.globals = baseGlobals
+		// Ctrl-C stops the command, such as agg, rather than the shell.
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+		s.ctx = ctx
+		err = c.run(s, Command{Name: words[0], Args: words[1:]})
+		stop()
+		s.ctx = nil
+		if err != nil {
+			fmt.Println("Error:", err)
+		}
+	}
+
+	stdin := int(os.Stdin.Fd())
+	if !term.IsTerminal(stdin) {
+		scanner := bufio.NewScanner(os.Stdin)
+		for scanner.Scan() {
+			line := strings.TrimSpace(scanner.Text())
+			if isShellExit(line) {
+				break
+			}
+			execute(line)
+		}
+		return scanner.Err()
+	}
+
+	history := loadShellHistory(s)
+	defer history.save()
+	screen := struct {
+		io.Reader
+		io.Writer
+	}{os.Stdin, os.Stdout}
+	terminal := term.NewTerminal(screen, shellPrompt)
+	terminal.History = history
+	fmt.Println("Type 'help' for commands, 'exit' or Ctrl-D to leave.")
+	for {
+		// Raw mode is only needed while editing the line; commands print
+		// with plain newlines and would staircase under it.
+		oldState, err := term.MakeRaw(stdin)
+		if err != nil {
+			return err
+		}
+		if width, height, err := term.GetSize(stdin); err == nil && width > 0 {
+			terminal.SetSize(width, height)
+		}
+		line, err := terminal.ReadLine()
+		term.Restore(stdin, oldState)
+		if errors.Is(err, io.EOF) {
+			fmt.Println()
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		line = strings.TrimSpace(line)
+		if isShellExit(line) {
+			return nil
+		}
+		execute(line)
+	}
+}
+
+func isShellExit(line string) bool {
+	return line == "exit" || line == "quit"
+}
+
+// splitWords splits a shell line into words, honoring single quotes, double
+// quotes and backslash escapes the way a POSIX shell would.
+func splitWords(line string) ([]string, error) {
+	var words []string
+	var word strings.Builder
+	inWord := false
+	var quote rune
+	escaped := false
+	for _, r := range line {
+		switch {
+		case escaped:
+			word.WriteRune(r)
+			escaped = false
+		case quote == '\'':
+			if r == '\'' {
+				quote = 0
+			} else {
+				word.WriteRune(r)
+			}
+		case r == '\\':
+			escaped = true
+			inWord = true
+		case quote == '"':
+			if r == '"' {
+				quote = 0
+			} else {
+				word.WriteRune(r)
+			}
+		case r == '\'' || r == '"':
+			quote = r
+			inWord = true
+		case r == ' ' || r == '\t':
+			if inWord {
+				words = append(words, word.String())
+				word.Reset()
+				inWord = false
+			}
+		default:
+			word.WriteRune(r)
+			inWord = true
+		}
+	}
+	if quote != 0 {
+		return nil, fmt.Errorf("unterminated %c quote", quote)
+	}
+	if escaped {
+		return nil, fmt.Errorf("line ends with a backslash")
+	}
+	if inWord {
+		words = append(words, word.String())
+	}
+	return words, nil
+}
+
+// shellHistory is the shell's term.History, kept in a file next to the
+// config so it survives between sessions.
+type shellHistory struct {
+	path string
+	// entries is oldest first.
+	entries []string
+}
+
+func loadShellHistory(s *state) *shellHistory {
+	history := &shellHistory{}
+	configPath, err := s.Config.FilePath()
+	if err != nil {
+		return history
+	}
+	history.path = filepath.Join(filepath.Dir(configPath), shellHistoryFile)
+	data, err := os.ReadFile(history.path)
+	if err != nil {
+		return history
+	}
+	for _, line := range strings.Split(string(data), "\n") {
+		if line != "" {
+			history.Add(line)
+		}
+	}
+	return history
+}
+
+func (h *shellHistory) Add(entry string) {
+	if entry == "" || (len(h.entries) > 0 && h.entries[len(h.entries)-1] == entry) {
+		return
+	}
+	h.entries = append(h.entries, entry)
+	if len(h.entries) > shellHistorySize {
+		h.entries = h.entries[len(h.entries)-shellHistorySize:]
+	}
+}
+
+func (h *shellHistory) Len() int {
+	return len(h.entries)
+}
+
+func (h *shellHistory) At(idx int) string {
+	return h.entries[len(h.entries)-1-idx]
+}
+
+func (h *shellHistory) save() {
+	if h.path == "" {
+		return
+	}
+	data := strings.Join(h.entries, "\n") + "\n"
+	os.WriteFile(h.path, []byte(data), 0600)
+}
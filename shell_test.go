@@ -0,0 +1,96 @@
+package main
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSplitWords(t *testing.T) {
+	cases := []struct {
+		line  string
+		words []string
+		err   string
+	}{
+		{line: "", words: nil},
+		{line: "  feeds  ", words: []string{"feeds"}},
+		{line: "addfeed Blog https://example.com", words: []string{"addfeed", "Blog", "https://example.com"}},
+		{line: "addfeed\t'My Blog'  url", words: []string{"addfeed", "My Blog", "url"}},
+		{line: `addfeed "Gus's Blog" url`, words: []string{"addfeed", "Gus's Blog", "url"}},
+		{line: `addfeed 'say "hi"' url`, words: []string{"addfeed", `say "hi"`, "url"}},
+		{line: `addfeed My\ Blog url`, words: []string{"addfeed", "My Blog", "url"}},
+		{line: `echo "a\"b" 'c\d'`, words: []string{"echo", `a"b`, `c\d`}},
+		{line: `echo "" ''`, words: []string{"echo", "", ""}},
+		{line: `echo pre"quoted"post`, words: []string{"echo", "prequotedpost"}},
+		{line: `echo 'open`, err: "unterminated ' quote"},
+		{line: `echo "open`, err: `unterminated " quote`},
+		{line: `echo trailing\`, err: "ends with a backslash"},
+	}
+	for _, c := range cases {
+		words, err := splitWords(c.line)
+		if c.err != "" {
+			if err == nil || !strings.Contains(err.Error(), c.err) {
+				t.Errorf("%q: expected error %q, got %q (%v)", c.line, c.err, words, err)
+			}
+			continue
+		}
+		if err != nil || !slices.Equal(words, c.words) {
+			t.Errorf("%q: expected %q, got %q (%v)", c.line, c.words, words, err)
+		}
+	}
+}
+
+func TestShellHistoryPersists(t *testing.T) {
+	s := newTestState(t)
+	history := loadShellHistory(s)
+	if history.Len() != 0 {
+		t.Fatalf("expected no history to start with, got %d entries", history.Len())
+	}
+	for _, entry := range []string{"feeds", "feeds", "", "browse 5"} {
+		history.Add(entry)
+	}
+	history.save()
+
+	path := filepath.Join(filepath.Dir(s.Config.Path), shellHistoryFile)
+	info, err := os.Stat(path)
+	if err != nil || info.Mode().Perm() != 0600 {
+		t.Fatalf("expected a private history file, got %v (%v)", info, err)
+	}
+	loaded := loadShellHistory(s)
+	if loaded.Len() != 2 || loaded.At(0) != "browse 5" || loaded.At(1) != "feeds" {
+		t.Fatalf("expected the saved entries newest first, got %q", loaded.entries)
+	}
+
+	for i := range shellHistorySize + 10 {
+		loaded.Add(strings.Repeat("x", i+1))
+	}
+	if loaded.Len() != shellHistorySize || loaded.At(0) != strings.Repeat("x", shellHistorySize+10) {
+		t.Fatalf("expected history capped at %d newest entries, got %d", shellHistorySize, loaded.Len())
+	}
+}
+
+func TestAggStopsWhenCancelled(t *testing.T) {
+	s := newTestState(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	s.ctx = ctx
+	done := make(chan error, 1)
+	var out string
+	go func() {
+		var err error
+		out, err = runCommand(t, s, "agg", "1h")
+		done <- err
+	}()
+	select {
+	case err := <-done:
+		if err != nil || !strings.Contains(out, "Stopped collecting feeds") {
+			t.Fatalf("expected agg to stop cleanly, got %q (%v)", out, err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatalf("expected agg to stop once its context was cancelled")
+	}
+}
@@ -19,7 +19,7 @@ JOIN users ON follow.user_id = users.id;
 
 -- name: GetFeedFollowsForUser :many
 
-SELECT follow.*, feeds.name AS feed_name, users.name AS user_name
+SELECT follow.*, feeds.name AS feed_name, users.name AS user_name, feeds.url AS feed_url
 FROM feed_follows follow
 JOIN feeds ON follow.feed_id = feeds.id
 JOIN users ON follow.user_id = users.id
@@ -29,3 +29,6 @@ WHERE users.id = $1;
 -- name: DeleteFeedFollowByUser :exec
 
 DELETE FROM feed_follows WHERE user_id = $1 AND feed_id = $2;
+
+-- name: GetAllFeedFollows :many
+SELECT * FROM feed_follows ORDER BY created_at, id;
@@ -23,3 +23,19 @@ UPDATE feeds SET last_fetched_at = CURRENT_TIMESTAMP, updated_at = CURRENT_TIMES
 
 -- name: GetNextFeedToFetch :one
 SELECT * FROM feeds ORDER BY last_fetched_at ASC NULLS FIRST LIMIT 1;
+
+-- name: SetFeedFetchWarning :exec
+UPDATE feeds SET last_fetch_warning = $2 WHERE id = $1;
+
+-- name: GetFeedByID :one
+SELECT * FROM feeds WHERE id = $1;
+
+-- name: GetNextFeedsToFetch :many
+SELECT * FROM feeds ORDER BY last_fetched_at ASC NULLS FIRST LIMIT $1;
+
+-- name: DeleteAllFeeds :exec
+DELETE FROM feeds;
+
+-- name: RestoreFeed :exec
+INSERT INTO feeds (id, created_at, updated_at, last_fetched_at, name, url, user_id, last_fetch_warning)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8);
@@ -0,0 +1,20 @@
+-- name: CreatePostEnclosure :one
+INSERT INTO post_enclosures (id, created_at, updated_at, post_id, url, mime_type, length, duration, episode, image_url)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+RETURNING *;
+
+-- name: GetEnclosuresForPost :many
+SELECT * FROM post_enclosures WHERE post_id = $1 ORDER BY created_at;
+
+-- name: InsertPostEnclosures :exec
+-- InsertPostEnclosures adds a batch of enclosures, given as a JSON array of
+-- objects keyed by column name, skipping those already stored.
+INSERT INTO post_enclosures (id, created_at, updated_at, post_id, url, mime_type, length, duration, episode, image_url)
+SELECT (e->>'id')::uuid, (e->>'created_at')::timestamptz, (e->>'updated_at')::timestamptz,
+    (e->>'post_id')::uuid, e->>'url', e->>'mime_type', (e->>'length')::bigint,
+    e->>'duration', e->>'episode', e->>'image_url'
+FROM json_array_elements(sqlc.arg('enclosures')::json) AS e
+ON CONFLICT DO NOTHING;
+
+-- name: GetAllPostEnclosures :many
+SELECT * FROM post_enclosures ORDER BY created_at, id;
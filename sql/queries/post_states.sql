@@ -0,0 +1,45 @@
+-- name: SetPostRead :exec
+INSERT INTO post_states (id, created_at, updated_at, user_id, post_id, read_at)
+VALUES ($1, $2, $3, $4, $5, $6)
+ON CONFLICT (user_id, post_id) DO UPDATE
+SET read_at = EXCLUDED.read_at, updated_at = EXCLUDED.updated_at;
+
+-- name: SetPostStarred :exec
+INSERT INTO post_states (id, created_at, updated_at, user_id, post_id, starred)
+VALUES ($1, $2, $3, $4, $5, $6)
+ON CONFLICT (user_id, post_id) DO UPDATE
+SET starred = EXCLUDED.starred, updated_at = EXCLUDED.updated_at;
+
+-- name: GetPostsWithStateForUser :many
+SELECT p.*,
+    (ps.read_at IS NOT NULL)::boolean AS read,
+    COALESCE(ps.starred, false)::boolean AS starred,
+    f.name AS feed_name
+FROM posts p
+JOIN feeds f ON f.id = p.feed_id
+JOIN feed_follows ff ON ff.feed_id = p.feed_id
+LEFT JOIN post_states ps ON ps.post_id = p.id AND ps.user_id = ff.user_id
+WHERE ff.user_id = sqlc.arg('user_id')
+    AND (sqlc.narg('feed_id')::uuid IS NULL OR p.feed_id = sqlc.narg('feed_id'))
+    AND (NOT sqlc.arg('unread_only')::boolean OR ps.read_at IS NULL)
+    AND (NOT sqlc.arg('starred_only')::boolean OR COALESCE(ps.starred, false))
+ORDER BY p.published_at DESC
+LIMIT sqlc.arg('limit');
+
+-- name: GetUnreadCountsForUser :many
+SELECT f.id, f.name, f.url,
+    COUNT(p.id) FILTER (WHERE ps.read_at IS NULL) AS unread
+FROM feed_follows ff
+JOIN feeds f ON f.id = ff.feed_id
+LEFT JOIN posts p ON p.feed_id = f.id
+LEFT JOIN post_states ps ON ps.post_id = p.id AND ps.user_id = ff.user_id
+WHERE ff.user_id = $1
+GROUP BY f.id, f.name, f.url
+ORDER BY f.name;
+
+-- name: GetAllPostStates :many
+SELECT * FROM post_states ORDER BY created_at, id;
+
+-- name: RestorePostState :exec
+INSERT INTO post_states (id, created_at, updated_at, user_id, post_id, read_at, starred)
+VALUES ($1, $2, $3, $4, $5, $6, $7);
@@ -1,6 +1,6 @@
 -- name: CreatePost :one
-INSERT INTO posts (id, created_at, updated_at, title, url, description, published_at, feed_id)
-VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+INSERT INTO posts (id, created_at, updated_at, title, url, description, published_at, feed_id, thumbnail_url)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
 RETURNING *;
 
 
@@ -10,3 +10,53 @@ JOIN feed_follows ff on ff.feed_id = p.feed_id
 WHERE ff.user_id = $1
 ORDER BY p.published_at DESC
 LIMIT $2;
+
+-- name: GetPostByID :one
+SELECT * FROM posts WHERE id = $1;
+
+-- name: InsertPosts :many
+-- InsertPosts adds a batch of posts, given as a JSON array of objects keyed
+-- by column name, skipping those already stored.
+INSERT INTO posts (id, created_at, updated_at, title, url, description, published_at, feed_id, thumbnail_url)
+SELECT (p->>'id')::uuid, (p->>'created_at')::timestamptz, (p->>'updated_at')::timestamptz,
+    p->>'title', p->>'url', p->>'description', (p->>'published_at')::timestamptz,
+    (p->>'feed_id')::uuid, p->>'thumbnail_url'
+FROM json_array_elements(sqlc.arg('posts')::json) AS p
+ON CONFLICT DO NOTHING
+RETURNING *;
+
+-- name: GetPrunablePosts :many
+-- GetPrunablePosts lists a feed's posts that are older than published_before
+-- or beyond its keep_last newest (zero keeps all), leaving out posts anyone
+-- has starred. unread marks the posts a follower hasn't read, which are kept.
+SELECT p.id, p.feed_id, p.title, p.url, p.published_at,
+    EXISTS (
+        SELECT 1 FROM feed_follows ff
+        LEFT JOIN post_states ps ON ps.post_id = p.id AND ps.user_id = ff.user_id
+        WHERE ff.feed_id = p.feed_id AND ps.read_at IS NULL
+    ) AS unread
+FROM posts p
+WHERE p.feed_id = sqlc.arg('feed_id')
+    AND (p.published_at < sqlc.arg('published_before')::timestamptz
+        OR (sqlc.arg('keep_last')::int > 0 AND (
+            SELECT COUNT(*) FROM posts newer
+            WHERE newer.feed_id = p.feed_id
+                AND (newer.published_at > p.published_at
+                    OR (newer.published_at = p.published_at AND newer.id < p.id))
+        ) >= sqlc.arg('keep_last')::int))
+    AND NOT EXISTS (
+        SELECT 1 FROM post_states ps WHERE ps.post_id = p.id AND ps.starred
+    )
+ORDER BY p.published_at;
+
+-- name: DeletePostsByID :exec
+-- DeletePostsByID deletes the posts whose IDs are in a JSON array, along with
+-- their enclosures and states.
+DELETE FROM posts
+WHERE id IN (SELECT value::uuid FROM json_array_elements_text(sqlc.arg('ids')::json));
+
+-- name: GetAllPosts :many
+SELECT * FROM posts ORDER BY created_at, id;
+
+-- name: DeleteAllPosts :exec
+DELETE FROM posts;
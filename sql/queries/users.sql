@@ -9,10 +9,33 @@ VALUES (
 RETURNING *;
 
 -- name: GetUserByName :one
-SELECT * FROM users WHERE name = $1 LIMIT 1;
+SELECT * FROM users WHERE lower(name) = lower($1) LIMIT 1;
 
 -- name: DeleteAllUsers :exec
 DELETE FROM users;
 
 -- name: GetAllUsers :many
 SELECT * FROM USERS;
+
+-- name: DeleteUser :exec
+DELETE FROM users WHERE id = $1;
+
+-- name: RenameUser :one
+UPDATE users SET name = $2, updated_at = $3
+WHERE id = $1
+RETURNING *;
+
+-- name: GetUserStats :one
+SELECT
+    (SELECT COUNT(*) FROM feeds WHERE feeds.user_id = $1) AS feeds_added,
+    (SELECT COUNT(*) FROM feed_follows WHERE feed_follows.user_id = $1) AS follows,
+    (SELECT COUNT(*) FROM post_states WHERE post_states.user_id = $1 AND read_at IS NOT NULL) AS read_posts,
+    (SELECT COUNT(*) FROM post_states WHERE post_states.user_id = $1 AND starred) AS starred_posts;
+
+-- name: GetUserDeletionImpact :one
+SELECT
+    (SELECT COUNT(*) FROM posts JOIN feeds ON posts.feed_id = feeds.id WHERE feeds.user_id = $1) AS posts,
+    (SELECT COUNT(*) FROM feed_follows JOIN feeds ON feed_follows.feed_id = feeds.id
+        WHERE feeds.user_id = $1 AND feed_follows.user_id <> $1) AS other_follows,
+    (SELECT COUNT(DISTINCT feed_follows.user_id) FROM feed_follows JOIN feeds ON feed_follows.feed_id = feeds.id
+        WHERE feeds.user_id = $1 AND feed_follows.user_id <> $1) AS other_followers;
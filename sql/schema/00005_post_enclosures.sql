@@ -0,0 +1,24 @@
+-- +goose Up
+-- +goose StatementBegin
+SELECT 'up SQL query';
+CREATE TABLE IF NOT EXISTS post_enclosures (
+    id UUID NOT NULL,
+    created_at TIMESTAMP NOT NULL,
+    updated_at TIMESTAMP NOT NULL,
+    post_id UUID NOT NULL REFERENCES posts ON DELETE CASCADE,
+    url TEXT NOT NULL,
+    mime_type TEXT NULL,
+    length BIGINT NULL,
+    duration TEXT NULL,
+    episode TEXT NULL,
+    image_url TEXT NULL,
+    UNIQUE (post_id, url),
+    PRIMARY KEY(id)
+);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+SELECT 'down SQL query';
+DROP TABLE IF EXISTS post_enclosures;
+-- +goose StatementEnd
@@ -0,0 +1,11 @@
+-- +goose Up
+-- +goose StatementBegin
+SELECT 'up SQL query';
+ALTER TABLE posts ADD COLUMN IF NOT EXISTS thumbnail_url TEXT NULL;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+SELECT 'down SQL query';
+ALTER TABLE posts DROP COLUMN IF EXISTS thumbnail_url;
+-- +goose StatementEnd
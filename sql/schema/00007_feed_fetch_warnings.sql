@@ -0,0 +1,11 @@
+-- +goose Up
+-- +goose StatementBegin
+SELECT 'up SQL query';
+ALTER TABLE feeds ADD COLUMN IF NOT EXISTS last_fetch_warning TEXT NULL;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+SELECT 'down SQL query';
+ALTER TABLE feeds DROP COLUMN IF EXISTS last_fetch_warning;
+-- +goose StatementEnd
@@ -0,0 +1,14 @@
+-- +goose Up
+-- +goose StatementBegin
+SELECT 'up SQL query';
+-- Names are unique ignoring case, so "Shawn" can't register beside "shawn".
+-- This fails if two existing users' names differ only in case; rename one
+-- first.
+CREATE UNIQUE INDEX IF NOT EXISTS users_name_lower_key ON users (lower(name));
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+SELECT 'down SQL query';
+DROP INDEX IF EXISTS users_name_lower_key;
+-- +goose StatementEnd
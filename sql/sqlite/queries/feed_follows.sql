@@ -0,0 +1,27 @@
+-- SQLite has no INSERT inside WITH, so CreateFeedFollow is an insert
+-- followed by GetFeedFollow.
+
+-- name: InsertFeedFollow :one
+INSERT INTO feed_follows (id, created_at, updated_at, user_id, feed_id)
+VALUES (?, ?, ?, ?, ?)
+RETURNING *;
+
+-- name: GetFeedFollow :one
+SELECT follow.*, feeds.name AS feed_name, users.name AS user_name
+FROM feed_follows follow
+JOIN feeds ON follow.feed_id = feeds.id
+JOIN users ON follow.user_id = users.id
+WHERE follow.id = ?;
+
+-- name: GetFeedFollowsForUser :many
+SELECT follow.*, feeds.name AS feed_name, users.name AS user_name, feeds.url AS feed_url
+FROM feed_follows follow
+JOIN feeds ON follow.feed_id = feeds.id
+JOIN users ON follow.user_id = users.id
+WHERE users.id = ?;
+
+-- name: DeleteFeedFollowByUser :exec
+DELETE FROM feed_follows WHERE user_id = ? AND feed_id = ?;
+
+-- name: GetAllFeedFollows :many
+SELECT * FROM feed_follows ORDER BY created_at, id;
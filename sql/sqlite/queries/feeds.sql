@@ -0,0 +1,35 @@
+-- name: CreateFeed :one
+INSERT INTO feeds (id, created_at, updated_at, name, url, user_id)
+VALUES (?, ?, ?, ?, ?, ?)
+RETURNING *;
+
+-- name: GetAllFeeds :many
+SELECT * FROM feeds;
+
+-- name: GetAllFeedsWithUsers :many
+SELECT f.*, u.name AS user_name FROM feeds f JOIN users u ON u.id = f.user_id;
+
+-- name: GetFeedByUrl :one
+SELECT * FROM feeds WHERE url = ?;
+
+-- name: MarkFeedFetched :exec
+UPDATE feeds SET last_fetched_at = sqlc.arg('last_fetched_at'), updated_at = sqlc.arg('updated_at') WHERE id = sqlc.arg('id');
+
+-- name: GetNextFeedToFetch :one
+SELECT * FROM feeds ORDER BY last_fetched_at ASC NULLS FIRST LIMIT 1;
+
+-- name: SetFeedFetchWarning :exec
+UPDATE feeds SET last_fetch_warning = sqlc.narg('last_fetch_warning') WHERE id = sqlc.arg('id');
+
+-- name: GetFeedByID :one
+SELECT * FROM feeds WHERE id = ?;
+
+-- name: GetNextFeedsToFetch :many
+SELECT * FROM feeds ORDER BY last_fetched_at ASC NULLS FIRST LIMIT ?;
+
+-- name: DeleteAllFeeds :exec
+DELETE FROM feeds;
+
+-- name: RestoreFeed :exec
+INSERT INTO feeds (id, created_at, updated_at, last_fetched_at, name, url, user_id, last_fetch_warning)
+VALUES (?, ?, ?, ?, ?, ?, ?, ?);
@@ -0,0 +1,23 @@
+-- name: CreatePostEnclosure :one
+INSERT INTO post_enclosures (id, created_at, updated_at, post_id, url, mime_type, length, duration, episode, image_url)
+VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+RETURNING *;
+
+-- name: GetEnclosuresForPost :many
+SELECT * FROM post_enclosures WHERE post_id = ? ORDER BY created_at;
+
+-- name: InsertPostEnclosures :exec
+-- InsertPostEnclosures adds a batch of enclosures, given as a JSON array of
+-- objects keyed by column name, skipping those already stored. WHERE true
+-- stops SQLite reading ON CONFLICT as a join constraint.
+INSERT INTO post_enclosures (id, created_at, updated_at, post_id, url, mime_type, length, duration, episode, image_url)
+SELECT json_extract(value, '$.id'), json_extract(value, '$.created_at'), json_extract(value, '$.updated_at'),
+    json_extract(value, '$.post_id'), json_extract(value, '$.url'), json_extract(value, '$.mime_type'),
+    json_extract(value, '$.length'), json_extract(value, '$.duration'), json_extract(value, '$.episode'),
+    json_extract(value, '$.image_url')
+FROM (SELECT CAST(sqlc.arg('enclosures') AS TEXT) AS batch) AS b, json_each(b.batch)
+WHERE true
+ON CONFLICT DO NOTHING;
+
+-- name: GetAllPostEnclosures :many
+SELECT * FROM post_enclosures ORDER BY created_at, id;
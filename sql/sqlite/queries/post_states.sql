@@ -0,0 +1,45 @@
+-- name: SetPostRead :exec
+INSERT INTO post_states (id, created_at, updated_at, user_id, post_id, read_at)
+VALUES (?, ?, ?, ?, ?, ?)
+ON CONFLICT (user_id, post_id) DO UPDATE
+SET read_at = excluded.read_at, updated_at = excluded.updated_at;
+
+-- name: SetPostStarred :exec
+INSERT INTO post_states (id, created_at, updated_at, user_id, post_id, starred)
+VALUES (?, ?, ?, ?, ?, ?)
+ON CONFLICT (user_id, post_id) DO UPDATE
+SET starred = excluded.starred, updated_at = excluded.updated_at;
+
+-- name: GetPostsWithStateForUser :many
+SELECT p.*,
+    CAST(ps.read_at IS NOT NULL AS BOOLEAN) AS read,
+    CAST(COALESCE(ps.starred, FALSE) AS BOOLEAN) AS starred,
+    f.name AS feed_name
+FROM posts p
+JOIN feeds f ON f.id = p.feed_id
+JOIN feed_follows ff ON ff.feed_id = p.feed_id
+LEFT JOIN post_states ps ON ps.post_id = p.id AND ps.user_id = ff.user_id
+WHERE ff.user_id = sqlc.arg('user_id')
+    AND (CAST(sqlc.narg('feed_id') AS UUID) IS NULL OR p.feed_id = sqlc.narg('feed_id'))
+    AND (CAST(sqlc.arg('unread_only') AS BOOLEAN) = FALSE OR ps.read_at IS NULL)
+    AND (CAST(sqlc.arg('starred_only') AS BOOLEAN) = FALSE OR COALESCE(ps.starred, FALSE))
+ORDER BY p.published_at DESC
+LIMIT sqlc.arg('limit');
+
+-- name: GetUnreadCountsForUser :many
+SELECT f.id, f.name, f.url,
+    CAST(COALESCE(SUM(p.id IS NOT NULL AND ps.read_at IS NULL), 0) AS BIGINT) AS unread
+FROM feed_follows ff
+JOIN feeds f ON f.id = ff.feed_id
+LEFT JOIN posts p ON p.feed_id = f.id
+LEFT JOIN post_states ps ON ps.post_id = p.id AND ps.user_id = ff.user_id
+WHERE ff.user_id = ?
+GROUP BY f.id, f.name, f.url
+ORDER BY f.name;
+
+-- name: GetAllPostStates :many
+SELECT * FROM post_states ORDER BY created_at, id;
+
+-- name: RestorePostState :exec
+INSERT INTO post_states (id, created_at, updated_at, user_id, post_id, read_at, starred)
+VALUES (?, ?, ?, ?, ?, ?, ?);
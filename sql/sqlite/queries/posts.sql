@@ -0,0 +1,65 @@
+-- name: CreatePost :one
+INSERT INTO posts (id, created_at, updated_at, title, url, description, published_at, feed_id, thumbnail_url)
+VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)
+RETURNING *;
+
+-- name: GetPostsForUser :many
+SELECT p.* FROM posts p
+JOIN feed_follows ff on ff.feed_id = p.feed_id
+WHERE ff.user_id = ?
+ORDER BY p.published_at DESC
+LIMIT ?;
+
+-- name: GetPostByID :one
+SELECT * FROM posts WHERE id = ?;
+
+-- name: InsertPosts :many
+-- InsertPosts adds a batch of posts, given as a JSON array of objects keyed
+-- by column name, skipping those already stored. WHERE true stops SQLite
+-- reading ON CONFLICT as a join constraint.
+INSERT INTO posts (id, created_at, updated_at, title, url, description, published_at, feed_id, thumbnail_url)
+SELECT json_extract(value, '$.id'), json_extract(value, '$.created_at'), json_extract(value, '$.updated_at'),
+    json_extract(value, '$.title'), json_extract(value, '$.url'), json_extract(value, '$.description'),
+    json_extract(value, '$.published_at'), json_extract(value, '$.feed_id'), json_extract(value, '$.thumbnail_url')
+FROM (SELECT CAST(sqlc.arg('posts') AS TEXT) AS batch) AS b, json_each(b.batch)
+WHERE true
+ON CONFLICT DO NOTHING
+RETURNING *;
+
+-- name: GetPrunablePosts :many
+-- GetPrunablePosts lists a feed's posts that are older than published_before
+-- or beyond its keep_last newest (zero keeps all), leaving out posts anyone
+-- has starred. unread marks the posts a follower hasn't read, which are kept.
+SELECT p.id, p.feed_id, p.title, p.url, p.published_at,
+    EXISTS (
+        SELECT 1 FROM feed_follows ff
+        LEFT JOIN post_states ps ON ps.post_id = p.id AND ps.user_id = ff.user_id
+        WHERE ff.feed_id = p.feed_id AND ps.read_at IS NULL
+    ) AS unread
+FROM posts p
+WHERE p.feed_id = sqlc.arg('feed_id')
+    AND (p.published_at < sqlc.arg('published_before')
+        OR (CAST(sqlc.arg('keep_last') AS INTEGER) > 0 AND (
+            SELECT COUNT(*) FROM posts newer
+            WHERE newer.feed_id = p.feed_id
+                AND (newer.published_at > p.published_at
+                    OR (newer.published_at = p.published_at AND newer.id < p.id))
+        ) >= CAST(sqlc.arg('keep_last') AS INTEGER)))
+    AND NOT EXISTS (
+        SELECT 1 FROM post_states ps WHERE ps.post_id = p.id AND ps.starred
+    )
+ORDER BY p.published_at;
+
+-- name: DeletePostsByID :exec
+-- DeletePostsByID deletes the posts whose IDs are in a JSON array, along with
+-- their enclosures and states.
+DELETE FROM posts
+WHERE id IN (
+    SELECT j.value FROM (SELECT CAST(sqlc.arg('ids') AS TEXT) AS batch) AS b, json_each(b.batch) AS j
+);
+
+-- name: GetAllPosts :many
+SELECT * FROM posts ORDER BY created_at, id;
+
+-- name: DeleteAllPosts :exec
+DELETE FROM posts;
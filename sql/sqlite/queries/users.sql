@@ -0,0 +1,36 @@
+-- name: CreateUser :one
+INSERT INTO users (id, created_at, updated_at, name)
+VALUES (?, ?, ?, ?)
+RETURNING *;
+
+-- name: GetUserByName :one
+SELECT * FROM users WHERE lower(name) = lower(?) LIMIT 1;
+
+-- name: DeleteAllUsers :exec
+DELETE FROM users;
+
+-- name: GetAllUsers :many
+SELECT * FROM users;
+
+-- name: DeleteUser :exec
+DELETE FROM users WHERE id = ?;
+
+-- name: RenameUser :one
+UPDATE users SET name = ?2, updated_at = ?3
+WHERE id = ?1
+RETURNING *;
+
+-- name: GetUserStats :one
+SELECT
+    (SELECT COUNT(*) FROM feeds WHERE feeds.user_id = sqlc.arg('user_id')) AS feeds_added,
+    (SELECT COUNT(*) FROM feed_follows WHERE feed_follows.user_id = sqlc.arg('user_id')) AS follows,
+    (SELECT COUNT(*) FROM post_states WHERE post_states.user_id = sqlc.arg('user_id') AND read_at IS NOT NULL) AS read_posts,
+    (SELECT COUNT(*) FROM post_states WHERE post_states.user_id = sqlc.arg('user_id') AND starred) AS starred_posts;
+
+-- name: GetUserDeletionImpact :one
+SELECT
+    (SELECT COUNT(*) FROM posts JOIN feeds ON posts.feed_id = feeds.id WHERE feeds.user_id = sqlc.arg('user_id')) AS posts,
+    (SELECT COUNT(*) FROM feed_follows JOIN feeds ON feed_follows.feed_id = feeds.id
+        WHERE feeds.user_id = sqlc.arg('user_id') AND feed_follows.user_id <> sqlc.arg('user_id')) AS other_follows,
+    (SELECT COUNT(DISTINCT feed_follows.user_id) FROM feed_follows JOIN feeds ON feed_follows.feed_id = feeds.id
+        WHERE feeds.user_id = sqlc.arg('user_id') AND feed_follows.user_id <> sqlc.arg('user_id')) AS other_followers;
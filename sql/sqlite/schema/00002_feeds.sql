@@ -0,0 +1,18 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS feeds (
+    id UUID NOT NULL,
+    created_at TIMESTAMP NOT NULL,
+    updated_at TIMESTAMP NOT NULL,
+    last_fetched_at TIMESTAMP,
+    name text NOT NULL,
+    url text UNIQUE NOT NULL,
+    user_id UUID NOT NULL REFERENCES users ON DELETE CASCADE,
+    PRIMARY KEY(id)
+);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS feeds;
+-- +goose StatementEnd
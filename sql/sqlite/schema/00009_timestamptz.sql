@@ -0,0 +1,27 @@
+-- +goose Up
+-- +goose StatementBegin
+-- SQLite has no time zone type; times are stored as text, which only sorts
+-- correctly when every value is in the same zone. Rewrite those stored with
+-- another zone's offset in UTC.
+UPDATE users SET created_at = strftime('%Y-%m-%d %H:%M:%f+00:00', created_at),
+    updated_at = strftime('%Y-%m-%d %H:%M:%f+00:00', updated_at);
+UPDATE feeds SET created_at = strftime('%Y-%m-%d %H:%M:%f+00:00', created_at),
+    updated_at = strftime('%Y-%m-%d %H:%M:%f+00:00', updated_at),
+    last_fetched_at = strftime('%Y-%m-%d %H:%M:%f+00:00', last_fetched_at);
+UPDATE feed_follows SET created_at = strftime('%Y-%m-%d %H:%M:%f+00:00', created_at),
+    updated_at = strftime('%Y-%m-%d %H:%M:%f+00:00', updated_at);
+UPDATE posts SET created_at = strftime('%Y-%m-%d %H:%M:%f+00:00', created_at),
+    updated_at = strftime('%Y-%m-%d %H:%M:%f+00:00', updated_at),
+    published_at = strftime('%Y-%m-%d %H:%M:%f+00:00', published_at);
+UPDATE post_enclosures SET created_at = strftime('%Y-%m-%d %H:%M:%f+00:00', created_at),
+    updated_at = strftime('%Y-%m-%d %H:%M:%f+00:00', updated_at);
+UPDATE post_states SET created_at = strftime('%Y-%m-%d %H:%M:%f+00:00', created_at),
+    updated_at = strftime('%Y-%m-%d %H:%M:%f+00:00', updated_at),
+    read_at = strftime('%Y-%m-%d %H:%M:%f+00:00', read_at);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+-- UTC values read back the same, so there is nothing to undo.
+SELECT 1;
+-- +goose StatementEnd
@@ -0,0 +1,12 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Names are unique ignoring case, so "Shawn" can't register beside "shawn".
+-- This fails if two existing users' names differ only in case; rename one
+-- first.
+CREATE UNIQUE INDEX IF NOT EXISTS users_name_lower_key ON users (lower(name));
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS users_name_lower_key;
+-- +goose StatementEnd
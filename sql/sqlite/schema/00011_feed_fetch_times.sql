@@ -0,0 +1,14 @@
+-- +goose Up
+-- +goose StatementBegin
+-- MarkFeedFetched used CURRENT_TIMESTAMP, whose text has no fraction or
+-- offset, so fetch times written since 00009 don't sort among the others.
+-- Rewrite them in the same UTC layout 00009 used.
+UPDATE feeds SET updated_at = strftime('%Y-%m-%d %H:%M:%f+00:00', updated_at),
+    last_fetched_at = strftime('%Y-%m-%d %H:%M:%f+00:00', last_fetched_at);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+-- UTC values read back the same, so there is nothing to undo.
+SELECT 1;
+-- +goose StatementEnd
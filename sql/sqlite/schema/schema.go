@@ -0,0 +1,9 @@
+// Package schema embeds the SQLite versions of the goose migrations in
+// sql/schema. Each file here matches the Postgres migration with the same
+// version.
+package schema
+
+import "embed"
+
+//go:embed *.sql
+var Migrations embed.FS
@@ -0,0 +1,110 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/WagnerJust/go-gator/internal/database"
+	"github.com/WagnerJust/go-gator/internal/tui"
+	"github.com/google/uuid"
+)
+
+// tuiPostLimit caps how many posts the reader loads for one folder or feed.
+const tuiPostLimit = 500
+
+// tuiSource adapts the database to what the tui reader needs for one user.
+type tuiSource struct {
+	s    *state
+	user database.User
+}
+
+func (t tuiSource) Feeds() ([]tui.Feed, error) {
+	rows, err := t.s.Db.GetUnreadCountsForUser(context.Background(), t.user.ID)
+	if err != nil {
+		return nil, err
+	}
+	feeds := make([]tui.Feed, 0, len(rows))
+	for _, row := range rows {
+		feeds = append(feeds, tui.Feed{
+			ID:     row.ID,
+			Name:   row.Name,
+			URL:    row.Url,
+			Unread: int(row.Unread),
+		})
+	}
+	return feeds, nil
+}
+
+func (t tuiSource) Posts(filter tui.Filter) ([]tui.Post, error) {
+	params := database.GetPostsWithStateForUserParams{
+		UserID:      t.user.ID,
+		FeedID:      filter.FeedID,
+		UnreadOnly:  filter.UnreadOnly,
+		StarredOnly: filter.StarredOnly,
+		Limit:       tuiPostLimit,
+	}
+	rows, err := t.s.Db.GetPostsWithStateForUser(context.Background(), params)
+	if err != nil {
+		return nil, err
+	}
+	location := t.s.Config.Output.Location()
+	posts := make([]tui.Post, 0, len(rows))
+	for _, row := range rows {
+		posts = append(posts, tui.Post{
+			ID:        row.ID,
+			FeedID:    row.FeedID,
+			FeedName:  row.FeedName,
+			Title:     row.Title,
+			URL:       row.Url,
+			Body:      row.Description.String,
+			Published: row.PublishedAt.In(location),
+			Read:      row.Read,
+			Starred:   row.Starred,
+		})
+	}
+	return posts, nil
+}
+
+func (t tuiSource) SetRead(postID uuid.UUID, read bool) error {
+	params := database.SetPostReadParams{
+		ID:        uuid.New(),
+		CreatedAt: time.Now().UTC(),
+		UpdatedAt: time.Now().UTC(),
+		UserID:    t.user.ID,
+		PostID:    postID,
+	}
+	if read {
+		params.ReadAt.Time = time.Now().UTC()
+		params.ReadAt.Valid = true
+	}
+	return t.s.Db.SetPostRead(context.Background(), params)
+}
+
+func (t tuiSource) SetStarred(postID uuid.UUID, starred bool) error {
+	params := database.SetPostStarredParams{
+		ID:        uuid.New(),
+		CreatedAt: time.Now().UTC(),
+		UpdatedAt: time.Now().UTC(),
+		UserID:    t.user.ID,
+		PostID:    postID,
+		Starred:   starred,
+	}
+	return t.s.Db.SetPostStarred(context.Background(), params)
+}
+
+func (t tuiSource) Refresh(feedID uuid.UUID) (string, error) {
+	feed, err := t.s.Db.GetFeedByID(context.Background(), feedID)
+	if err != nil {
+		return "", err
+	}
+	result, err := scrapeFeed(t.s, feed)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%s: %d new", feed.Name, len(result.Created)), nil
+}
+
+func handlerTUI(s *state, cmd Command, user database.User) error {
+	return tui.Run(tuiSource{s: s, user: user}, user.Name)
+}
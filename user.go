@@ -0,0 +1,182 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/WagnerJust/go-gator/internal/database"
+	"github.com/WagnerJust/go-gator/internal/output"
+	"github.com/WagnerJust/go-gator/internal/storage"
+)
+
+// maxUserNameLength is the users.name column's varchar limit.
+const maxUserNameLength = 20
+
+// validateUserName checks a new user name against what the users table
+// holds, so register and rename fail with a reason rather than a database
+// error.
+func validateUserName(name string) error {
+	if name == "" {
+		return errors.New("user names can't be empty")
+	}
+	if len(name) > maxUserNameLength {
+		return fmt.Errorf("user name %q is %d characters; the most is %d", name, len(name), maxUserNameLength)
+	}
+	for _, r := range name {
+		if !isNameChar(r) {
+			return fmt.Errorf("user name %q has %q; use letters, digits, '-', '_' and '.'", name, r)
+		}
+	}
+	if !isNameChar(rune(name[0])) || strings.ContainsRune("-_.", rune(name[0])) {
+		return fmt.Errorf("user name %q must start with a letter or digit", name)
+	}
+	return nil
+}
+
+func isNameChar(r rune) bool {
+	return r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || strings.ContainsRune("-_.", r)
+}
+
+// nameTakenError explains a duplicate error from creating or renaming a
+// user, passing other errors through.
+func nameTakenError(name string, err error) error {
+	if storage.IsDuplicate(err) {
+		return fmt.Errorf("a user named %s already exists (names ignore case)", name)
+	}
+	return err
+}
+
+func handlerUser(s *state, cmd Command) error {
+	usage := fmt.Errorf("usage: %s user show [name] | rename <name> <new_name> | delete <name> [--yes]", programName)
+	action, args := cmd.Args[0], cmd.Args[1:]
+	switch {
+	case action == "show" && len(args) <= 1:
+		name := s.currentUserName()
+		if len(args) == 1 {
+			name = args[0]
+		}
+		if name == "" {
+			return errors.New("not logged in; pass a name")
+		}
+		return showUser(s, name)
+	case action == "rename" && len(args) == 2:
+		return renameUser(s, args[0], args[1])
+	case action == "delete" && len(args) == 1:
+		return deleteUser(s, cmd, args[0])
+	}
+	return usage
+}
+
+func showUser(s *state, name string) error {
+	ctx := context.Background()
+	user, err := s.Db.GetUserByName(ctx, name)
+	if err != nil {
+		return err
+	}
+	stats, err := s.Db.GetUserStats(ctx, user.ID)
+	if err != nil {
+		return err
+	}
+	record := userDetailRecord{
+		ID:           user.ID,
+		Name:         user.Name,
+		CreatedAt:    user.CreatedAt.UTC(),
+		UpdatedAt:    user.UpdatedAt.UTC(),
+		Current:      strings.EqualFold(user.Name, s.currentUserName()),
+		FeedsAdded:   stats.FeedsAdded,
+		Following:    stats.Follows,
+		ReadPosts:    stats.ReadPosts,
+		StarredPosts: stats.StarredPosts,
+	}
+	if s.Output != output.Text {
+		return s.print([]userDetailRecord{record})
+	}
+	current := ""
+	if record.Current {
+		current = " (current)"
+	}
+	fmt.Printf("name: %s%s\n", record.Name, current)
+	fmt.Printf("\tid: %s\n", record.ID)
+	fmt.Printf("\tregistered: %s\n", user.CreatedAt.In(s.Config.Output.Location()).Format("2006-01-02 15:04 MST"))
+	fmt.Printf("\tfeeds added: %d\n", record.FeedsAdded)
+	fmt.Printf("\tfollowing: %d\n", record.Following)
+	fmt.Printf("\tread posts: %d\n", record.ReadPosts)
+	fmt.Printf("\tstarred posts: %d\n", record.StarredPosts)
+	return nil
+}
+
+// renameUser renames a user, keeping them logged in if they were.
+func renameUser(s *state, name, newName string) error {
+	ctx := context.Background()
+	err := validateUserName(newName)
+	if err != nil {
+		return err
+	}
+	user, err := s.Db.GetUserByName(ctx, name)
+	if err != nil {
+		return err
+	}
+	renamed, err := s.Db.RenameUser(ctx, database.RenameUserParams{ID: user.ID, Name: newName, UpdatedAt: time.Now().UTC()})
+	if err != nil {
+		return nameTakenError(newName, err)
+	}
+	if strings.EqualFold(s.Config.Current().CurrentUserName, user.Name) {
+		err = s.Config.SetUser(renamed.Name)
+		if err != nil {
+			return err
+		}
+	}
+	fmt.Printf("Renamed %s to %s\n", user.Name, renamed.Name)
+	return nil
+}
+
+// deleteUser deletes a user like reset user, confirming and saving a
+// snapshot first, and logs them out if they were logged in.
+func deleteUser(s *state, cmd Command, name string) error {
+	ctx := context.Background()
+	user, err := s.Db.GetUserByName(ctx, name)
+	if err != nil {
+		return err
+	}
+	what, err := describeUserDeletion(ctx, s.Db, user)
+	if err != nil {
+		return err
+	}
+	snapshot, err := confirmAndSnapshot(s, cmd, "user-delete", what)
+	if err != nil {
+		return err
+	}
+	err = s.Db.DeleteUser(ctx, user.ID)
+	if err != nil {
+		return err
+	}
+	if strings.EqualFold(s.Config.Current().CurrentUserName, user.Name) {
+		err = s.Config.SetUser("")
+		if err != nil {
+			return err
+		}
+	}
+	fmt.Printf("Deleted %s\n", what)
+	printUndo(snapshot, false)
+	return nil
+}
+
+// describeUserDeletion says what deleting user takes with them, counting
+// what other users lose with the feeds they added: those feeds' posts and
+// the other users' follows of them.
+func describeUserDeletion(ctx context.Context, store storage.Store, user database.User) (string, error) {
+	impact, err := store.GetUserDeletionImpact(ctx, user.ID)
+	if err != nil {
+		return "", err
+	}
+	what := fmt.Sprintf("user %s, with the feeds they added and their follows", user.Name)
+	if impact.OtherFollows > 0 {
+		what += fmt.Sprintf(", and so %d posts and %d follows by %d other users", impact.Posts, impact.OtherFollows, impact.OtherFollowers)
+	} else if impact.Posts > 0 {
+		what += fmt.Sprintf(", and so %d posts", impact.Posts)
+	}
+	return what, nil
+}